@@ -0,0 +1,175 @@
+package neuro
+
+import (
+	"math"
+	"sort"
+)
+
+// bvhLeafSize is the maximal number of faces in a leaf node of a bounding volume hierarchy.
+const bvhLeafSize = 4
+
+// bvhNode is a node of a bounding volume hierarchy, see meshBVH.
+type bvhNode struct {
+	min, max    [3]float32 // the axis-aligned bounding box of all faces below the node
+	left, right int32      // the child nodes, for inner nodes
+	start       int32      // the index of the first face of a leaf in meshBVH.faces
+	count       int32      // the number of faces of a leaf, 0 for inner nodes
+}
+
+// meshBVH is a bounding volume hierarchy over the faces of a mesh, a binary tree of axis-aligned bounding boxes that
+// allows finding the faces near a point or box without testing all faces.
+type meshBVH struct {
+	mesh  Mesh
+	nodes []bvhNode // the nodes, the root is nodes[0]
+	faces []int32   // the face indices, ordered so that the faces of each leaf are contiguous
+}
+
+// faceBounds computes the axis-aligned bounding box of a face.
+func faceBounds(mesh Mesh, face int32) ([3]float32, [3]float32) {
+	var lo, hi [3]float32
+	for j := 0; j < 3; j++ {
+		v := mesh.Faces[face*3+int32(j)] * 3
+		for k := 0; k < 3; k++ {
+			c := mesh.Vertices[v+int32(k)]
+			if j == 0 || c < lo[k] {
+				lo[k] = c
+			}
+			if j == 0 || c > hi[k] {
+				hi[k] = c
+			}
+		}
+	}
+	return lo, hi
+}
+
+// newMeshBVH builds a bounding volume hierarchy over the faces of a mesh, by recursively splitting the faces at the median of
+// their centroids along the longest axis of the bounding box. The mesh must have valid face indices.
+func newMeshBVH(mesh Mesh) *meshBVH {
+	numFaces := NumFaces(mesh)
+	bvh := &meshBVH{mesh: mesh, faces: make([]int32, numFaces)}
+	centroids := make([][3]float32, numFaces)
+	for i := range bvh.faces {
+		bvh.faces[i] = int32(i)
+		for j := 0; j < 3; j++ {
+			v := mesh.Faces[i*3+j] * 3
+			for k := 0; k < 3; k++ {
+				centroids[i][k] += mesh.Vertices[v+int32(k)] / 3
+			}
+		}
+	}
+	if numFaces > 0 {
+		bvh.build(centroids, 0, int32(numFaces))
+	}
+	return bvh
+}
+
+// build creates the node for the faces in bvh.faces[start:end] and its children, and returns its index.
+func (bvh *meshBVH) build(centroids [][3]float32, start int32, end int32) int32 {
+	node := bvhNode{}
+	for i := start; i < end; i++ {
+		lo, hi := faceBounds(bvh.mesh, bvh.faces[i])
+		for k := 0; k < 3; k++ {
+			if i == start || lo[k] < node.min[k] {
+				node.min[k] = lo[k]
+			}
+			if i == start || hi[k] > node.max[k] {
+				node.max[k] = hi[k]
+			}
+		}
+	}
+	idx := int32(len(bvh.nodes))
+	bvh.nodes = append(bvh.nodes, node)
+	if end-start <= bvhLeafSize {
+		bvh.nodes[idx].start, bvh.nodes[idx].count = start, end-start
+		return idx
+	}
+
+	axis := 0
+	for k := 1; k < 3; k++ {
+		if node.max[k]-node.min[k] > node.max[axis]-node.min[axis] {
+			axis = k
+		}
+	}
+	faces := bvh.faces[start:end]
+	sort.Slice(faces, func(a, b int) bool { return centroids[faces[a]][axis] < centroids[faces[b]][axis] })
+	mid := start + (end-start)/2
+	left := bvh.build(centroids, start, mid)
+	right := bvh.build(centroids, mid, end)
+	bvh.nodes[idx].left, bvh.nodes[idx].right = left, right
+	return idx
+}
+
+// boxesOverlap checks whether two axis-aligned bounding boxes overlap, including touching boxes.
+func boxesOverlap(min1 [3]float32, max1 [3]float32, min2 [3]float32, max2 [3]float32) bool {
+	return min1[0] <= max2[0] && min2[0] <= max1[0] && min1[1] <= max2[1] && min2[1] <= max1[1] && min1[2] <= max2[2] && min2[2] <= max1[2]
+}
+
+// overlapping calls fn for each face whose bounding box overlaps the given box.
+func (bvh *meshBVH) overlapping(lo [3]float32, hi [3]float32, fn func(face int32)) {
+	if len(bvh.nodes) == 0 {
+		return
+	}
+	stack := []int32{0}
+	for len(stack) > 0 {
+		node := &bvh.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if !boxesOverlap(lo, hi, node.min, node.max) {
+			continue
+		}
+		if node.count > 0 {
+			for _, face := range bvh.faces[node.start : node.start+node.count] {
+				fmin, fmax := faceBounds(bvh.mesh, face)
+				if boxesOverlap(lo, hi, fmin, fmax) {
+					fn(face)
+				}
+			}
+			continue
+		}
+		stack = append(stack, node.left, node.right)
+	}
+}
+
+// boxDistanceSquared computes the squared distance from a point to an axis-aligned bounding box, 0 if the point is inside.
+func boxDistanceSquared(p [3]float32, lo [3]float32, hi [3]float32) float64 {
+	var d float64
+	for k := 0; k < 3; k++ {
+		if p[k] < lo[k] {
+			d += float64(lo[k]-p[k]) * float64(lo[k]-p[k])
+		} else if p[k] > hi[k] {
+			d += float64(p[k]-hi[k]) * float64(p[k]-hi[k])
+		}
+	}
+	return d
+}
+
+// nearestFace finds the face closest to a point, using faceDist to compute the squared distance from the point to a face.
+// It returns the face index and the squared distance, or -1 and +Inf if the mesh has no faces.
+func (bvh *meshBVH) nearestFace(p [3]float32, faceDist func(face int32) float64) (int32, float64) {
+	best, bestDist := int32(-1), math.Inf(1)
+	if len(bvh.nodes) == 0 {
+		return best, bestDist
+	}
+	stack := []int32{0}
+	for len(stack) > 0 {
+		node := &bvh.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if boxDistanceSquared(p, node.min, node.max) >= bestDist {
+			continue
+		}
+		if node.count > 0 {
+			for _, face := range bvh.faces[node.start : node.start+node.count] {
+				if d := faceDist(face); d < bestDist {
+					best, bestDist = face, d
+				}
+			}
+			continue
+		}
+		// Visit the closer child first, so that more nodes can be skipped.
+		left, right := node.left, node.right
+		if boxDistanceSquared(p, bvh.nodes[left].min, bvh.nodes[left].max) > boxDistanceSquared(p, bvh.nodes[right].min, bvh.nodes[right].max) {
+			left, right = right, left
+		}
+		stack = append(stack, right, left)
+	}
+	return best, bestDist
+}
@@ -0,0 +1,60 @@
+//go:build js && wasm
+
+// Demo application for the neurogo package, compiled to WebAssembly. Parses a FreeSurfer surface file dropped into the browser, entirely client-side.
+//
+// Build it with 'make wasm', which also copies the wasm_exec.js support script from the Go installation. Then serve the cmd/example_wasm directory with any static web server and open index.html.
+// The module registers a global JavaScript function 'neurogoReadSurface(bytes)', which takes the file contents as a Uint8Array
+// and returns an object with the mesh properties and a rendered lateral view as a PNG data URL, or an object with an 'error' field.
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"syscall/js"
+
+	"github.com/dfsp-spirit/neuro"
+)
+
+func readSurface(this js.Value, args []js.Value) any {
+	if len(args) != 1 {
+		return map[string]any{"error": "neurogoReadSurface expects exactly one argument, the file contents as a Uint8Array"}
+	}
+	bs := make([]byte, args[0].Get("length").Int())
+	js.CopyBytesToGo(bs, args[0])
+
+	mesh, err := neuro.ReadFsSurfaceFromReader(bytes.NewReader(bs))
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+
+	result := map[string]any{
+		"numVertices": neuro.NumVertices(mesh),
+		"numFaces":    neuro.NumFaces(mesh),
+	}
+	if stats, err := neuro.MeshStats(mesh); err == nil {
+		result["totalArea"] = stats["totalArea"]
+		result["avgEdgeLength"] = stats["avgEdgeLength"]
+	}
+	if topo, err := neuro.ComputeMeshTopology(mesh); err == nil {
+		result["numComponents"] = topo.NumComponents
+		result["genus"] = topo.Genus
+	}
+
+	img, err := neuro.RenderMeshView(mesh, nil, "lateral", 640, 480)
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	result["png"] = "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData.Bytes())
+	return result
+}
+
+func main() {
+	js.Global().Set("neurogoReadSurface", js.FuncOf(readSurface))
+	select {} // keep the module alive, so the function can be called from JavaScript
+}
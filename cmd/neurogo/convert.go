@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dfsp-spirit/neuro"
+)
+
+// runConvert implements the 'convert' subcommand, which converts a mesh between file formats.
+func runConvert(args []string) error {
+	flagSet := flag.NewFlagSet("convert", flag.ExitOnError)
+	informat := flagSet.String("informat", "auto", "Input mesh format, one of 'fs', 'ply', 'obj', 'stl', 'gii', or 'auto' to determine it from the file extension (files without known extension are treated as FreeSurfer surfaces).")
+	outformat := flagSet.String("outformat", "auto", "Output mesh format, one of 'fs', 'ply', 'obj', 'stl', 'gii', or 'auto' to determine it from the file extension.")
+	asBinary := flagSet.Bool("binary", false, "Write the binary variant of the output format (PLY, STL), or compressed data arrays for GIFTI. The default is ASCII.")
+	overlay := flagSet.String("overlay", "", "Optional per-vertex data file in FreeSurfer curv format (e.g., 'lh.thickness') or MGH/MGZ format (e.g., 'lh.thickness.fwhm10.fsaverage.mgh') used to color the mesh. Only supported for PLY and OBJ output.")
+	colormap := flagSet.String("colormap", "viridis", "Colormap used for the overlay, one of 'viridis', 'gray', 'bwr', 'curv'.")
+	curv := flagSet.String("curv", "", "Optional curvature file in FreeSurfer curv format (e.g., 'lh.curv') used for the binarized gray gyri/sulci background like in FreeSurfer visualizations. Vertices with NaN overlay values show the background. Only supported for PLY and OBJ output.")
+	verbosity := flagSet.Int("verbosity", 0, "Verbosity level: 0 = silent, 1 = info, 2 = debug.")
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage: neurogo convert [flags] <input_mesh> <output_mesh>\n\nExample: neurogo convert -binary -overlay lh.thickness lh.white lh_white_thickness.ply\n\nFlags:\n")
+		flagSet.PrintDefaults()
+	}
+	flagSet.Parse(args)
+
+	if flagSet.NArg() != 2 {
+		flagSet.Usage()
+		os.Exit(2)
+	}
+	neuro.Verbosity = *verbosity
+	infile, outfile := flagSet.Arg(0), flagSet.Arg(1)
+
+	mesh, err := neuro.ImportMesh(infile, *informat)
+	if err != nil {
+		return err
+	}
+
+	colors, err := meshColors(neuro.NumVertices(mesh), *overlay, *curv, *colormap)
+	if err != nil {
+		return err
+	}
+
+	if err := neuro.ExportMesh(mesh, outfile, *outformat, *asBinary, colors); err != nil {
+		return err
+	}
+
+	fmt.Printf("Converted mesh with %d vertices and %d faces from '%s' to '%s'.\n", neuro.NumVertices(mesh), neuro.NumFaces(mesh), infile, outfile)
+	return nil
+}
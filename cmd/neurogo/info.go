@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dfsp-spirit/neuro"
+)
+
+// meshInfo holds the information printed by the 'info' subcommand for a mesh.
+type meshInfo struct {
+	File        string             `json:"file"`
+	Kind        string             `json:"kind"`
+	NumVertices int                `json:"numVertices"`
+	NumFaces    int                `json:"numFaces"`
+	Stats       map[string]float32 `json:"stats"`
+	Topology    neuro.MeshTopology `json:"topology"`
+}
+
+// volumeInfo holds the information printed by the 'info' subcommand for a volume.
+type volumeInfo struct {
+	File       string       `json:"file"`
+	Kind       string       `json:"kind"`
+	Dims       [4]int32     `json:"dims"`
+	DataType   string       `json:"dataType"`
+	VoxelSize  [3]float32   `json:"voxelSize"`
+	HasRasInfo bool         `json:"hasRasInfo"`
+	Vox2Ras    *[16]float32 `json:"vox2ras,omitempty"`
+}
+
+// runInfo implements the 'info' subcommand, which prints information on a mesh or volume file.
+func runInfo(args []string) error {
+	flagSet := flag.NewFlagSet("info", flag.ExitOnError)
+	informat := flagSet.String("informat", "auto", "Input mesh format, one of 'fs', 'ply', 'obj', 'stl', 'gii', or 'auto' to determine it from the file extension. Files with extension '.mgh' or '.mgz' are always treated as volumes.")
+	asJSON := flagSet.Bool("json", false, "Print the information in JSON format instead of human-readable text.")
+	verbosity := flagSet.Int("verbosity", 0, "Verbosity level: 0 = silent, 1 = info, 2 = debug.")
+	flagSet.Usage = func() {
+		fmt.Fprintf(flagSet.Output(), "Usage: neurogo info [flags] <mesh_or_volume_file>\n\nExample: neurogo info -json lh.white\n\nFlags:\n")
+		flagSet.PrintDefaults()
+	}
+	flagSet.Parse(args)
+
+	if flagSet.NArg() != 1 {
+		flagSet.Usage()
+		os.Exit(2)
+	}
+	neuro.Verbosity = *verbosity
+	infile := flagSet.Arg(0)
+
+	ext := strings.ToLower(filepath.Ext(infile))
+	if ext == ".mgh" || ext == ".mgz" {
+		info, err := getVolumeInfo(infile)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(info)
+		}
+		printVolumeInfo(info)
+		return nil
+	}
+
+	info, err := getMeshInfo(infile, *informat)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(info)
+	}
+	printMeshInfo(info)
+	return nil
+}
+
+func getMeshInfo(infile string, informat string) (meshInfo, error) {
+	info := meshInfo{File: infile, Kind: "mesh"}
+	mesh, err := neuro.ImportMesh(infile, informat)
+	if err != nil {
+		return info, err
+	}
+	info.NumVertices = neuro.NumVertices(mesh)
+	info.NumFaces = neuro.NumFaces(mesh)
+	info.Stats, err = neuro.MeshStats(mesh)
+	if err != nil {
+		return info, err
+	}
+	// MeshStats counts 3 edges per face, the number of unique edges is part of the topology.
+	delete(info.Stats, "numEdges")
+	info.Topology, err = neuro.ComputeMeshTopology(mesh)
+	if err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+func getVolumeInfo(infile string) (volumeInfo, error) {
+	info := volumeInfo{File: infile, Kind: "volume"}
+	hdr, err := neuro.ReadFsMghHeader(infile, "auto")
+	if err != nil {
+		return info, err
+	}
+	info.Dims = [4]int32{hdr.Dim1Length, hdr.Dim2Length, hdr.Dim3Length, hdr.Dim4Length}
+	info.DataType, err = neuro.MghDataTypeName(hdr.MghDataType)
+	if err != nil {
+		return info, err
+	}
+	info.HasRasInfo = hdr.RasGoodFlag == 1
+	if info.HasRasInfo {
+		info.VoxelSize = [3]float32{hdr.XSize, hdr.YSize, hdr.ZSize}
+		vox2ras, err := neuro.MghVox2Ras(hdr)
+		if err != nil {
+			return info, err
+		}
+		info.Vox2Ras = &vox2ras
+	}
+	return info, nil
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printMeshInfo(info meshInfo) {
+	topo := info.Topology
+	fmt.Printf("File: %s\n", info.File)
+	fmt.Printf("Mesh with %d vertices, %d faces and %d edges.\n", info.NumVertices, info.NumFaces, topo.NumEdges)
+
+	fmt.Printf("\nStatistics:\n")
+	keys := make([]string, 0, len(info.Stats))
+	for k := range info.Stats {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("  %-14s %g\n", k, info.Stats[k])
+	}
+
+	fmt.Printf("\nTopology:\n")
+	fmt.Printf("  %-22s %t (%d boundary edges)\n", "closed", topo.IsClosed, topo.NumBoundaryEdges)
+	fmt.Printf("  %-22s %t (%d non-manifold edges)\n", "manifold", topo.IsManifold, topo.NumNonManifoldEdges)
+	fmt.Printf("  %-22s %d\n", "connected components", topo.NumComponents)
+	fmt.Printf("  %-22s %d\n", "Euler characteristic", topo.EulerCharacteristic)
+	if topo.Genus >= 0 {
+		fmt.Printf("  %-22s %d\n", "genus", topo.Genus)
+	} else {
+		fmt.Printf("  %-22s undefined (mesh is not a closed manifold)\n", "genus")
+	}
+}
+
+func printVolumeInfo(info volumeInfo) {
+	fmt.Printf("File: %s\n", info.File)
+	fmt.Printf("Volume with dimensions %d x %d x %d x %d.\n", info.Dims[0], info.Dims[1], info.Dims[2], info.Dims[3])
+	fmt.Printf("Data type: %s\n", info.DataType)
+	if !info.HasRasInfo {
+		fmt.Printf("No valid RAS information in header.\n")
+		return
+	}
+	fmt.Printf("Voxel size: %g x %g x %g mm\n", info.VoxelSize[0], info.VoxelSize[1], info.VoxelSize[2])
+	fmt.Printf("vox2ras:\n")
+	for i := 0; i < 4; i++ {
+		fmt.Printf("  %10.4f %10.4f %10.4f %10.4f\n", info.Vox2Ras[i*4], info.Vox2Ras[i*4+1], info.Vox2Ras[i*4+2], info.Vox2Ras[i*4+3])
+	}
+}
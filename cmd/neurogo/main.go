@@ -0,0 +1,123 @@
+// Command line tool for the neurogo package. Provides subcommands for common tasks, so users do not have to write Go code for them.
+//
+// Usage:
+//
+//	neurogo <subcommand> [flags] <arguments>
+//
+// Run 'neurogo help' for a list of subcommands, and 'neurogo <subcommand> -h' for the flags of a subcommand.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dfsp-spirit/neuro"
+)
+
+// subcommand models a subcommand of the neurogo tool.
+type subcommand struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+// subcommands returns all subcommands of the neurogo tool.
+func subcommands() []subcommand {
+	return []subcommand{
+		{"convert", "Convert a mesh between file formats, optionally coloring it by a per-vertex overlay.", runConvert},
+		{"info", "Print information on a mesh (statistics, topology) or volume (dimensions, data type, vox2ras).", runInfo},
+		{"render", "Render lateral, medial and dorsal views of a surface to PNG images, optionally colored by a per-vertex overlay.", runRender},
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: neurogo <subcommand> [flags] <arguments>\n\nSubcommands:\n")
+	for _, cmd := range subcommands() {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'neurogo <subcommand> -h' for the flags of a subcommand.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		usage()
+		if len(os.Args) < 2 {
+			os.Exit(2)
+		}
+		return
+	}
+
+	for _, cmd := range subcommands() {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "neurogo %s: %s\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "neurogo: unknown subcommand '%s'.\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+// readOverlay reads per-vertex data from a file in FreeSurfer curv format, or in MGH/MGZ format if the file extension is '.mgh' or '.mgz'.
+//
+// Parameters:
+//   - overlayFile: path to the file
+//   - numVertices: the number of vertices of the mesh the overlay belongs to
+//
+// Returns:
+//   - []float32: the per-vertex data
+//   - error: an error if the file could not be read or the number of values does not match the mesh
+func readOverlay(overlayFile string, numVertices int) ([]float32, error) {
+	ext := strings.ToLower(filepath.Ext(overlayFile))
+	if ext == ".mgh" || ext == ".mgz" {
+		return neuro.ReadFsMghPerVertex(overlayFile, numVertices)
+	}
+	data, err := neuro.ReadFsCurv(overlayFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != numVertices {
+		return nil, fmt.Errorf("overlay file '%s' contains %d values, but the mesh has %d vertices", overlayFile, len(data), numVertices)
+	}
+	return data, nil
+}
+
+// meshColors computes the per-vertex colors of a mesh from an overlay file and a curvature file used as background.
+//
+// Parameters:
+//   - numVertices: the number of vertices of the mesh
+//   - overlayFile: path to the overlay file, see readOverlay, or an empty string for none
+//   - curvFile: path to a curvature file (e.g., 'lh.curv') for the binarized curvature background, or an empty string for none.
+//     If an overlay is given as well, the background is shown for vertices with NaN overlay values.
+//   - colormap: the colormap used for the overlay, see neuro.OverlayColors
+//
+// Returns:
+//   - []uint8: the colors as a flat array of RGB values, or nil if neither an overlay nor a curvature file is given
+//   - error: an error if one of the files could not be read
+func meshColors(numVertices int, overlayFile string, curvFile string, colormap string) ([]uint8, error) {
+	var background []uint8
+	if len(curvFile) > 0 {
+		curv, err := readOverlay(curvFile, numVertices)
+		if err != nil {
+			return nil, err
+		}
+		background = neuro.CurvatureBackgroundColors(curv)
+	}
+	if len(overlayFile) == 0 {
+		return background, nil
+	}
+	data, err := readOverlay(overlayFile, numVertices)
+	if err != nil {
+		return nil, err
+	}
+	if background != nil {
+		return neuro.OverlayColorsOnBackground(data, colormap, background)
+	}
+	return neuro.OverlayColors(data, colormap)
+}
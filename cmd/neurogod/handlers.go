@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dfsp-spirit/neuro"
+)
+
+// server handles the HTTP requests of the neurogo service.
+type server struct {
+	maxUploadBytes int64 // maximal size of request bodies
+}
+
+// newServer returns the HTTP handler of the service, with all endpoints registered.
+func newServer(maxUploadBytes int64) http.Handler {
+	s := &server{maxUploadBytes: maxUploadBytes}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleIndex)
+	mux.HandleFunc("/convert", s.handleConvert)
+	mux.HandleFunc("/stats", s.handleStats)
+	mux.HandleFunc("/render", s.handleRender)
+	return mux
+}
+
+// meshStatsResponse is the JSON response of the stats endpoint for meshes.
+type meshStatsResponse struct {
+	Kind        string             `json:"kind"`
+	NumVertices int                `json:"numVertices"`
+	NumFaces    int                `json:"numFaces"`
+	Stats       map[string]float32 `json:"stats"`
+	Topology    neuro.MeshTopology `json:"topology"`
+}
+
+// volumeStatsResponse is the JSON response of the stats endpoint for volumes.
+type volumeStatsResponse struct {
+	Kind       string       `json:"kind"`
+	Dims       [4]int32     `json:"dims"`
+	DataType   string       `json:"dataType"`
+	VoxelSize  [3]float32   `json:"voxelSize"`
+	HasRasInfo bool         `json:"hasRasInfo"`
+	Vox2Ras    *[16]float32 `json:"vox2ras,omitempty"`
+}
+
+func (s *server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	fmt.Fprintf(w, "neurogod: send files as the body of POST requests to /convert, /stats or /render. See the package documentation of cmd/neurogod for the query parameters.\n")
+}
+
+func (s *server) handleConvert(w http.ResponseWriter, r *http.Request) {
+	mesh, ok := s.readMesh(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	outformat := query.Get("outformat")
+	if outformat == "" {
+		http.Error(w, "missing query parameter 'outformat'", http.StatusBadRequest)
+		return
+	}
+	asBinary, err := boolParam(query.Get("binary"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	bs, err := neuro.MeshToBytes(mesh, outformat, asBinary, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(bs)
+}
+
+func (s *server) handleStats(w http.ResponseWriter, r *http.Request) {
+	informat := strings.ToLower(r.URL.Query().Get("informat"))
+	if informat == "mgh" || informat == "mgz" {
+		s.handleVolumeStats(w, r, informat)
+		return
+	}
+
+	mesh, ok := s.readMesh(w, r)
+	if !ok {
+		return
+	}
+	resp := meshStatsResponse{Kind: "mesh", NumVertices: neuro.NumVertices(mesh), NumFaces: neuro.NumFaces(mesh)}
+	var err error
+	if resp.Stats, err = neuro.MeshStats(mesh); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// MeshStats counts 3 edges per face, the number of unique edges is part of the topology.
+	delete(resp.Stats, "numEdges")
+	if resp.Topology, err = neuro.ComputeMeshTopology(mesh); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func (s *server) handleVolumeStats(w http.ResponseWriter, r *http.Request, informat string) {
+	body, ok := s.readBody(w, r)
+	if !ok {
+		return
+	}
+	// Only the header is needed, so the voxel data of a possibly compressed upload is never decompressed.
+	hdr, err := neuro.ReadFsMghHeaderFromReader(bytes.NewReader(body), informat)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := volumeStatsResponse{Kind: "volume", Dims: [4]int32{hdr.Dim1Length, hdr.Dim2Length, hdr.Dim3Length, hdr.Dim4Length}, HasRasInfo: hdr.RasGoodFlag == 1}
+	if resp.DataType, err = neuro.MghDataTypeName(hdr.MghDataType); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if resp.HasRasInfo {
+		resp.VoxelSize = [3]float32{hdr.XSize, hdr.YSize, hdr.ZSize}
+		vox2ras, err := neuro.MghVox2Ras(hdr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp.Vox2Ras = &vox2ras
+	}
+	writeJSON(w, resp)
+}
+
+func (s *server) handleRender(w http.ResponseWriter, r *http.Request) {
+	mesh, ok := s.readMesh(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	view := query.Get("view")
+	if view == "" {
+		view = "lateral"
+	}
+	width, err := intParam(query.Get("width"), 800)
+	if err != nil {
+		http.Error(w, "invalid query parameter 'width': "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	height, err := intParam(query.Get("height"), 600)
+	if err != nil {
+		http.Error(w, "invalid query parameter 'height': "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if width > 4096 || height > 4096 {
+		http.Error(w, "image size is limited to 4096x4096 pixels", http.StatusBadRequest)
+		return
+	}
+	perspective, err := boolParam(query.Get("perspective"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	camera, err := neuro.ViewCamera(mesh, view, perspective)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts := neuro.RenderOptions{Width: width, Height: height, Camera: camera, Shading: query.Get("shading"), Background: color.RGBA{255, 255, 255, 255}}
+	img, err := neuro.RenderMeshToImage(mesh, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, img)
+}
+
+// readBody reads the body of a POST request, limited to the maximal upload size. On error, it writes the error response and returns false.
+func (s *server) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST requests are supported, send the file as the request body", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxUploadBytes))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(w, fmt.Sprintf("file too large, the limit is %d bytes", maxBytesError.Limit), http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, "could not read request body: "+err.Error(), http.StatusBadRequest)
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// readMesh parses the request body as a mesh in the format given by the 'informat' query parameter, which defaults to FreeSurfer surface format.
+// On error, it writes the error response and returns false.
+func (s *server) readMesh(w http.ResponseWriter, r *http.Request) (neuro.Mesh, bool) {
+	body, ok := s.readBody(w, r)
+	if !ok {
+		return neuro.Mesh{}, false
+	}
+	informat := r.URL.Query().Get("informat")
+	if informat == "" {
+		informat = "fs"
+	}
+	mesh, err := neuro.MeshFromBytes(body, informat)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return mesh, false
+	}
+	return mesh, true
+}
+
+// boolParam parses an optional boolean query parameter, which defaults to false.
+func boolParam(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid boolean query parameter value '%s'", value)
+	}
+	return b, nil
+}
+
+// intParam parses an optional positive integer query parameter.
+func intParam(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i <= 0 {
+		return 0, fmt.Errorf("must be a positive integer, got '%s'", value)
+	}
+	return i, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/dfsp-spirit/neuro"
+)
+
+func postFile(t *testing.T, handler http.Handler, url string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func cubeFsSurface(t *testing.T) []byte {
+	t.Helper()
+	bs, err := neuro.MeshToBytes(neuro.GenerateCube(), "fs", true, nil)
+	if err != nil {
+		t.Fatalf("could not create cube surface: %s", err)
+	}
+	return bs
+}
+
+func TestConvert(t *testing.T) {
+	rec := postFile(t, newServer(1<<20), "/convert?informat=fs&outformat=ply&binary=true", cubeFsSurface(t))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body.String())
+	}
+	mesh, err := neuro.MeshFromBytes(rec.Body.Bytes(), "ply")
+	if err != nil {
+		t.Fatalf("response is not a valid PLY file: %s", err)
+	}
+	if neuro.NumVertices(mesh) != 8 || neuro.NumFaces(mesh) != 12 {
+		t.Errorf("got %d vertices and %d faces, wanted 8 and 12", neuro.NumVertices(mesh), neuro.NumFaces(mesh))
+	}
+}
+
+func TestStatsMesh(t *testing.T) {
+	rec := postFile(t, newServer(1<<20), "/stats", cubeFsSurface(t))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp meshStatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %s", err)
+	}
+	if resp.NumVertices != 8 || !resp.Topology.IsClosed || resp.Topology.Genus != 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestStatsVolume(t *testing.T) {
+	bs, err := os.ReadFile("../../testdata/brain.mgz")
+	if err != nil {
+		t.Fatalf("could not read test volume: %s", err)
+	}
+	rec := postFile(t, newServer(10<<20), "/stats?informat=mgz", bs)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp volumeStatsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON response: %s", err)
+	}
+	if resp.Dims != [4]int32{256, 256, 256, 1} || resp.DataType != "MRI_UCHAR" || resp.Vox2Ras == nil {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestRender(t *testing.T) {
+	rec := postFile(t, newServer(1<<20), "/render?view=dorsal&width=40&height=30&shading=gouraud", cubeFsSurface(t))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body.String())
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("response is not a valid PNG image: %s", err)
+	}
+	if img.Bounds().Dx() != 40 || img.Bounds().Dy() != 30 {
+		t.Errorf("got image size %v, wanted 40x30", img.Bounds())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	handler := newServer(100)
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET request: got status %d, wanted %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	if rec := postFile(t, handler, "/stats", cubeFsSurface(t)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large upload: got status %d, wanted %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	if rec := postFile(t, handler, "/stats", []byte("not a mesh")); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid mesh: got status %d, wanted %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := postFile(t, handler, "/convert", []byte("x")); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing outformat: got status %d, wanted %d", rec.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,41 @@
+// HTTP service for the neurogo package. Offers mesh format conversion, mesh and volume statistics, and mesh rendering
+// over HTTP, so a lab can run one central service instead of installing tools on every machine.
+//
+// Usage:
+//
+//	neurogod [-addr :8080] [-maxsize 256] [-verbosity 0]
+//
+// The file to process is sent as the raw request body of a POST request, options are passed as query parameters. Endpoints:
+//
+//	POST /convert?informat=fs&outformat=ply&binary=true   convert a mesh, responds with the converted file
+//	POST /stats?informat=fs                               mesh statistics and topology, or volume header information for informat=mgh/mgz, as JSON
+//	POST /render?informat=fs&view=lateral&width=800&height=600&shading=flat&perspective=false   render a mesh view, responds with a PNG image
+//
+// Example: curl --data-binary @lh.white 'http://localhost:8080/convert?informat=fs&outformat=ply' -o lh_white.ply
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dfsp-spirit/neuro"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "Address to listen on, in the form 'host:port'.")
+	maxSize := flag.Int64("maxsize", 256, "Maximal size of uploaded files in MB.")
+	verbosity := flag.Int("verbosity", 0, "Verbosity level of the neurogo package: 0 = silent, 1 = info, 2 = debug.")
+	flag.Parse()
+
+	neuro.Verbosity = *verbosity
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(*maxSize << 20),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("neurogod listening on %s", *addr)
+	log.Fatal(server.ListenAndServe())
+}
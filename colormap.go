@@ -0,0 +1,143 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+)
+
+// colormapStops holds the control points of the supported colormaps, as RGB triplets evenly spaced over the range [0, 1].
+var colormapStops = map[string][][3]float32{
+	"viridis": {
+		{68, 1, 84}, {72, 40, 120}, {62, 74, 137}, {49, 104, 142}, {38, 130, 142},
+		{31, 158, 137}, {53, 183, 121}, {109, 205, 89}, {180, 222, 44}, {253, 231, 37},
+	},
+	"gray": {{0, 0, 0}, {255, 255, 255}},
+	"bwr":  {{0, 0, 255}, {255, 255, 255}, {255, 0, 0}},
+}
+
+// OverlayColors maps per-vertex data (an overlay, like cortical thickness) to per-vertex colors.
+//
+// The data range is mapped linearly onto the colormap, i.e., the minimum value gets the first color and
+// the maximum value the last color. NaN values (e.g., in the medial wall) are colored gray.
+//
+// Parameters:
+//   - data: the per-vertex data, one value per vertex of the mesh
+//   - colormap: the name of the colormap, one of 'viridis' (good default for most data), 'gray', 'bwr' (blue-white-red, for signed data like curvature or effect sizes), or 'curv' (binarized curvature, see CurvatureBackgroundColors)
+//
+// Returns:
+//   - []uint8: the colors, stored as a flat array of RGB values, i.e. [r1, g1, b1, r2, g2, b2, ...]
+//   - error: an error if one occurred, e.g., the colormap is unknown
+func OverlayColors(data []float32, colormap string) ([]uint8, error) {
+	if colormap == "curv" {
+		return CurvatureBackgroundColors(data), nil
+	}
+	stops, ok := colormapStops[colormap]
+	if !ok {
+		return nil, fmt.Errorf("OverlayColors: invalid colormap '%s', use one of 'viridis', 'gray', 'bwr', 'curv'", colormap)
+	}
+
+	var dataMin float32 = math.MaxFloat32
+	var dataMax float32 = -math.MaxFloat32
+	for _, v := range data {
+		if math.IsNaN(float64(v)) {
+			continue
+		}
+		if v < dataMin {
+			dataMin = v
+		}
+		if v > dataMax {
+			dataMax = v
+		}
+	}
+
+	colors := make([]uint8, len(data)*3)
+	for i, v := range data {
+		if math.IsNaN(float64(v)) {
+			colors[i*3], colors[i*3+1], colors[i*3+2] = 128, 128, 128
+			continue
+		}
+		var t float32 = 0.5
+		if dataMax > dataMin {
+			t = (v - dataMin) / (dataMax - dataMin)
+		}
+		c := colormapLookup(stops, t)
+		colors[i*3], colors[i*3+1], colors[i*3+2] = c[0], c[1], c[2]
+	}
+	return colors, nil
+}
+
+// colormapLookup linearly interpolates the color at position t in [0, 1] between the colormap control points.
+func colormapLookup(stops [][3]float32, t float32) [3]uint8 {
+	if t <= 0 {
+		return [3]uint8{uint8(stops[0][0]), uint8(stops[0][1]), uint8(stops[0][2])}
+	}
+	if t >= 1 {
+		last := stops[len(stops)-1]
+		return [3]uint8{uint8(last[0]), uint8(last[1]), uint8(last[2])}
+	}
+	pos := t * float32(len(stops)-1)
+	idx := int(pos)
+	frac := pos - float32(idx)
+	var c [3]uint8
+	for j := 0; j < 3; j++ {
+		c[j] = uint8(stops[idx][j] + frac*(stops[idx+1][j]-stops[idx][j]) + 0.5)
+	}
+	return c
+}
+
+// The gray levels of the binarized curvature map, see CurvatureBackgroundColors.
+const (
+	curvColorGyral  uint8 = 166
+	curvColorSulcal uint8 = 89
+)
+
+// CurvatureBackgroundColors computes the binary light gray / dark gray coloring of a surface used as background in FreeSurfer
+// visualizations, so that gyri and sulci can be told apart on any surface, including the inflated surface.
+//
+// Vertices with positive curvature (sulci, with FreeSurfer's sign convention) are dark gray, all others light gray.
+// NaN values are treated as gyral.
+//
+// Parameters:
+//   - curv: the per-vertex curvature, e.g., from '<subject>/surf/lh.curv' or '<subject>/surf/lh.sulc'
+//
+// Returns:
+//   - []uint8: the colors, stored as a flat array of RGB values, see OverlayColors
+func CurvatureBackgroundColors(curv []float32) []uint8 {
+	colors := make([]uint8, len(curv)*3)
+	for i, v := range curv {
+		gray := curvColorGyral
+		if v > 0 {
+			gray = curvColorSulcal
+		}
+		colors[i*3], colors[i*3+1], colors[i*3+2] = gray, gray, gray
+	}
+	return colors
+}
+
+// OverlayColorsOnBackground maps per-vertex data to colors like OverlayColors, but uses the background colors for vertices
+// with NaN values. This is typically used to show data that is only defined for the cortex on top of a binarized curvature map,
+// so that the medial wall looks like in FreeSurfer visualizations.
+//
+// Parameters:
+//   - data: the per-vertex data, one value per vertex of the mesh. Use NaN for vertices that should show the background.
+//   - colormap: the name of the colormap, see OverlayColors
+//   - background: the background colors, as a flat array of RGB values, e.g., from CurvatureBackgroundColors
+//
+// Returns:
+//   - []uint8: the colors, stored as a flat array of RGB values, see OverlayColors
+//   - error: an error if one occurred, e.g., the colormap is unknown or the number of background colors does not match the data
+func OverlayColorsOnBackground(data []float32, colormap string, background []uint8) ([]uint8, error) {
+	if len(background) != len(data)*3 {
+		return nil, fmt.Errorf("OverlayColorsOnBackground: got %d background color values for %d data values, need 3 per value", len(background), len(data))
+	}
+	colors, err := OverlayColors(data, colormap)
+	if err != nil {
+		return nil, fmt.Errorf("OverlayColorsOnBackground: %s", err)
+	}
+	for i, v := range data {
+		if math.IsNaN(float64(v)) {
+			copy(colors[i*3:i*3+3], background[i*3:i*3+3])
+		}
+	}
+	return colors, nil
+}
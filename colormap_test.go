@@ -0,0 +1,87 @@
+package neuro
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOverlayColors(t *testing.T) {
+	data := []float32{-1.0, 0.0, 1.0, float32(math.NaN())}
+
+	got, err := OverlayColors(data, "bwr")
+	if err != nil {
+		t.Fatalf("OverlayColors failed: %v", err)
+	}
+	want := []uint8{0, 0, 255, 255, 255, 255, 255, 0, 0, 128, 128, 128}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestOverlayColorsInvalidColormap(t *testing.T) {
+	_, err := OverlayColors([]float32{1.0}, "nope")
+	if err == nil {
+		t.Errorf("got no error for invalid colormap, wanted one")
+	}
+}
+
+func TestCurvatureBackgroundColors(t *testing.T) {
+	curv := []float32{-0.2, 0.3, 0.0, float32(math.NaN())}
+	want := []uint8{166, 166, 166, 89, 89, 89, 166, 166, 166, 166, 166, 166}
+	if diff := cmp.Diff(want, CurvatureBackgroundColors(curv)); diff != "" {
+		t.Error(diff)
+	}
+	got, err := OverlayColors(curv, "curv")
+	if err != nil {
+		t.Fatalf("OverlayColors with colormap 'curv' failed: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestOverlayColorsOnBackground(t *testing.T) {
+	data := []float32{-1.0, float32(math.NaN()), 1.0}
+	background := CurvatureBackgroundColors([]float32{0.5, 0.5, 0.5})
+
+	got, err := OverlayColorsOnBackground(data, "bwr", background)
+	if err != nil {
+		t.Fatalf("OverlayColorsOnBackground failed: %v", err)
+	}
+	want := []uint8{0, 0, 255, 89, 89, 89, 255, 0, 0}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := OverlayColorsOnBackground(data, "bwr", background[3:]); err == nil {
+		t.Errorf("got no error for wrong number of background colors, wanted one")
+	}
+}
+
+func TestMeshToBytesColors(t *testing.T) {
+	var myCube Mesh = GenerateCube()
+	colors, _ := OverlayColors(myCube.Vertices[0:NumVertices(myCube)], "viridis")
+
+	for _, format := range []string{"ply", "obj"} {
+		bs, err := MeshToBytes(myCube, format, false, colors)
+		if err != nil {
+			t.Fatalf("MeshToBytes with colors failed for format %s: %v", format, err)
+		}
+		got, err := MeshFromBytes(bs, format)
+		if err != nil {
+			t.Fatalf("MeshFromBytes failed for colored format %s: %v", format, err)
+		}
+		if diff := cmp.Diff(myCube, got); diff != "" {
+			t.Errorf("format %s: %s", format, diff)
+		}
+	}
+
+	if _, err := MeshToBytes(myCube, "stl", false, colors); err == nil {
+		t.Errorf("got no error for STL export with colors, wanted one")
+	}
+	if _, err := MeshToBytes(myCube, "ply", false, colors[3:]); err == nil {
+		t.Errorf("got no error for PLY export with wrong number of colors, wanted one")
+	}
+}
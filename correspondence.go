@@ -0,0 +1,148 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+)
+
+// closestPointOnTriangle computes the point of the triangle (a, b, c) that is closest to p, using the Voronoi region method from Ericson, Real-Time Collision Detection.
+func closestPointOnTriangle(p [3]float64, a [3]float64, b [3]float64, c [3]float64) [3]float64 {
+	along := func(o, e [3]float64, t float64) [3]float64 {
+		return [3]float64{o[0] + t*e[0], o[1] + t*e[1], o[2] + t*e[2]}
+	}
+
+	ab, ac, ap := sub64(b, a), sub64(c, a), sub64(p, a)
+	d1, d2 := dot64(ab, ap), dot64(ac, ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+	bp := sub64(p, b)
+	d3, d4 := dot64(ab, bp), dot64(ac, bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		return along(a, ab, d1/(d1-d3))
+	}
+	cp := sub64(p, c)
+	d5, d6 := dot64(ab, cp), dot64(ac, cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		return along(a, ac, d2/(d2-d6))
+	}
+	va := d3*d6 - d5*d4
+	if va <= 0 && d4-d3 >= 0 && d5-d6 >= 0 {
+		return along(b, sub64(c, b), (d4-d3)/((d4-d3)+(d5-d6)))
+	}
+	denom := va + vb + vc
+	if denom == 0 {
+		return a // degenerate triangle whose vertices are collinear, not reached for valid input
+	}
+	v, w := vb/denom, vc/denom
+	return [3]float64{a[0] + ab[0]*v + ac[0]*w, a[1] + ab[1]*v + ac[1]*w, a[2] + ab[2]*v + ac[2]*w}
+}
+
+// meshVertex64 returns the coordinates of a vertex of a mesh in float64 precision.
+func meshVertex64(mesh Mesh, v int32) [3]float64 {
+	return [3]float64{float64(mesh.Vertices[v*3]), float64(mesh.Vertices[v*3+1]), float64(mesh.Vertices[v*3+2])}
+}
+
+// distanceSquared64 computes the squared Euclidean distance between two points.
+func distanceSquared64(p [3]float64, q [3]float64) float64 {
+	return (p[0]-q[0])*(p[0]-q[0]) + (p[1]-q[1])*(p[1]-q[1]) + (p[2]-q[2])*(p[2]-q[2])
+}
+
+// checkCorrespondenceMeshes checks that the target mesh of a correspondence search has valid faces.
+func checkCorrespondenceMeshes(target Mesh) error {
+	if err := validateFaceIndices(target); err != nil {
+		return fmt.Errorf("invalid target mesh: %s", err)
+	}
+	if NumFaces(target) == 0 {
+		return fmt.Errorf("target mesh has no faces")
+	}
+	return nil
+}
+
+// NearestVertexMap finds, for each vertex of a mesh, the nearest vertex of another mesh in Euclidean distance.
+//
+// This establishes a correspondence between two surfaces, which allows transferring labels and per-vertex data between them:
+// entry i of the result is the vertex of the target mesh whose value should be used for vertex i of the source mesh.
+// Typical use cases are mapping between a decimated and the full-resolution mesh, or between a surface and a modified version of it,
+// e.g., after remeshing. The white and pial surfaces of a subject created by recon-all share their vertex indices, so they need no mapping.
+// See NearestSurfacePoints for mapping to the closest point on the target surface instead of the closest vertex.
+//
+// Parameters:
+//   - source : the mesh whose vertices are mapped. Only its vertices are used.
+//   - target : the mesh the vertices are mapped to. Vertices that are not part of any face are never chosen.
+//
+// Returns:
+//   - []int32 : for each vertex of the source mesh, the index of the nearest vertex of the target mesh
+//   - error : an error if the target mesh has no faces or invalid face indices
+func NearestVertexMap(source Mesh, target Mesh) ([]int32, error) {
+	if err := checkCorrespondenceMeshes(target); err != nil {
+		return nil, fmt.Errorf("NearestVertexMap: %s", err)
+	}
+	bvh := newMeshBVH(target)
+	vertexMap := make([]int32, NumVertices(source))
+	for i := range vertexMap {
+		p := [3]float32{source.Vertices[i*3], source.Vertices[i*3+1], source.Vertices[i*3+2]}
+		p64 := meshVertex64(source, int32(i))
+		nearest, nearestDist := int32(-1), math.Inf(1)
+		// Each vertex lies within the bounding boxes of its faces, so searching the faces for the closest of their vertices finds the nearest vertex.
+		bvh.nearestFace(p, func(face int32) float64 {
+			faceDist := math.Inf(1)
+			for j := int32(0); j < 3; j++ {
+				v := target.Faces[face*3+j]
+				d := distanceSquared64(p64, meshVertex64(target, v))
+				faceDist = math.Min(faceDist, d)
+				if d < nearestDist {
+					nearest, nearestDist = v, d
+				}
+			}
+			return faceDist
+		})
+		vertexMap[i] = nearest
+	}
+	return vertexMap, nil
+}
+
+// NearestSurfacePoints finds, for each vertex of a mesh, the closest point on the surface of another mesh, which may lie anywhere within a face.
+//
+// This is more accurate than NearestVertexMap if the target mesh is coarse, e.g., for measuring the distance between two surfaces,
+// or for interpolating per-vertex data of the target mesh with the barycentric coordinates of the point in its face.
+//
+// Parameters:
+//   - source : the mesh whose vertices are mapped. Only its vertices are used.
+//   - target : the mesh the vertices are mapped to
+//
+// Returns:
+//   - []float32 : the closest points on the target surface, as a flat array [x1, y1, z1, x2, ...] with one point per source vertex
+//   - []int32 : for each source vertex, the index of the target face that contains the closest point
+//   - error : an error if the target mesh has no faces or invalid face indices
+func NearestSurfacePoints(source Mesh, target Mesh) ([]float32, []int32, error) {
+	if err := checkCorrespondenceMeshes(target); err != nil {
+		return nil, nil, fmt.Errorf("NearestSurfacePoints: %s", err)
+	}
+	bvh := newMeshBVH(target)
+	nv := NumVertices(source)
+	points := make([]float32, nv*3)
+	faces := make([]int32, nv)
+	for i := 0; i < nv; i++ {
+		p := [3]float32{source.Vertices[i*3], source.Vertices[i*3+1], source.Vertices[i*3+2]}
+		p64 := meshVertex64(source, int32(i))
+		closestOnFace := func(face int32) [3]float64 {
+			return closestPointOnTriangle(p64, meshVertex64(target, target.Faces[face*3]), meshVertex64(target, target.Faces[face*3+1]), meshVertex64(target, target.Faces[face*3+2]))
+		}
+		face, _ := bvh.nearestFace(p, func(face int32) float64 { return distanceSquared64(p64, closestOnFace(face)) })
+		closest := closestOnFace(face)
+		for k := 0; k < 3; k++ {
+			points[i*3+k] = float32(closest[k])
+		}
+		faces[i] = face
+	}
+	return points, faces, nil
+}
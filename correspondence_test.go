@@ -0,0 +1,109 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClosestPointOnTriangle(t *testing.T) {
+	a, b, c := [3]float64{0, 0, 0}, [3]float64{2, 0, 0}, [3]float64{0, 2, 0}
+	tests := []struct {
+		p, want [3]float64
+	}{
+		{[3]float64{0.5, 0.5, 3}, [3]float64{0.5, 0.5, 0}}, // above the face
+		{[3]float64{-1, -1, 1}, a},                         // closest to a vertex
+		{[3]float64{3, -1, 0}, b},
+		{[3]float64{1, -2, 0}, [3]float64{1, 0, 0}},     // closest to edge ab
+		{[3]float64{2, 2, -1}, [3]float64{1, 1, 0}},     // closest to edge bc
+		{[3]float64{-3, 1.5, 0}, [3]float64{0, 1.5, 0}}, // closest to edge ca
+	}
+	for _, tc := range tests {
+		if diff := cmp.Diff(tc.want, closestPointOnTriangle(tc.p, a, b, c)); diff != "" {
+			t.Errorf("closest point to %v mismatch (-want +got):\n%s", tc.p, diff)
+		}
+	}
+}
+
+func TestNearestVertexMap(t *testing.T) {
+	target := generateGrid(4)
+	source := Mesh{Vertices: []float32{0.1, 0.2, 1, 2.6, 1.4, -1, 10, 10, 0}}
+	vertexMap, err := NearestVertexMap(source, target)
+	if err != nil {
+		t.Fatalf("NearestVertexMap failed: %v", err)
+	}
+	if diff := cmp.Diff([]int32{0, 7, 15}, vertexMap); diff != "" {
+		t.Error(diff)
+	}
+
+	// Mapping a mesh to itself gives the identity.
+	vertexMap, _ = NearestVertexMap(target, target)
+	for i, v := range vertexMap {
+		if int(v) != i {
+			t.Fatalf("got vertex %d for vertex %d when mapping a mesh to itself", v, i)
+		}
+	}
+
+	if _, err := NearestVertexMap(source, Mesh{Vertices: target.Vertices}); err == nil {
+		t.Errorf("got no error for target mesh without faces, wanted one")
+	}
+}
+
+func TestNearestSurfacePointsBrainMesh(t *testing.T) {
+	target, err := ReadFsSurface("testdata/lh.white")
+	if err != nil {
+		t.Fatalf("ReadFsSurface failed: %v", err)
+	}
+	// Query points near the surface, shifted off it.
+	coords, _, _ := SampleSurfacePoints(target, 50, 3)
+	for i := range coords {
+		coords[i] += float32(i%7) - 3
+	}
+	source := Mesh{Vertices: coords}
+
+	points, faces, err := NearestSurfacePoints(source, target)
+	if err != nil {
+		t.Fatalf("NearestSurfacePoints failed: %v", err)
+	}
+	vertexMap, err := NearestVertexMap(source, target)
+	if err != nil {
+		t.Fatalf("NearestVertexMap failed: %v", err)
+	}
+
+	// Compare with a brute force search.
+	for i := 0; i < NumVertices(source); i++ {
+		p := meshVertex64(source, int32(i))
+		bestFaceDist, bestVertexDist := math.Inf(1), math.Inf(1)
+		for f := int32(0); f < int32(NumFaces(target)); f++ {
+			q := closestPointOnTriangle(p, meshVertex64(target, target.Faces[f*3]), meshVertex64(target, target.Faces[f*3+1]), meshVertex64(target, target.Faces[f*3+2]))
+			bestFaceDist = math.Min(bestFaceDist, distanceSquared64(p, q))
+		}
+		for v := int32(0); v < int32(NumVertices(target)); v++ {
+			bestVertexDist = math.Min(bestVertexDist, distanceSquared64(p, meshVertex64(target, v)))
+		}
+		got := distanceSquared64(p, [3]float64{float64(points[i*3]), float64(points[i*3+1]), float64(points[i*3+2])})
+		// The returned points are rounded to float32.
+		if math.Abs(math.Sqrt(got)-math.Sqrt(bestFaceDist)) > 1e-4 {
+			t.Errorf("got squared distance %f to surface for point %d on face %d, brute force gives %f", got, i, faces[i], bestFaceDist)
+		}
+		if got := distanceSquared64(p, meshVertex64(target, vertexMap[i])); got != bestVertexDist {
+			t.Errorf("got squared distance %f to nearest vertex for point %d, brute force gives %f", got, i, bestVertexDist)
+		}
+	}
+}
+
+func ExampleNearestVertexMap() {
+	full, _ := ReadFsSurface("testdata/lh.white")
+	thickness, _ := ReadFsCurv("testdata/lh.thickness")
+	// Transfer the thickness values of the full mesh to points sampled from it, e.g., the vertices of a decimated mesh.
+	coords, _, _ := SampleSurfacePoints(full, 5, 1)
+	vertexMap, _ := NearestVertexMap(Mesh{Vertices: coords}, full)
+	mapped := make([]float32, len(vertexMap))
+	for i, v := range vertexMap {
+		mapped[i] = thickness[v]
+	}
+	fmt.Printf("Mapped %d thickness values.\n", len(mapped))
+	// Output: Mapped 5 thickness values.
+}
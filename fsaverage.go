@@ -0,0 +1,124 @@
+package neuro
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// fsaverageFS holds the embedded fsaverage files, with the directory layout of a FreeSurfer subject ('surf/lh.white', 'label/lh.cortex.label', ...).
+// It is nil unless the package is built with the 'fsaverage' build tag, see fsaverage_embed.go.
+var fsaverageFS fs.FS
+
+// checkFsaverageArgs checks the hemisphere and file name arguments of the fsaverage loaders.
+func checkFsaverageArgs(hemi string, name string) error {
+	if hemi != "lh" && hemi != "rh" {
+		return fmt.Errorf("invalid hemisphere '%s', use one of 'lh' or 'rh'", hemi)
+	}
+	if name == "" || !fs.ValidPath(name) || path.Base(name) != name {
+		return fmt.Errorf("invalid name '%s'", name)
+	}
+	return nil
+}
+
+// LoadFsaverageFromFS loads a surface of the fsaverage template subject from a file system, e.g., from a FreeSurfer installation.
+//
+// Example: to load the white surface of the left hemisphere from a FreeSurfer installation, use
+//
+//	mesh, err := LoadFsaverageFromFS(os.DirFS(filepath.Join(os.Getenv("FREESURFER_HOME"), "subjects", "fsaverage")), "lh", "white")
+//
+// Parameters:
+//   - fsys: the file system, with the directory layout of a FreeSurfer subject, i.e., the surfaces are in the 'surf' directory
+//   - hemi: the hemisphere, one of 'lh' or 'rh'
+//   - surface: the surface name, e.g., 'white', 'pial', 'inflated' or 'sphere'
+//
+// Returns:
+//   - Mesh: the surface. fsaverage surfaces have 163842 vertices.
+//   - error: an error if one occurred, e.g., the file does not exist
+func LoadFsaverageFromFS(fsys fs.FS, hemi string, surface string) (Mesh, error) {
+	if err := checkFsaverageArgs(hemi, surface); err != nil {
+		return Mesh{}, fmt.Errorf("LoadFsaverageFromFS: %s", err)
+	}
+	filename := path.Join("surf", hemi+"."+surface)
+	bs, err := fs.ReadFile(fsys, filename)
+	if err != nil {
+		return Mesh{}, fmt.Errorf("LoadFsaverageFromFS: could not read surface file '%s': %s", filename, err)
+	}
+	mesh, err := readFsSurfaceFromBytes(bs)
+	if err != nil {
+		return Mesh{}, fmt.Errorf("LoadFsaverageFromFS: failed to parse surface file '%s': %s", filename, err)
+	}
+	return mesh, nil
+}
+
+// LoadFsaverageLabelFromFS loads a label of the fsaverage template subject from a file system, see LoadFsaverageFromFS.
+//
+// Parameters:
+//   - fsys: the file system, with the directory layout of a FreeSurfer subject, i.e., the labels are in the 'label' directory
+//   - hemi: the hemisphere, one of 'lh' or 'rh'
+//   - label: the label name, e.g., 'cortex' for the file 'label/lh.cortex.label'
+//
+// Returns:
+//   - FsLabel: the label
+//   - error: an error if one occurred, e.g., the file does not exist
+func LoadFsaverageLabelFromFS(fsys fs.FS, hemi string, label string) (FsLabel, error) {
+	if err := checkFsaverageArgs(hemi, label); err != nil {
+		return FsLabel{}, fmt.Errorf("LoadFsaverageLabelFromFS: %s", err)
+	}
+	filename := path.Join("label", hemi+"."+label+".label")
+	bs, err := fs.ReadFile(fsys, filename)
+	if err != nil {
+		return FsLabel{}, fmt.Errorf("LoadFsaverageLabelFromFS: could not read label file '%s': %s", filename, err)
+	}
+	lines, err := readLinesFromReader(bytes.NewReader(bs))
+	if err != nil {
+		return FsLabel{}, fmt.Errorf("LoadFsaverageLabelFromFS: could not read label file '%s': %s", filename, err)
+	}
+	fsLabel, err := readFsLabelFromLines(lines, filename)
+	if err != nil {
+		return FsLabel{}, fmt.Errorf("LoadFsaverageLabelFromFS: failed to parse label file '%s': %s", filename, err)
+	}
+	return fsLabel, nil
+}
+
+// errFsaverageNotEmbedded is returned by the fsaverage loaders if the package was built without the embedded data.
+var errFsaverageNotEmbedded = fmt.Errorf("fsaverage data is not embedded, build with '-tags fsaverage' (see fsaverage/README.md), or use LoadFsaverageFromFS with a FreeSurfer installation")
+
+// LoadFsaverage loads a surface of the fsaverage template subject from the data embedded into the package.
+//
+// The fsaverage data is only embedded if the package is built with the 'fsaverage' build tag, e.g., 'go build -tags fsaverage', after
+// copying the files into the 'fsaverage' directory of this module, see the README.md file there. The data cannot be redistributed with
+// the module due to the FreeSurfer license, and the module cache is read-only, so this only works when building from a source checkout
+// of this module, e.g., one used via a 'replace' directive in your go.mod. Otherwise, use LoadFsaverageFromFS to load the data from a
+// FreeSurfer installation.
+//
+// Parameters:
+//   - hemi: the hemisphere, one of 'lh' or 'rh'
+//   - surface: the surface name, e.g., 'white', 'pial', 'inflated' or 'sphere'
+//
+// Returns:
+//   - Mesh: the surface
+//   - error: an error if one occurred, e.g., the data is not embedded
+func LoadFsaverage(hemi string, surface string) (Mesh, error) {
+	if fsaverageFS == nil {
+		return Mesh{}, fmt.Errorf("LoadFsaverage: %s", errFsaverageNotEmbedded)
+	}
+	return LoadFsaverageFromFS(fsaverageFS, hemi, surface)
+}
+
+// LoadFsaverageLabel loads a label of the fsaverage template subject, like the cortex label, from the data embedded into the package. See LoadFsaverage.
+//
+// Parameters:
+//   - hemi: the hemisphere, one of 'lh' or 'rh'
+//   - label: the label name, e.g., 'cortex'
+//
+// Returns:
+//   - FsLabel: the label
+//   - error: an error if one occurred, e.g., the data is not embedded
+func LoadFsaverageLabel(hemi string, label string) (FsLabel, error) {
+	if fsaverageFS == nil {
+		return FsLabel{}, fmt.Errorf("LoadFsaverageLabel: %s", errFsaverageNotEmbedded)
+	}
+	return LoadFsaverageLabelFromFS(fsaverageFS, hemi, label)
+}
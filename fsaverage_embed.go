@@ -0,0 +1,19 @@
+//go:build fsaverage
+
+package neuro
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed fsaverage
+var fsaverageEmbedded embed.FS
+
+func init() {
+	sub, err := fs.Sub(fsaverageEmbedded, "fsaverage")
+	if err != nil {
+		panic(err)
+	}
+	fsaverageFS = sub
+}
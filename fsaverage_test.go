@@ -0,0 +1,66 @@
+package neuro
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadFsaverageFromFS(t *testing.T) {
+	cube := GenerateCube()
+	surfaceBytes, err := MeshToBytes(cube, "fs", true, nil)
+	if err != nil {
+		t.Fatalf("MeshToBytes failed: %v", err)
+	}
+	labelBytes, _ := os.ReadFile("testdata/lh.cortex.label")
+	fsys := fstest.MapFS{
+		"surf/lh.white":         {Data: surfaceBytes},
+		"label/lh.cortex.label": {Data: labelBytes},
+	}
+
+	mesh, err := LoadFsaverageFromFS(fsys, "lh", "white")
+	if err != nil {
+		t.Fatalf("LoadFsaverageFromFS failed: %v", err)
+	}
+	if diff := cmp.Diff(cube, mesh); diff != "" {
+		t.Error(diff)
+	}
+
+	label, err := LoadFsaverageLabelFromFS(fsys, "lh", "cortex")
+	if err != nil {
+		t.Fatalf("LoadFsaverageLabelFromFS failed: %v", err)
+	}
+	if len(label.ElementIndex) != 140891 {
+		t.Errorf("got label with %d vertices, want 140891", len(label.ElementIndex))
+	}
+
+	if _, err := LoadFsaverageFromFS(fsys, "rh", "white"); err == nil {
+		t.Errorf("got no error for missing surface file, wanted one")
+	}
+	if _, err := LoadFsaverageFromFS(fsys, "left", "white"); err == nil {
+		t.Errorf("got no error for invalid hemisphere, wanted one")
+	}
+	if _, err := LoadFsaverageLabelFromFS(fsys, "lh", "../cortex"); err == nil {
+		t.Errorf("got no error for invalid label name, wanted one")
+	}
+
+	fsys["label/rh.cortex.label"] = &fstest.MapFile{Data: []byte("#!ascii label\nnot a number\n")}
+	if _, err := LoadFsaverageLabelFromFS(fsys, "rh", "cortex"); err == nil || !strings.HasPrefix(err.Error(), "LoadFsaverageLabelFromFS:") {
+		t.Errorf("got error %v for invalid label file, wanted one from LoadFsaverageLabelFromFS", err)
+	}
+}
+
+func TestLoadFsaverageNotEmbedded(t *testing.T) {
+	if fsaverageFS != nil {
+		t.Skip("fsaverage data is embedded")
+	}
+	if _, err := LoadFsaverage("lh", "white"); err == nil {
+		t.Errorf("got no error without embedded data, wanted one")
+	}
+	if _, err := LoadFsaverageLabel("lh", "cortex"); err == nil {
+		t.Errorf("got no error without embedded data, wanted one")
+	}
+}
@@ -0,0 +1,141 @@
+package neuro
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+)
+
+// dijkstraItem is an entry of the priority queue used by meshDijkstra.
+type dijkstraItem struct {
+	vertex int32
+	dist   float64
+}
+
+// dijkstraQueue is a min-heap of vertices by distance, see container/heap.
+type dijkstraQueue []dijkstraItem
+
+func (q dijkstraQueue) Len() int            { return len(q) }
+func (q dijkstraQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q dijkstraQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *dijkstraQueue) Push(x interface{}) { *q = append(*q, x.(dijkstraItem)) }
+func (q *dijkstraQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// edgeLength computes the Euclidean distance between two vertices of a mesh.
+func edgeLength(mesh Mesh, a int32, b int32) float64 {
+	dx := float64(mesh.Vertices[a*3] - mesh.Vertices[b*3])
+	dy := float64(mesh.Vertices[a*3+1] - mesh.Vertices[b*3+1])
+	dz := float64(mesh.Vertices[a*3+2] - mesh.Vertices[b*3+2])
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// meshDijkstra runs Dijkstra's algorithm on the edge graph of a mesh, with edge lengths as weights.
+//
+// Parameters:
+//   - mesh : the mesh
+//   - source : the index of the source vertex
+//   - target : the index of a target vertex at which the search stops early, or -1 to compute distances to all vertices
+//
+// Returns:
+//   - []float64 : for each vertex, the distance from the source. +Inf for vertices that are not reachable, or have not been reached before the target.
+//   - []int32 : for each vertex, the previous vertex on the shortest path from the source, or -1 for the source and unreached vertices
+func meshDijkstra(mesh Mesh, source int32, target int32) ([]float64, []int32) {
+	neighbors := VertexNeighbors(mesh)
+	dist := make([]float64, len(neighbors))
+	prev := make([]int32, len(neighbors))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		prev[i] = -1
+	}
+	dist[source] = 0
+	queue := &dijkstraQueue{{vertex: source, dist: 0}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(dijkstraItem)
+		if item.dist > dist[item.vertex] {
+			continue // stale entry, the vertex was reached on a shorter path already
+		}
+		if item.vertex == target {
+			break
+		}
+		for _, n := range neighbors[item.vertex] {
+			d := item.dist + edgeLength(mesh, item.vertex, n)
+			if d < dist[n] {
+				dist[n] = d
+				prev[n] = item.vertex
+				heap.Push(queue, dijkstraItem{vertex: n, dist: d})
+			}
+		}
+	}
+	return dist, prev
+}
+
+// checkVertexIndex returns an error if a vertex index is out of range for a mesh.
+func checkVertexIndex(mesh Mesh, vertex int32) error {
+	if vertex < 0 || int(vertex) >= NumVertices(mesh) {
+		return fmt.Errorf("vertex index %d out of range, mesh has %d vertices", vertex, NumVertices(mesh))
+	}
+	return nil
+}
+
+// GeodesicDistances computes the geodesic distance from a source vertex to all vertices of a mesh.
+//
+// The distances are computed along the edges of the mesh (Dijkstra's algorithm), so they slightly overestimate the
+// true geodesic distance on the surface. For brain meshes with their small, irregular triangles the error is small.
+//
+// Parameters:
+//   - mesh : the mesh
+//   - source : the index of the source vertex
+//
+// Returns:
+//   - []float32 : for each vertex, the distance from the source, in the unit of the vertex coordinates (mm for FreeSurfer surfaces). +Inf for vertices in other connected components.
+//   - error : an error if the source vertex index is out of range
+func GeodesicDistances(mesh Mesh, source int32) ([]float32, error) {
+	if err := checkVertexIndex(mesh, source); err != nil {
+		return nil, fmt.Errorf("GeodesicDistances: source %s", err)
+	}
+	dist, _ := meshDijkstra(mesh, source, -1)
+	distances := make([]float32, len(dist))
+	for i, d := range dist {
+		distances[i] = float32(d)
+	}
+	return distances, nil
+}
+
+// GeodesicPath computes the shortest path along the mesh edges between two vertices, e.g., to measure the along-surface
+// distance between two anatomical landmarks. See GeodesicDistances for the accuracy.
+//
+// Parameters:
+//   - mesh : the mesh
+//   - source : the index of the start vertex
+//   - target : the index of the end vertex
+//
+// Returns:
+//   - []int32 : the indices of the vertices on the path, starting with source and ending with target
+//   - float32 : the length of the path, in the unit of the vertex coordinates (mm for FreeSurfer surfaces)
+//   - error : an error if a vertex index is out of range, or the target cannot be reached from the source
+func GeodesicPath(mesh Mesh, source int32, target int32) ([]int32, float32, error) {
+	if err := checkVertexIndex(mesh, source); err != nil {
+		return nil, 0, fmt.Errorf("GeodesicPath: source %s", err)
+	}
+	if err := checkVertexIndex(mesh, target); err != nil {
+		return nil, 0, fmt.Errorf("GeodesicPath: target %s", err)
+	}
+	dist, prev := meshDijkstra(mesh, source, target)
+	if math.IsInf(dist[target], 1) {
+		return nil, 0, fmt.Errorf("GeodesicPath: vertex %d cannot be reached from vertex %d, they are in different connected components", target, source)
+	}
+
+	var path []int32
+	for v := target; v != -1; v = prev[v] {
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, float32(dist[target]), nil
+}
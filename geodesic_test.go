@@ -0,0 +1,108 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// generateGrid creates a flat square grid mesh in the xy plane with n x n vertices and spacing 1.
+func generateGrid(n int) Mesh {
+	var mesh Mesh
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			mesh.Vertices = append(mesh.Vertices, float32(x), float32(y), 0)
+		}
+	}
+	for y := 0; y < n-1; y++ {
+		for x := 0; x < n-1; x++ {
+			v := int32(y*n + x)
+			mesh.Faces = append(mesh.Faces, v, v+1, v+int32(n)+1, v, v+int32(n)+1, v+int32(n))
+		}
+	}
+	return mesh
+}
+
+func TestGeodesicPath(t *testing.T) {
+	mesh := generateGrid(4)
+
+	path, length, err := GeodesicPath(mesh, 0, 15)
+	if err != nil {
+		t.Fatalf("GeodesicPath failed: %v", err)
+	}
+	// The grid has diagonal edges from (x, y) to (x+1, y+1), so the shortest path follows the diagonal.
+	if diff := cmp.Diff([]int32{0, 5, 10, 15}, path); diff != "" {
+		t.Error(diff)
+	}
+	if math.Abs(float64(length)-3*math.Sqrt2) > 1e-5 {
+		t.Errorf("got path length %f, want %f", length, 3*math.Sqrt2)
+	}
+
+	path, length, err = GeodesicPath(mesh, 3, 3)
+	if err != nil || len(path) != 1 || length != 0 {
+		t.Errorf("got path %v with length %f and error %v from a vertex to itself", path, length, err)
+	}
+}
+
+func TestGeodesicPathErrors(t *testing.T) {
+	mesh := generateGrid(3)
+	if _, _, err := GeodesicPath(mesh, 0, 9); err == nil {
+		t.Errorf("got no error for out of range target vertex, wanted one")
+	}
+	if _, _, err := GeodesicPath(mesh, -1, 0); err == nil {
+		t.Errorf("got no error for negative source vertex, wanted one")
+	}
+
+	// Add an isolated vertex, which cannot be reached.
+	mesh.Vertices = append(mesh.Vertices, 10, 10, 10)
+	if _, _, err := GeodesicPath(mesh, 0, 9); err == nil {
+		t.Errorf("got no error for unreachable target vertex, wanted one")
+	}
+}
+
+func TestGeodesicDistances(t *testing.T) {
+	mesh := generateGrid(3)
+	mesh.Vertices = append(mesh.Vertices, 10, 10, 10)
+
+	dist, err := GeodesicDistances(mesh, 0)
+	if err != nil {
+		t.Fatalf("GeodesicDistances failed: %v", err)
+	}
+	if dist[0] != 0 || dist[2] != 2 || math.Abs(float64(dist[8])-2*math.Sqrt2) > 1e-5 {
+		t.Errorf("got unexpected distances %v", dist)
+	}
+	if !math.IsInf(float64(dist[9]), 1) {
+		t.Errorf("got distance %f for unreachable vertex, want +Inf", dist[9])
+	}
+}
+
+func TestGeodesicPathBrainMesh(t *testing.T) {
+	mesh, err := ReadFsSurface("testdata/lh.white")
+	if err != nil {
+		t.Fatalf("ReadFsSurface failed: %v", err)
+	}
+	path, length, err := GeodesicPath(mesh, 0, 100000)
+	if err != nil {
+		t.Fatalf("GeodesicPath failed: %v", err)
+	}
+	// The path cannot be shorter than the straight line between its end points, and must consist of mesh edges.
+	if straight := edgeLength(mesh, 0, 100000); float64(length) < straight {
+		t.Errorf("got path length %f shorter than Euclidean distance %f", length, straight)
+	}
+	var sum float64
+	for i := 1; i < len(path); i++ {
+		sum += edgeLength(mesh, path[i-1], path[i])
+	}
+	if math.Abs(sum-float64(length)) > 1e-3 {
+		t.Errorf("got path length %f, but sum of edge lengths is %f", length, sum)
+	}
+}
+
+func ExampleGeodesicPath() {
+	mesh, _ := ReadFsSurface("testdata/lh.white")
+	path, length, _ := GeodesicPath(mesh, 0, 1000)
+	fmt.Printf("The path has %d vertices and a length of %.1f mm.\n", len(path), length)
+	// Output: The path has 12 vertices and a length of 10.5 mm.
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"bufio"
+	"io"
 )
 
 // Write a string to a text file.
@@ -16,9 +17,7 @@ import (
 //  - error: an error if one occurred
 func strToTextFile(s string, filepath string) (error) {
 
-	if Verbosity >= 2 {
-		fmt.Printf("Writing string of length %d to file '%s'.\n", len(s), filepath)
-	}
+	logDebug("Writing string of length %d to file '%s'.", len(s), filepath)
 
 	f, err := os.Create(filepath)
     if err != nil {
@@ -34,9 +33,7 @@ func strToTextFile(s string, filepath string) (error) {
 		return err
 	}
 
-	if Verbosity >= 2 {
-    	fmt.Printf("Wrote %d bytes to text file '%s'\n", numBytesWritten, filepath)
-	}
+	logDebug("Wrote %d bytes to text file '%s'.", numBytesWritten, filepath)
 
 	f.Sync()
 
@@ -58,9 +55,20 @@ func readLines(path string) ([]string, error) {
         return nil, err
     }
     defer file.Close()
+    return readLinesFromReader(file)
+}
 
+// readLinesFromReader reads all lines from a reader.
+//
+// Parameters:
+//  - r: the reader
+//
+// Returns:
+//  - lines: a slice of strings, each string is a line
+//  - error: an error if one occurred
+func readLinesFromReader(r io.Reader) ([]string, error) {
     var lines []string
-    scanner := bufio.NewScanner(file)
+    scanner := bufio.NewScanner(r)
     for scanner.Scan() {
         lines = append(lines, scanner.Text())
     }
@@ -0,0 +1,127 @@
+package neuro
+
+import (
+	"fmt"
+)
+
+// LabelToMask converts a label into a per-vertex mask, i.e., a bool slice that is true for the vertices that are part of the label.
+//
+// Masks can be combined with MaskUnion, MaskIntersection and MaskComplement to compose regions of interest, and converted back with MaskToLabel.
+//
+// Parameters:
+//   - label: the label, e.g., from ReadFsLabel or AnnotRegionToLabel
+//   - numVertices: the number of vertices of the mesh the label belongs to
+//
+// Returns:
+//   - []bool: the mask, of length numVertices
+//   - error: an error if the label contains a vertex index that is out of range for the mesh
+func LabelToMask(label FsLabel, numVertices int) ([]bool, error) {
+	if numVertices < 0 {
+		return nil, fmt.Errorf("LabelToMask: invalid number of vertices %d", numVertices)
+	}
+	mask := make([]bool, numVertices)
+	for _, v := range label.ElementIndex {
+		if v < 0 || int(v) >= numVertices {
+			return nil, fmt.Errorf("LabelToMask: label contains vertex index %d, but the mesh has %d vertices", v, numVertices)
+		}
+		mask[v] = true
+	}
+	return mask, nil
+}
+
+// MaskToLabel converts a per-vertex mask into a label containing the vertices for which the mask is true.
+//
+// Parameters:
+//   - mask: the mask, one value per vertex of the mesh
+//   - mesh: the mesh the mask belongs to. Its vertex coordinates are stored in the label. Pass an empty Mesh{} to set all coordinates to 0.
+//
+// Returns:
+//   - FsLabel: the label. The values are all 0.
+//   - error: an error if the mesh is not empty and the mask length does not match its number of vertices
+func MaskToLabel(mask []bool, mesh Mesh) (FsLabel, error) {
+	useCoords := len(mesh.Vertices) > 0
+	if useCoords && len(mask) != NumVertices(mesh) {
+		return FsLabel{}, fmt.Errorf("MaskToLabel: mask has %d values, but the mesh has %d vertices", len(mask), NumVertices(mesh))
+	}
+	var label FsLabel
+	for i, inLabel := range mask {
+		if !inLabel {
+			continue
+		}
+		var x, y, z float32
+		if useCoords {
+			x, y, z = mesh.Vertices[i*3], mesh.Vertices[i*3+1], mesh.Vertices[i*3+2]
+		}
+		label.ElementIndex = append(label.ElementIndex, int32(i))
+		label.CoordX = append(label.CoordX, x)
+		label.CoordY = append(label.CoordY, y)
+		label.CoordZ = append(label.CoordZ, z)
+		label.Value = append(label.Value, 0)
+	}
+	return label, nil
+}
+
+// combineMasks combines masks of equal length element-wise with the given operation.
+func combineMasks(masks [][]bool, op func(a bool, b bool) bool) ([]bool, error) {
+	if len(masks) == 0 {
+		return nil, fmt.Errorf("no masks given")
+	}
+	result := make([]bool, len(masks[0]))
+	copy(result, masks[0])
+	for i, mask := range masks[1:] {
+		if len(mask) != len(result) {
+			return nil, fmt.Errorf("mask %d has %d values, but mask 0 has %d", i+1, len(mask), len(result))
+		}
+		for j, v := range mask {
+			result[j] = op(result[j], v)
+		}
+	}
+	return result, nil
+}
+
+// MaskUnion computes the union of masks, i.e., a mask that is true for all vertices that are part of at least one of the masks.
+//
+// Parameters:
+//   - masks: the masks, all of the same length
+//
+// Returns:
+//   - []bool: the union
+//   - error: an error if no masks are given or their lengths differ
+func MaskUnion(masks ...[]bool) ([]bool, error) {
+	result, err := combineMasks(masks, func(a bool, b bool) bool { return a || b })
+	if err != nil {
+		return nil, fmt.Errorf("MaskUnion: %s", err)
+	}
+	return result, nil
+}
+
+// MaskIntersection computes the intersection of masks, i.e., a mask that is true for all vertices that are part of all masks.
+//
+// Parameters:
+//   - masks: the masks, all of the same length
+//
+// Returns:
+//   - []bool: the intersection
+//   - error: an error if no masks are given or their lengths differ
+func MaskIntersection(masks ...[]bool) ([]bool, error) {
+	result, err := combineMasks(masks, func(a bool, b bool) bool { return a && b })
+	if err != nil {
+		return nil, fmt.Errorf("MaskIntersection: %s", err)
+	}
+	return result, nil
+}
+
+// MaskComplement computes the complement of a mask, e.g., the medial wall from a cortex mask.
+//
+// Parameters:
+//   - mask: the mask
+//
+// Returns:
+//   - []bool: a mask that is true for all vertices that are not part of the input mask
+func MaskComplement(mask []bool) []bool {
+	result := make([]bool, len(mask))
+	for i, v := range mask {
+		result[i] = !v
+	}
+	return result
+}
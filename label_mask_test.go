@@ -0,0 +1,84 @@
+package neuro
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLabelToMask(t *testing.T) {
+	label := FsLabel{ElementIndex: []int32{0, 3}}
+	mask, err := LabelToMask(label, 5)
+	if err != nil {
+		t.Fatalf("LabelToMask failed: %v", err)
+	}
+	if diff := cmp.Diff([]bool{true, false, false, true, false}, mask); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := LabelToMask(label, 3); err == nil {
+		t.Errorf("got no error for label with out of range vertex index, wanted one")
+	}
+	if _, err := VertexIsPartOfLabel(FsLabel{ElementIndex: []int32{7}}, 5); err == nil {
+		t.Errorf("VertexIsPartOfLabel: got no error for label with out of range vertex index, wanted one")
+	}
+}
+
+func TestMaskAlgebra(t *testing.T) {
+	a := []bool{true, true, false, false}
+	b := []bool{true, false, true, false}
+
+	union, err := MaskUnion(a, b)
+	if err != nil {
+		t.Fatalf("MaskUnion failed: %v", err)
+	}
+	if diff := cmp.Diff([]bool{true, true, true, false}, union); diff != "" {
+		t.Error(diff)
+	}
+
+	intersection, err := MaskIntersection(a, b)
+	if err != nil {
+		t.Fatalf("MaskIntersection failed: %v", err)
+	}
+	if diff := cmp.Diff([]bool{true, false, false, false}, intersection); diff != "" {
+		t.Error(diff)
+	}
+
+	if diff := cmp.Diff([]bool{false, false, true, true}, MaskComplement(a)); diff != "" {
+		t.Error(diff)
+	}
+	if a[0] != true {
+		t.Errorf("input mask was modified")
+	}
+
+	if _, err := MaskUnion(a, b[1:]); err == nil {
+		t.Errorf("got no error for masks of different length, wanted one")
+	}
+	if _, err := MaskIntersection(); err == nil {
+		t.Errorf("got no error for no masks, wanted one")
+	}
+}
+
+func TestMaskToLabel(t *testing.T) {
+	mesh := Mesh{Vertices: []float32{0, 0, 0, 1, 2, 3, 4, 5, 6}, Faces: []int32{0, 1, 2}}
+	label, err := MaskToLabel([]bool{false, true, true}, mesh)
+	if err != nil {
+		t.Fatalf("MaskToLabel failed: %v", err)
+	}
+	want := FsLabel{ElementIndex: []int32{1, 2}, CoordX: []float32{1, 4}, CoordY: []float32{2, 5}, CoordZ: []float32{3, 6}, Value: []float32{0, 0}}
+	if diff := cmp.Diff(want, label); diff != "" {
+		t.Error(diff)
+	}
+	if _, err := MaskToLabel([]bool{true}, mesh); err == nil {
+		t.Errorf("got no error for mask length not matching the mesh, wanted one")
+	}
+}
+
+func ExampleMaskComplement() {
+	label, _ := ReadFsLabel("testdata/lh.cortex.label")
+	cortex, _ := LabelToMask(label, 149244)
+	medialWall, _ := MaskToLabel(MaskComplement(cortex), Mesh{})
+	fmt.Printf("The medial wall contains %d vertices.\n", len(medialWall.ElementIndex))
+	// Output: The medial wall contains 8353 vertices.
+}
@@ -0,0 +1,65 @@
+package neuro
+
+import (
+	"log"
+	"os"
+)
+
+// Logger is the interface used by the neuro package to emit informational and debug messages.
+//
+// The standard library *log.Logger satisfies this interface, so you can simply pass something like
+// log.New(os.Stderr, "neuro: ", log.LstdFlags) to SetLogger. Messages are only emitted if the
+// package Verbosity is high enough, so the package is silent by default.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
+// discardLogger is a Logger that drops all messages.
+type discardLogger struct{}
+
+// Printf implements Logger and does nothing.
+func (discardLogger) Printf(format string, v ...any) {}
+
+// logger is the Logger currently used by the package. Set it with SetLogger.
+var logger Logger = log.New(os.Stdout, "", 0)
+
+// SetLogger sets the Logger that receives the messages of the neuro package.
+//
+// By default, messages go to STDOUT, but only if Verbosity is larger than 0 (the default is 0, so nothing is printed).
+// Libraries embedding neuro can use this to route messages into their own logging infrastructure.
+//
+// Parameters:
+//   - l : the logger to use. Pass nil to discard all messages, regardless of Verbosity.
+func SetLogger(l Logger) {
+	if l == nil {
+		logger = discardLogger{}
+		return
+	}
+	logger = l
+}
+
+// SetVerbose is a convenience function to switch between silent mode (Verbosity 0) and verbose mode (Verbosity 1).
+//
+// Parameters:
+//   - verbose : whether to print informational messages. Use the Verbosity variable directly for debug output.
+func SetVerbose(verbose bool) {
+	if verbose {
+		Verbosity = 1
+	} else {
+		Verbosity = 0
+	}
+}
+
+// logInfo emits an informational message if Verbosity is at least 1.
+func logInfo(format string, v ...any) {
+	if Verbosity >= 1 {
+		logger.Printf(format, v...)
+	}
+}
+
+// logDebug emits a debug message if Verbosity is at least 2.
+func logDebug(format string, v ...any) {
+	if Verbosity >= 2 {
+		logger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,47 @@
+package neuro
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestLoggerSilentByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(log.New(&buf, "", 0))
+	defer SetLogger(log.New(os.Stdout, "", 0))
+
+	var surfFile string = "testdata/lh.white"
+	ReadFsSurface(surfFile)
+
+	if buf.Len() != 0 {
+		t.Errorf("got %d bytes of log output with Verbosity %d, wanted none", buf.Len(), Verbosity)
+	}
+}
+
+func TestLoggerVerbose(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(log.New(&buf, "", 0))
+	SetVerbose(true)
+	defer SetLogger(log.New(os.Stdout, "", 0))
+	defer SetVerbose(false)
+
+	var curvFile string = "testdata/lh.thickness"
+	ReadFsCurv(curvFile)
+
+	if !bytes.Contains(buf.Bytes(), []byte("ReadFsCurv: NumVertices: 149244")) {
+		t.Errorf("got log output '%s', wanted it to contain the number of vertices", buf.String())
+	}
+}
+
+func TestLoggerNilDiscards(t *testing.T) {
+	SetLogger(nil)
+	Verbosity = 2
+	defer SetLogger(log.New(os.Stdout, "", 0))
+	defer func() { Verbosity = 0 }()
+
+	if _, err := ToPlyFormat(GenerateCube()); err != nil {
+		t.Errorf("got error %s with discarding logger", err)
+	}
+}
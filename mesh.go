@@ -78,11 +78,9 @@ func MeshStats(mesh Mesh) (map[string]float32, error) {
 	stats["maxY"] = max_y
 	stats["maxZ"] = max_z
 
-	if Verbosity >= 2 {
-		fmt.Printf("MeshStats: max_x: %f, max_y: %f, max_z: %f\n", max_x, max_y, max_z)
-		fmt.Printf("MeshStats: min_x: %f, min_y: %f, min_z: %f\n", min_x, min_y, min_z)
-		fmt.Printf("MeshStats: numVertices: %d, numFaces: %d\n", int(stats["numVertices"]), int(stats["numFaces"]))
-	}
+	logDebug("MeshStats: max_x: %f, max_y: %f, max_z: %f", max_x, max_y, max_z)
+	logDebug("MeshStats: min_x: %f, min_y: %f, min_z: %f", min_x, min_y, min_z)
+	logDebug("MeshStats: numVertices: %d, numFaces: %d", int(stats["numVertices"]), int(stats["numFaces"]))
 
 	stats["minX"] = min_x
 	stats["minY"] = min_y
@@ -139,10 +137,25 @@ func MeshStats(mesh Mesh) (map[string]float32, error) {
 //   - string : the mesh string representation in PLY format
 //   - error  : the error if one occured, or nil otherwise
 func ToPlyFormat(mesh Mesh) (string, error) {
+	return ToPlyFormatWithColors(mesh, nil)
+}
+
+// Convert a mesh with per-vertex colors to PLY format.
+//
+// Parameters:
+//   - mesh   : the mesh to convert
+//   - colors : the per-vertex colors as a flat array of RGB values, i.e. [r1, g1, b1, r2, g2, b2, ...], see OverlayColors. Pass nil to omit colors.
+//
+// Returns:
+//   - string : the mesh string representation in PLY format
+//   - error  : the error if one occured, e.g., the number of colors does not match the number of vertices, or nil otherwise
+func ToPlyFormatWithColors(mesh Mesh, colors []uint8) (string, error) {
 
-	if Verbosity >= 2 {
-		fmt.Printf("Generating PLY representation for mesh with %d vertices and %d faces.\n", len(mesh.Vertices)/3, len(mesh.Faces)/3)
+	if colors != nil && len(colors) != len(mesh.Vertices) {
+		return "", fmt.Errorf("ToPlyFormatWithColors: got %d color values for %d vertices, need 3 per vertex", len(colors), len(mesh.Vertices)/3)
 	}
+
+	logDebug("Generating PLY representation for mesh with %d vertices and %d faces.", len(mesh.Vertices)/3, len(mesh.Faces)/3)
 	var ply strings.Builder
 	ply.WriteString("ply\n")
 	ply.WriteString("format ascii 1.0\n")
@@ -151,12 +164,21 @@ func ToPlyFormat(mesh Mesh) (string, error) {
 	ply.WriteString("property float x\n")
 	ply.WriteString("property float y\n")
 	ply.WriteString("property float z\n")
+	if colors != nil {
+		ply.WriteString("property uchar red\n")
+		ply.WriteString("property uchar green\n")
+		ply.WriteString("property uchar blue\n")
+	}
 	ply.WriteString(fmt.Sprintf("element face %d\n", len(mesh.Faces)/3))
 	ply.WriteString("property list uchar int vertex_indices\n")
 	ply.WriteString("end_header\n")
 
 	for i := 0; i < len(mesh.Vertices); i += 3 {
-		ply.WriteString(fmt.Sprintf("%f %f %f\n", mesh.Vertices[i], mesh.Vertices[i+1], mesh.Vertices[i+2]))
+		if colors != nil {
+			ply.WriteString(fmt.Sprintf("%f %f %f %d %d %d\n", mesh.Vertices[i], mesh.Vertices[i+1], mesh.Vertices[i+2], colors[i], colors[i+1], colors[i+2]))
+		} else {
+			ply.WriteString(fmt.Sprintf("%f %f %f\n", mesh.Vertices[i], mesh.Vertices[i+1], mesh.Vertices[i+2]))
+		}
 	}
 
 	for i := 0; i < len(mesh.Faces); i += 3 {
@@ -175,15 +197,37 @@ func ToPlyFormat(mesh Mesh) (string, error) {
 //   - string : the mesh string representation in OBJ format
 //   - error  : the error if one occured, or nil otherwise
 func ToObjFormat(mesh Mesh) (string, error) {
+	return ToObjFormatWithColors(mesh, nil)
+}
 
-	if Verbosity >= 2 {
-		fmt.Printf("Generating OBJ representation for mesh with %d vertices and %d faces.\n", len(mesh.Vertices)/3, len(mesh.Faces)/3)
+// Convert a mesh with per-vertex colors to OBJ format.
+//
+// OBJ has no official support for vertex colors, but the widely supported extension of appending
+// RGB values in range [0, 1] to the vertex lines is used, which is understood by MeshLab, Blender and others.
+//
+// Parameters:
+//   - mesh   : the mesh to convert
+//   - colors : the per-vertex colors as a flat array of RGB values, i.e. [r1, g1, b1, r2, g2, b2, ...], see OverlayColors. Pass nil to omit colors.
+//
+// Returns:
+//   - string : the mesh string representation in OBJ format
+//   - error  : the error if one occured, e.g., the number of colors does not match the number of vertices, or nil otherwise
+func ToObjFormatWithColors(mesh Mesh, colors []uint8) (string, error) {
+
+	if colors != nil && len(colors) != len(mesh.Vertices) {
+		return "", fmt.Errorf("ToObjFormatWithColors: got %d color values for %d vertices, need 3 per vertex", len(colors), len(mesh.Vertices)/3)
 	}
 
+	logDebug("Generating OBJ representation for mesh with %d vertices and %d faces.", len(mesh.Vertices)/3, len(mesh.Faces)/3)
+
 	var obj strings.Builder
 	obj.WriteString("# neurogo\n")
 	for i := 0; i < len(mesh.Vertices); i += 3 {
-		obj.WriteString(fmt.Sprintf("v %f %f %f\n", mesh.Vertices[i], mesh.Vertices[i+1], mesh.Vertices[i+2]))
+		if colors != nil {
+			obj.WriteString(fmt.Sprintf("v %f %f %f %f %f %f\n", mesh.Vertices[i], mesh.Vertices[i+1], mesh.Vertices[i+2], float32(colors[i])/255.0, float32(colors[i+1])/255.0, float32(colors[i+2])/255.0))
+		} else {
+			obj.WriteString(fmt.Sprintf("v %f %f %f\n", mesh.Vertices[i], mesh.Vertices[i+1], mesh.Vertices[i+2]))
+		}
 	}
 
 	for i := 0; i < len(mesh.Faces); i += 3 {
@@ -203,9 +247,7 @@ func ToObjFormat(mesh Mesh) (string, error) {
 //   - error  : the error if one occured, or nil otherwise
 func ToStlFormat(mesh Mesh) (string, error) {
 
-	if Verbosity >= 2 {
-		fmt.Printf("Generating STL representation for mesh with %d vertices and %d faces.\n", len(mesh.Vertices)/3, len(mesh.Faces)/3)
-	}
+	logDebug("Generating STL representation for mesh with %d vertices and %d faces.", len(mesh.Vertices)/3, len(mesh.Faces)/3)
 
 	var stl strings.Builder
 	stl.WriteString("solid neurogo\n")
@@ -369,3 +411,25 @@ func GenerateSphere(radius float32, slices int, stacks int) Mesh {
 
 	return mesh
 }
+
+// faceNormal computes the unit normal of a face of a triangular mesh, using the right-hand rule on the face vertex order.
+//
+// Parameters:
+//   - mesh : the mesh
+//   - face : the index of the face
+//
+// Returns:
+//   - [3]float32 : the normal. All zeros for degenerate faces.
+func faceNormal(mesh Mesh, face int) [3]float32 {
+	v0 := mesh.Faces[face*3] * 3
+	v1 := mesh.Faces[face*3+1] * 3
+	v2 := mesh.Faces[face*3+2] * 3
+	e1 := [3]float32{mesh.Vertices[v1] - mesh.Vertices[v0], mesh.Vertices[v1+1] - mesh.Vertices[v0+1], mesh.Vertices[v1+2] - mesh.Vertices[v0+2]}
+	e2 := [3]float32{mesh.Vertices[v2] - mesh.Vertices[v0], mesh.Vertices[v2+1] - mesh.Vertices[v0+1], mesh.Vertices[v2+2] - mesh.Vertices[v0+2]}
+	n := [3]float32{e1[1]*e2[2] - e1[2]*e2[1], e1[2]*e2[0] - e1[0]*e2[2], e1[0]*e2[1] - e1[1]*e2[0]}
+	length := float32(math.Sqrt(float64(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])))
+	if length == 0 {
+		return [3]float32{0, 0, 0}
+	}
+	return [3]float32{n[0] / length, n[1] / length, n[2] / length}
+}
@@ -0,0 +1,137 @@
+package neuro
+
+import (
+	"math"
+)
+
+// Mesh64 is a variant of Mesh with float64 vertex coordinates, for workflows where float32 rounding matters, e.g., when applying long chains of affine transforms.
+//
+// Use ToMesh64 and Mesh64ToMesh to convert between the two precisions. Functions that read or write files use Mesh.
+//
+// Fields:
+//   - Vertices : the vertex coordinates, as a flat array [x1, y1, z1, x2, ...]
+//   - Faces    : the faces, as a flat array of vertex indices, 3 per face. The first vertex has index 0.
+type Mesh64 struct {
+	Vertices []float64
+	Faces    []int32
+}
+
+// ToMesh64 converts a mesh to float64 precision.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - Mesh64 : the mesh with float64 coordinates. The faces are copied, so the meshes do not share memory.
+func ToMesh64(mesh Mesh) Mesh64 {
+	m := Mesh64{Vertices: make([]float64, len(mesh.Vertices)), Faces: make([]int32, len(mesh.Faces))}
+	for i, v := range mesh.Vertices {
+		m.Vertices[i] = float64(v)
+	}
+	copy(m.Faces, mesh.Faces)
+	return m
+}
+
+// Mesh64ToMesh converts a float64 mesh back to float32 precision, e.g., for writing it to a file.
+//
+// Parameters:
+//   - m : the float64 mesh
+//
+// Returns:
+//   - Mesh : the mesh with float32 coordinates, rounded to the nearest float32 value. The faces are copied.
+func Mesh64ToMesh(m Mesh64) Mesh {
+	mesh := Mesh{Vertices: make([]float32, len(m.Vertices)), Faces: make([]int32, len(m.Faces))}
+	for i, v := range m.Vertices {
+		mesh.Vertices[i] = float32(v)
+	}
+	copy(mesh.Faces, m.Faces)
+	return mesh
+}
+
+// Mesh64Area computes the total surface area of a float64 mesh, i.e., the sum of the areas of all faces, in float64 precision.
+//
+// Parameters:
+//   - m : the mesh
+//
+// Returns:
+//   - float64 : the total area, in the squared unit of the vertex coordinates (mm^2 for FreeSurfer surfaces)
+func Mesh64Area(m Mesh64) float64 {
+	var total float64
+	for i := 0; i < len(m.Faces); i += 3 {
+		a, b, c := m.Faces[i]*3, m.Faces[i+1]*3, m.Faces[i+2]*3
+		total += triangleArea64([3]float64(m.Vertices[a:a+3]), [3]float64(m.Vertices[b:b+3]), [3]float64(m.Vertices[c:c+3]))
+	}
+	return total
+}
+
+// TransformMesh64 applies an affine transformation to the vertices of a float64 mesh.
+//
+// Parameters:
+//   - m      : the mesh
+//   - affine : the 4x4 transformation matrix in row-major order, like the vox2ras matrix returned by MghVox2Ras. Use ComposeAffine64 to chain transforms.
+//
+// Returns:
+//   - Mesh64 : the transformed mesh. The faces are shared with the input mesh.
+func TransformMesh64(m Mesh64, affine [16]float64) Mesh64 {
+	out := Mesh64{Vertices: make([]float64, len(m.Vertices)), Faces: m.Faces}
+	for i := 0; i < len(m.Vertices); i += 3 {
+		x, y, z := m.Vertices[i], m.Vertices[i+1], m.Vertices[i+2]
+		for row := 0; row < 3; row++ {
+			out.Vertices[i+row] = affine[row*4]*x + affine[row*4+1]*y + affine[row*4+2]*z + affine[row*4+3]
+		}
+	}
+	return out
+}
+
+// ComposeAffine64 multiplies two 4x4 affine transformation matrices in row-major order, so that the result applies b first and then a.
+//
+// Composing a chain of transforms once and applying the result with TransformMesh64 is faster and more accurate than transforming the mesh several times.
+//
+// Parameters:
+//   - a : the transform applied second
+//   - b : the transform applied first
+//
+// Returns:
+//   - [16]float64 : the matrix product a * b
+func ComposeAffine64(a [16]float64, b [16]float64) [16]float64 {
+	var c [16]float64
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			for k := 0; k < 4; k++ {
+				c[row*4+col] += a[row*4+k] * b[k*4+col]
+			}
+		}
+	}
+	return c
+}
+
+// sub64 returns the difference u - v of two vectors.
+func sub64(u [3]float64, v [3]float64) [3]float64 {
+	return [3]float64{u[0] - v[0], u[1] - v[1], u[2] - v[2]}
+}
+
+// cross64 returns the cross product of two vectors.
+func cross64(u [3]float64, v [3]float64) [3]float64 {
+	return [3]float64{u[1]*v[2] - u[2]*v[1], u[2]*v[0] - u[0]*v[2], u[0]*v[1] - u[1]*v[0]}
+}
+
+// dot64 returns the dot product of two vectors.
+func dot64(u [3]float64, v [3]float64) float64 {
+	return u[0]*v[0] + u[1]*v[1] + u[2]*v[2]
+}
+
+// faceCross returns the cross product of the edges of a triangle, i.e., its normal scaled by twice its area.
+func faceCross(p0 [3]float64, p1 [3]float64, p2 [3]float64) [3]float64 {
+	return cross64(sub64(p1, p0), sub64(p2, p0))
+}
+
+// triangleArea64 returns the area of a triangle.
+func triangleArea64(p0 [3]float64, p1 [3]float64, p2 [3]float64) float64 {
+	n := faceCross(p0, p1, p2)
+	return 0.5 * math.Sqrt(dot64(n, n))
+}
+
+// faceArea64 returns the area of face f of a mesh, computed in float64 precision.
+func faceArea64(mesh Mesh, f int) float64 {
+	return triangleArea64(meshVertex64(mesh, mesh.Faces[f*3]), meshVertex64(mesh, mesh.Faces[f*3+1]), meshVertex64(mesh, mesh.Faces[f*3+2]))
+}
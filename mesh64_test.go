@@ -0,0 +1,70 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMesh64Conversion(t *testing.T) {
+	cube := GenerateCube()
+	m := ToMesh64(cube)
+	if len(m.Vertices) != len(cube.Vertices) || m.Vertices[3] != float64(cube.Vertices[3]) {
+		t.Errorf("ToMesh64 returned unexpected vertices %v", m.Vertices)
+	}
+	m.Faces[0] = 7
+	if cube.Faces[0] == 7 {
+		t.Errorf("ToMesh64 shares the faces with the input mesh")
+	}
+	m.Faces[0] = cube.Faces[0]
+	if diff := cmp.Diff(cube, Mesh64ToMesh(m)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestMesh64Area(t *testing.T) {
+	m := Mesh64{Vertices: []float64{0, 0, 0, 2, 0, 0, 0, 3, 0}, Faces: []int32{0, 1, 2}}
+	if area := Mesh64Area(m); area != 3 {
+		t.Errorf("got area %f, want 3", area)
+	}
+
+	mesh, _ := ReadFsSurface("testdata/lh.white")
+	stats, _ := MeshStats(mesh)
+	area := Mesh64Area(ToMesh64(mesh))
+	if math.Abs(area-float64(stats["totalArea"]))/area > 1e-3 {
+		t.Errorf("got area %f, but MeshStats reports %f", area, stats["totalArea"])
+	}
+}
+
+func TestTransformMesh64ChainedRotations(t *testing.T) {
+	m := ToMesh64(GenerateCube())
+	angle := 2 * math.Pi / 1000
+	rotation := [16]float64{math.Cos(angle), -math.Sin(angle), 0, 0, math.Sin(angle), math.Cos(angle), 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
+
+	// Rotating 1000 times by 1/1000 of a full turn must give the original mesh.
+	rotated := m
+	for i := 0; i < 1000; i++ {
+		rotated = TransformMesh64(rotated, rotation)
+	}
+	for i, v := range rotated.Vertices {
+		if math.Abs(v-m.Vertices[i]) > 1e-9 {
+			t.Fatalf("vertex coordinate %d is %f after a full turn, want %f", i, v, m.Vertices[i])
+		}
+	}
+
+	translation := [16]float64{1, 0, 0, 10, 0, 1, 0, 20, 0, 0, 1, 30, 0, 0, 0, 1}
+	composed := ComposeAffine64(translation, rotation)
+	want := TransformMesh64(TransformMesh64(m, rotation), translation)
+	got := TransformMesh64(m, composed)
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(a, b float64) bool { return math.Abs(a-b) < 1e-12 })); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func ExampleMesh64Area() {
+	mesh, _ := ReadFsSurface("testdata/lh.white")
+	fmt.Printf("Total surface area: %.1f mm^2\n", Mesh64Area(ToMesh64(mesh)))
+	// Output: Total surface area: 99852.5 mm^2
+}
@@ -0,0 +1,178 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+)
+
+// jacobiEigen3 computes the eigenvalues and eigenvectors of a symmetric 3x3 matrix with the cyclic Jacobi method.
+//
+// Returns the eigenvalues in decreasing order, and the matching unit eigenvectors as the rows of the second return value.
+func jacobiEigen3(a [3][3]float64) ([3]float64, [3][3]float64) {
+	v := [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} // the columns of v are the eigenvectors
+	for sweep := 0; sweep < 50; sweep++ {
+		off := a[0][1]*a[0][1] + a[0][2]*a[0][2] + a[1][2]*a[1][2]
+		if off < 1e-30*(a[0][0]*a[0][0]+a[1][1]*a[1][1]+a[2][2]*a[2][2]) || off == 0 {
+			break
+		}
+		for p := 0; p < 2; p++ {
+			for q := p + 1; q < 3; q++ {
+				if a[p][q] == 0 {
+					continue
+				}
+				// Rotate in the (p, q) plane so that a[p][q] becomes 0.
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < 3; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p], a[k][q] = c*akp-s*akq, s*akp+c*akq
+				}
+				for k := 0; k < 3; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k], a[q][k] = c*apk-s*aqk, s*apk+c*aqk
+				}
+				for k := 0; k < 3; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p], v[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+
+	order := [3]int{0, 1, 2}
+	for i := 0; i < 3; i++ {
+		for j := i + 1; j < 3; j++ {
+			if a[order[j]][order[j]] > a[order[i]][order[i]] {
+				order[i], order[j] = order[j], order[i]
+			}
+		}
+	}
+	var values [3]float64
+	var vectors [3][3]float64
+	for i, o := range order {
+		values[i] = a[o][o]
+		for k := 0; k < 3; k++ {
+			vectors[i][k] = v[k][o]
+		}
+	}
+	return values, vectors
+}
+
+// PrincipalAxes computes the centroid and the principal axes of the vertices of a mesh, i.e., the eigenvectors of the covariance matrix of the vertex coordinates.
+//
+// The signs of the axes are chosen so that the result does not depend on the position and orientation of the mesh:
+// the first two axes point in the direction in which the vertex distribution is skewed (the direction of the longer tail),
+// and the third axis is their cross product, so the axes form a right-handed coordinate system.
+// For meshes that are symmetric along an axis, the sign of that axis is arbitrary.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - [3]float64 : the centroid of the vertices
+//   - [3][3]float64 : the principal axes as unit vectors, one per row, ordered by decreasing variance
+//   - [3]float64 : the variance of the vertex coordinates along each axis
+//   - error : an error if the mesh has no vertices
+func PrincipalAxes(mesh Mesh) ([3]float64, [3][3]float64, [3]float64, error) {
+	var centroid [3]float64
+	var axes [3][3]float64
+	var variances [3]float64
+	nv := NumVertices(mesh)
+	if nv == 0 {
+		return centroid, axes, variances, fmt.Errorf("PrincipalAxes: mesh has no vertices")
+	}
+	for i := 0; i < nv*3; i += 3 {
+		for k := 0; k < 3; k++ {
+			centroid[k] += float64(mesh.Vertices[i+k])
+		}
+	}
+	for k := 0; k < 3; k++ {
+		centroid[k] /= float64(nv)
+	}
+
+	var cov [3][3]float64
+	for i := 0; i < nv*3; i += 3 {
+		d := [3]float64{float64(mesh.Vertices[i]) - centroid[0], float64(mesh.Vertices[i+1]) - centroid[1], float64(mesh.Vertices[i+2]) - centroid[2]}
+		for r := 0; r < 3; r++ {
+			for c := r; c < 3; c++ {
+				cov[r][c] += d[r] * d[c]
+			}
+		}
+	}
+	for r := 0; r < 3; r++ {
+		for c := r; c < 3; c++ {
+			cov[r][c] /= float64(nv)
+			cov[c][r] = cov[r][c]
+		}
+	}
+	variances, axes = jacobiEigen3(cov)
+
+	// Fix the signs of the first two axes by the third central moment of the vertex coordinates along them.
+	for a := 0; a < 2; a++ {
+		var skew float64
+		for i := 0; i < nv*3; i += 3 {
+			p := (float64(mesh.Vertices[i])-centroid[0])*axes[a][0] + (float64(mesh.Vertices[i+1])-centroid[1])*axes[a][1] + (float64(mesh.Vertices[i+2])-centroid[2])*axes[a][2]
+			skew += p * p * p
+		}
+		if skew < 0 {
+			axes[a] = [3]float64{-axes[a][0], -axes[a][1], -axes[a][2]}
+		}
+	}
+	axes[2] = [3]float64{
+		axes[0][1]*axes[1][2] - axes[0][2]*axes[1][1],
+		axes[0][2]*axes[1][0] - axes[0][0]*axes[1][2],
+		axes[0][0]*axes[1][1] - axes[0][1]*axes[1][0],
+	}
+	return centroid, axes, variances, nil
+}
+
+// PrincipalAxesTransform computes the rigid transform that moves the centroid of a mesh to the origin and rotates its principal axes
+// (see PrincipalAxes) onto the x, y and z axes, in that order.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - [16]float64 : the 4x4 transformation matrix in row-major order, see TransformMesh64
+//   - error : an error if the mesh has no vertices
+func PrincipalAxesTransform(mesh Mesh) ([16]float64, error) {
+	centroid, axes, _, err := PrincipalAxes(mesh)
+	if err != nil {
+		return [16]float64{}, fmt.Errorf("PrincipalAxesTransform: %s", err)
+	}
+	var affine [16]float64
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			affine[row*4+col] = axes[row][col]
+			affine[row*4+3] -= axes[row][col] * centroid[col]
+		}
+	}
+	affine[15] = 1
+	return affine, nil
+}
+
+// AlignToPrincipalAxes brings a mesh into a canonical position and orientation, by centering it at the origin and rotating its
+// principal axes onto the coordinate axes, see PrincipalAxesTransform.
+//
+// This is useful to normalize meshes with arbitrary orientation, e.g., imported from other software, and as a robust
+// initialization before fine registration with methods like ICP.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - Mesh : the aligned mesh. The faces are copied.
+//   - [16]float64 : the applied transform, in row-major order. Its inverse maps the aligned mesh back to the original position.
+//   - error : an error if the mesh has no vertices
+func AlignToPrincipalAxes(mesh Mesh) (Mesh, [16]float64, error) {
+	affine, err := PrincipalAxesTransform(mesh)
+	if err != nil {
+		return Mesh{}, affine, fmt.Errorf("AlignToPrincipalAxes: %s", err)
+	}
+	return Mesh64ToMesh(TransformMesh64(ToMesh64(mesh), affine)), affine, nil
+}
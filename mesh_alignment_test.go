@@ -0,0 +1,70 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestJacobiEigen3(t *testing.T) {
+	values, vectors := jacobiEigen3([3][3]float64{{2, 1, 0}, {1, 2, 0}, {0, 0, 5}})
+	for i, want := range []float64{5, 3, 1} {
+		if math.Abs(values[i]-want) > 1e-12 {
+			t.Errorf("got eigenvalue %f at index %d, want %f", values[i], i, want)
+		}
+	}
+	if math.Abs(math.Abs(vectors[1][0])-math.Sqrt(0.5)) > 1e-12 || math.Abs(vectors[1][0]-vectors[1][1]) > 1e-12 {
+		t.Errorf("got eigenvector %v for eigenvalue 3, want +-(1, 1, 0)/sqrt(2)", vectors[1])
+	}
+}
+
+func TestAlignToPrincipalAxesIsCanonical(t *testing.T) {
+	mesh, err := ReadFsSurface("testdata/lh.white")
+	if err != nil {
+		t.Fatalf("ReadFsSurface failed: %v", err)
+	}
+	aligned, affine, err := AlignToPrincipalAxes(mesh)
+	if err != nil {
+		t.Fatalf("AlignToPrincipalAxes failed: %v", err)
+	}
+	// The rotation part must be a proper rotation, i.e., have determinant 1.
+	det := affine[0]*(affine[5]*affine[10]-affine[6]*affine[9]) - affine[1]*(affine[4]*affine[10]-affine[6]*affine[8]) + affine[2]*(affine[4]*affine[9]-affine[5]*affine[8])
+	if math.Abs(det-1) > 1e-9 {
+		t.Errorf("got determinant %f for rotation, want 1", det)
+	}
+
+	centroid, _, variances, _ := PrincipalAxes(aligned)
+	for k := 0; k < 3; k++ {
+		if math.Abs(centroid[k]) > 1e-3 {
+			t.Errorf("got centroid %v for aligned mesh, want origin", centroid)
+		}
+	}
+	if !(variances[0] > variances[1] && variances[1] > variances[2]) {
+		t.Errorf("got variances %v, want decreasing values", variances)
+	}
+
+	// Rotating and moving the mesh must not change its canonical position.
+	rotation := [16]float64{0, -0.6, 0.8, 10, 1, 0, 0, -20, 0, 0.8, 0.6, 5, 0, 0, 0, 1}
+	moved := Mesh64ToMesh(TransformMesh64(ToMesh64(mesh), rotation))
+	alignedMoved, _, _ := AlignToPrincipalAxes(moved)
+	var maxDiff float64
+	for i := range aligned.Vertices {
+		maxDiff = math.Max(maxDiff, math.Abs(float64(aligned.Vertices[i]-alignedMoved.Vertices[i])))
+	}
+	if maxDiff > 1e-2 {
+		t.Errorf("got maximal coordinate difference %f between aligned meshes, want 0", maxDiff)
+	}
+}
+
+func TestPrincipalAxesEmptyMesh(t *testing.T) {
+	if _, _, err := AlignToPrincipalAxes(Mesh{}); err == nil {
+		t.Errorf("got no error for empty mesh, wanted one")
+	}
+}
+
+func ExamplePrincipalAxes() {
+	mesh, _ := ReadFsSurface("testdata/lh.white")
+	_, _, variances, _ := PrincipalAxes(mesh)
+	fmt.Printf("Standard deviation along the principal axes: %.1f mm, %.1f mm, %.1f mm\n", math.Sqrt(variances[0]), math.Sqrt(variances[1]), math.Sqrt(variances[2]))
+	// Output: Standard deviation along the principal axes: 43.3 mm, 23.8 mm, 15.9 mm
+}
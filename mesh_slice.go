@@ -0,0 +1,199 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+)
+
+// SliceContour is a polyline where a plane intersects a mesh, see SliceMeshWithPlane.
+type SliceContour struct {
+	Points []float32 // The points of the polyline in order, as a flat array [x1, y1, z1, x2, ...]. For closed contours, the first point is not repeated at the end.
+	Closed bool      // Whether the contour is a closed loop. Contours are open where the plane leaves the mesh through a boundary, e.g., a hole.
+}
+
+// sliceSegment is the part of a slice contour within a face, between the intersection points on two of its edges, see SliceMeshWithPlane.
+type sliceSegment struct {
+	from, to int // the indices of the intersection points
+	visited  bool
+}
+
+// SliceMeshWithPlane intersects a mesh with a plane, and returns the cross-section as contours, i.e., polylines in 3D space that lie in the plane.
+//
+// This can be used for drawing the outline of a surface on a volume slice, or for measuring the perimeter of a cross-section, see ContourLength.
+// For a closed mesh like a brain hemisphere, all contours are closed. The contours follow the orientation of the majority of their faces, so if
+// the face normals point outwards, outer contours run counter-clockwise around the plane normal, and contours around holes in the cross-section run clockwise.
+//
+// Vertices lying exactly in the plane are treated as being on the side the normal points to, so faces lying in the plane produce no contour.
+//
+// Parameters:
+//   - mesh : the mesh
+//   - point : a point on the plane
+//   - normal : the normal vector of the plane. It does not need to have unit length.
+//
+// Returns:
+//   - []SliceContour : the contours, empty if the plane does not intersect the mesh
+//   - error : an error if the mesh has invalid face indices, or the normal is the zero vector
+func SliceMeshWithPlane(mesh Mesh, point [3]float32, normal [3]float32) ([]SliceContour, error) {
+	if err := validateFaceIndices(mesh); err != nil {
+		return nil, fmt.Errorf("SliceMeshWithPlane: invalid mesh: %s", err)
+	}
+	n := [3]float64{float64(normal[0]), float64(normal[1]), float64(normal[2])}
+	length := math.Sqrt(dot64(n, n))
+	if length == 0 {
+		return nil, fmt.Errorf("SliceMeshWithPlane: plane normal must not be the zero vector")
+	}
+	p := [3]float64{float64(point[0]), float64(point[1]), float64(point[2])}
+
+	dist := make([]float64, NumVertices(mesh))
+	for v := range dist {
+		q := meshVertex64(mesh, int32(v))
+		dist[v] = ((q[0]-p[0])*n[0] + (q[1]-p[1])*n[1] + (q[2]-p[2])*n[2]) / length
+	}
+	above := func(v int32) bool { return dist[v] >= 0 }
+
+	// Each mesh edge that crosses the plane yields one intersection point, shared by the faces of the edge.
+	pointIndex := map[[2]int32]int{}
+	var points [][3]float64
+	edgePoint := func(a int32, b int32) int {
+		key := meshEdgeKey(a, b)
+		if idx, ok := pointIndex[key]; ok {
+			return idx
+		}
+		t := dist[key[0]] / (dist[key[0]] - dist[key[1]])
+		qa, qb := meshVertex64(mesh, key[0]), meshVertex64(mesh, key[1])
+		points = append(points, [3]float64{qa[0] + t*(qb[0]-qa[0]), qa[1] + t*(qb[1]-qa[1]), qa[2] + t*(qb[2]-qa[2])})
+		pointIndex[key] = len(points) - 1
+		return len(points) - 1
+	}
+
+	// Within a face, the segment runs from the edge where the face boundary enters the upper side to the edge where it leaves it. Adjacent faces
+	// traverse their shared edge in opposite directions, so their segments connect head to tail.
+	var segments []sliceSegment
+	for f := 0; f < NumFaces(mesh); f++ {
+		entry, exit := -1, -1
+		for j := 0; j < 3; j++ {
+			a, b := mesh.Faces[f*3+j], mesh.Faces[f*3+(j+1)%3]
+			if above(a) == above(b) {
+				continue
+			}
+			if above(a) {
+				exit = edgePoint(a, b)
+			} else {
+				entry = edgePoint(a, b)
+			}
+		}
+		if entry >= 0 && exit >= 0 {
+			segments = append(segments, sliceSegment{from: entry, to: exit})
+		}
+	}
+
+	// The segments are chained without regard to their direction, so meshes with inconsistently oriented faces yield complete contours as well.
+	incident := make([][]int, len(points))
+	for i, s := range segments {
+		incident[s.from] = append(incident[s.from], i)
+		incident[s.to] = append(incident[s.to], i)
+	}
+	walk := func(start int) ([]int, int) {
+		chain, numForward := []int{start}, 0
+		current := start
+		for {
+			next := -1
+			for _, i := range incident[current] {
+				if !segments[i].visited {
+					next = i
+					break
+				}
+			}
+			if next < 0 {
+				return chain, numForward
+			}
+			segments[next].visited = true
+			if segments[next].from == current {
+				current = segments[next].to
+				numForward++
+			} else {
+				current = segments[next].from
+			}
+			chain = append(chain, current)
+		}
+	}
+	var contours []SliceContour
+	addContour := func(start int) {
+		chain, numForward := walk(start)
+		numSegments := len(chain) - 1
+		closed := numSegments > 1 && chain[numSegments] == chain[0]
+		if closed {
+			chain = chain[:numSegments]
+		}
+		// Orient the contour like the majority of its segments.
+		if 2*numForward < numSegments {
+			for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+				chain[i], chain[j] = chain[j], chain[i]
+			}
+		}
+		contours = append(contours, sliceContour(points, chain, closed))
+	}
+
+	// Open contours start at points with an odd number of segments, i.e., at boundary edges. All remaining segments form loops.
+	for start := range points {
+		for len(incident[start])%2 == 1 && hasUnvisitedSegment(segments, incident[start]) {
+			addContour(start)
+		}
+	}
+	for i := range segments {
+		if !segments[i].visited {
+			addContour(segments[i].from)
+		}
+	}
+	return contours, nil
+}
+
+// hasUnvisitedSegment returns whether any of the given segments has not been visited yet.
+func hasUnvisitedSegment(segments []sliceSegment, indices []int) bool {
+	for _, i := range indices {
+		if !segments[i].visited {
+			return true
+		}
+	}
+	return false
+}
+
+// sliceContour creates a contour from a chain of intersection points, dropping consecutive duplicate points.
+// Duplicates occur where the contour passes through a vertex that lies exactly in the plane.
+func sliceContour(points [][3]float64, chain []int, closed bool) SliceContour {
+	contour := SliceContour{Closed: closed}
+	var last [3]float64
+	for i, idx := range chain {
+		q := points[idx]
+		if i > 0 && q == last {
+			continue
+		}
+		contour.Points = append(contour.Points, float32(q[0]), float32(q[1]), float32(q[2]))
+		last = q
+	}
+	if n := len(contour.Points); closed && n > 3 && points[chain[0]] == last {
+		contour.Points = contour.Points[:n-3]
+	}
+	return contour
+}
+
+// ContourLength computes the length of a contour, e.g., the perimeter of a closed cross-section from SliceMeshWithPlane.
+//
+// Parameters:
+//   - contour : the contour
+//
+// Returns:
+//   - float32 : the length, including the segment from the last to the first point for closed contours
+func ContourLength(contour SliceContour) float32 {
+	numPoints := len(contour.Points) / 3
+	var length float64
+	for i := 1; i <= numPoints; i++ {
+		if i == numPoints && (!contour.Closed || numPoints < 3) {
+			break
+		}
+		a, b := contour.Points[(i-1)*3:], contour.Points[(i%numPoints)*3:]
+		dx, dy, dz := float64(b[0]-a[0]), float64(b[1]-a[1]), float64(b[2]-a[2])
+		length += math.Sqrt(dx*dx + dy*dy + dz*dz)
+	}
+	return float32(length)
+}
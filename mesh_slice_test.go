@@ -0,0 +1,118 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// contourSignedArea computes the signed area enclosed by a closed contour in the z=const plane, positive if it runs counter-clockwise seen from above.
+func contourSignedArea(c SliceContour) float64 {
+	var area float64
+	n := len(c.Points) / 3
+	for i := 0; i < n; i++ {
+		j := (i + 1) % n
+		area += float64(c.Points[i*3]*c.Points[j*3+1] - c.Points[j*3]*c.Points[i*3+1])
+	}
+	return area / 2
+}
+
+func TestSliceMeshWithPlaneSphere(t *testing.T) {
+	sphere, _, err := FromTriangleSoup(ToTriangleSoup(GenerateSphere(10, 64, 32)), 1e-5)
+	if err != nil {
+		t.Fatalf("FromTriangleSoup failed: %v", err)
+	}
+	contours, err := SliceMeshWithPlane(sphere, [3]float32{0, 0, 0.5}, [3]float32{0, 0, 2})
+	if err != nil {
+		t.Fatalf("SliceMeshWithPlane failed: %v", err)
+	}
+	if len(contours) != 1 || !contours[0].Closed {
+		t.Fatalf("got %d contours, want a single closed one", len(contours))
+	}
+	for i := 2; i < len(contours[0].Points); i += 3 {
+		if math.Abs(float64(contours[0].Points[i])-0.5) > 1e-5 {
+			t.Fatalf("contour point %d has z coordinate %f, want 0.5", i/3, contours[0].Points[i])
+		}
+	}
+	// The circle at z=0.5 has radius sqrt(10^2 - 0.5^2). The polygon inscribed into the sphere is slightly shorter.
+	perimeter := 2 * math.Pi * math.Sqrt(100-0.25)
+	if length := float64(ContourLength(contours[0])); length > perimeter || length < 0.99*perimeter {
+		t.Errorf("got contour length %f, want slightly less than %f", length, perimeter)
+	}
+	// The normals of the sphere point outwards, so the contour runs counter-clockwise around the plane normal.
+	if area := contourSignedArea(contours[0]); area <= 0 {
+		t.Errorf("got signed area %f, want a counter-clockwise contour", area)
+	}
+
+	contours, _ = SliceMeshWithPlane(sphere, [3]float32{0, 0, 20}, [3]float32{0, 0, 1})
+	if len(contours) != 0 {
+		t.Errorf("got %d contours for plane outside of the mesh, want none", len(contours))
+	}
+}
+
+func TestSliceMeshWithPlaneOpenMesh(t *testing.T) {
+	// The plane x=1.5 cuts the 3x3 grid in the z=0 plane along a line from y=0 to y=3.
+	contours, err := SliceMeshWithPlane(generateGrid(4), [3]float32{1.5, 0, 0}, [3]float32{1, 0, 0})
+	if err != nil {
+		t.Fatalf("SliceMeshWithPlane failed: %v", err)
+	}
+	if len(contours) != 1 || contours[0].Closed {
+		t.Fatalf("got %d contours, want a single open one", len(contours))
+	}
+	points := contours[0].Points
+	if length := ContourLength(contours[0]); math.Abs(float64(length)-3) > 1e-5 {
+		t.Errorf("got contour length %f, want 3", length)
+	}
+	first, last := points[1], points[len(points)-2]
+	if math.Min(float64(first), float64(last)) != 0 || math.Max(float64(first), float64(last)) != 3 {
+		t.Errorf("got contour from y=%f to y=%f, want from 0 to 3", first, last)
+	}
+}
+
+func TestSliceMeshWithPlaneThroughVertices(t *testing.T) {
+	// The plane x=1 contains a column of grid vertices, which are reached from several crossing edges but must not produce duplicate points.
+	contours, err := SliceMeshWithPlane(generateGrid(4), [3]float32{1, 0, 0}, [3]float32{1, 0, 0})
+	if err != nil || len(contours) != 1 || contours[0].Closed {
+		t.Fatalf("got %d contours and error %v, want a single open contour", len(contours), err)
+	}
+	if len(contours[0].Points) != 12 || ContourLength(contours[0]) != 3 {
+		t.Errorf("got contour %v, want the 4 vertices at x=1", contours[0].Points)
+	}
+}
+
+func TestSliceMeshWithPlaneInconsistentOrientation(t *testing.T) {
+	// The faces of GenerateCube are not consistently oriented, the contour is still complete.
+	cube, _, _ := FromTriangleSoup(ToTriangleSoup(GenerateCube()), 1e-5)
+	contours, err := SliceMeshWithPlane(cube, [3]float32{0, 0, 0.5}, [3]float32{0, 0, 1})
+	if err != nil || len(contours) != 1 || !contours[0].Closed {
+		t.Fatalf("got %d contours and error %v, want a single closed contour", len(contours), err)
+	}
+	if length := ContourLength(contours[0]); math.Abs(float64(length)-8) > 1e-5 {
+		t.Errorf("got perimeter %f, want 8", length)
+	}
+}
+
+func TestSliceMeshWithPlaneInvalid(t *testing.T) {
+	cube := GenerateCube()
+	if _, err := SliceMeshWithPlane(cube, [3]float32{0, 0, 0}, [3]float32{0, 0, 0}); err == nil {
+		t.Errorf("got no error for zero normal, wanted one")
+	}
+	if _, err := SliceMeshWithPlane(Mesh{Vertices: cube.Vertices, Faces: []int32{0, 1, 8}}, [3]float32{0, 0, 0}, [3]float32{0, 0, 1}); err == nil {
+		t.Errorf("got no error for invalid face indices, wanted one")
+	}
+}
+
+func ExampleSliceMeshWithPlane() {
+	mesh, _ := ReadFsSurface("testdata/lh.white")
+	contours, _ := SliceMeshWithPlane(mesh, [3]float32{0, 0, 10}, [3]float32{0, 0, 1})
+	var perimeter float32
+	numClosed := 0
+	for _, c := range contours {
+		perimeter += ContourLength(c)
+		if c.Closed {
+			numClosed++
+		}
+	}
+	fmt.Printf("The axial plane z=10 cuts the white surface in %d contours (%d closed) with a total length of %.0f mm.\n", len(contours), numClosed, perimeter)
+	// Output: The axial plane z=10 cuts the white surface in 7 contours (7 closed) with a total length of 402 mm.
+}
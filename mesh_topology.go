@@ -0,0 +1,162 @@
+package neuro
+
+import (
+	"fmt"
+)
+
+// MeshTopology holds topological properties of a triangular mesh, see ComputeMeshTopology.
+type MeshTopology struct {
+	NumVertices         int  `json:"numVertices"`         // number of vertices
+	NumEdges            int  `json:"numEdges"`            // number of unique edges
+	NumFaces            int  `json:"numFaces"`            // number of faces
+	NumBoundaryEdges    int  `json:"numBoundaryEdges"`    // number of edges that belong to a single face only, i.e., edges at holes in the mesh
+	NumNonManifoldEdges int  `json:"numNonManifoldEdges"` // number of edges shared by more than 2 faces
+	NumComponents       int  `json:"numComponents"`       // number of connected components. Isolated vertices count as a component each.
+	EulerCharacteristic int  `json:"eulerCharacteristic"` // the Euler characteristic V - E + F
+	IsClosed            bool `json:"isClosed"`            // whether the mesh has no boundary edges and no isolated vertices
+	IsManifold          bool `json:"isManifold"`          // whether every edge is shared by at most 2 faces
+	Genus               int  `json:"genus"`               // the total genus (number of handles) of all components. Only defined for closed manifold meshes, -1 otherwise. A brain hemisphere from recon-all has genus 0.
+}
+
+// meshEdgeKey returns the key of the undirected edge between vertices a and b, with the smaller index first.
+func meshEdgeKey(a int32, b int32) [2]int32 {
+	if a < b {
+		return [2]int32{a, b}
+	}
+	return [2]int32{b, a}
+}
+
+// meshEdges computes the unique undirected edges of a mesh, and for each edge the number of faces it belongs to.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - [][2]int32 : the edges, as vertex index pairs with the smaller index first, in order of first occurrence in the faces
+//   - []int : for each edge, the number of faces containing it
+func meshEdges(mesh Mesh) ([][2]int32, []int) {
+	edgeIndex := make(map[[2]int32]int, len(mesh.Faces))
+	edges := make([][2]int32, 0, len(mesh.Faces)/2)
+	faceCounts := make([]int, 0, len(mesh.Faces)/2)
+	for i := 0; i < len(mesh.Faces); i += 3 {
+		for j := 0; j < 3; j++ {
+			e := meshEdgeKey(mesh.Faces[i+j], mesh.Faces[i+(j+1)%3])
+			idx, ok := edgeIndex[e]
+			if !ok {
+				idx = len(edges)
+				edgeIndex[e] = idx
+				edges = append(edges, e)
+				faceCounts = append(faceCounts, 0)
+			}
+			faceCounts[idx]++
+		}
+	}
+	return edges, faceCounts
+}
+
+// VertexNeighbors computes the neighbors of each vertex, i.e., the vertices connected to it by an edge.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - [][]int32 : for each vertex, the indices of its neighbors
+func VertexNeighbors(mesh Mesh) [][]int32 {
+	neighbors := make([][]int32, NumVertices(mesh))
+	edges, _ := meshEdges(mesh)
+	for _, e := range edges {
+		neighbors[e[0]] = append(neighbors[e[0]], e[1])
+		neighbors[e[1]] = append(neighbors[e[1]], e[0])
+	}
+	return neighbors
+}
+
+// ConnectedComponents computes the connected components of a mesh.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - []int32 : for each vertex, the index of the component it belongs to. Components are numbered from 0, in order of their lowest vertex index.
+//   - int : the number of components
+func ConnectedComponents(mesh Mesh) ([]int32, int) {
+	numVertices := NumVertices(mesh)
+	neighbors := VertexNeighbors(mesh)
+	component := make([]int32, numVertices)
+	for i := range component {
+		component[i] = -1
+	}
+
+	numComponents := 0
+	stack := make([]int32, 0)
+	for start := 0; start < numVertices; start++ {
+		if component[start] >= 0 {
+			continue
+		}
+		component[start] = int32(numComponents)
+		stack = append(stack[:0], int32(start))
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, n := range neighbors[v] {
+				if component[n] < 0 {
+					component[n] = int32(numComponents)
+					stack = append(stack, n)
+				}
+			}
+		}
+		numComponents++
+	}
+	return component, numComponents
+}
+
+// ComputeMeshTopology computes topological properties of a mesh, like whether it is closed, its number of connected components, and its genus.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - MeshTopology : the topological properties
+//   - error : an error if the mesh is invalid, e.g., faces reference vertices that do not exist
+func ComputeMeshTopology(mesh Mesh) (MeshTopology, error) {
+	topo := MeshTopology{NumVertices: NumVertices(mesh), NumFaces: NumFaces(mesh), Genus: -1}
+
+	if err := validateFaceIndices(mesh); err != nil {
+		return topo, fmt.Errorf("ComputeMeshTopology: invalid mesh: %s", err)
+	}
+
+	edges, faceCounts := meshEdges(mesh)
+	topo.NumEdges = len(edges)
+	for _, count := range faceCounts {
+		if count == 1 {
+			topo.NumBoundaryEdges++
+		} else if count > 2 {
+			topo.NumNonManifoldEdges++
+		}
+	}
+
+	usedVertices := make([]bool, topo.NumVertices)
+	for _, v := range mesh.Faces {
+		usedVertices[v] = true
+	}
+	numIsolatedVertices := 0
+	for _, used := range usedVertices {
+		if !used {
+			numIsolatedVertices++
+		}
+	}
+
+	_, topo.NumComponents = ConnectedComponents(mesh)
+	topo.EulerCharacteristic = topo.NumVertices - topo.NumEdges + topo.NumFaces
+	topo.IsManifold = topo.NumNonManifoldEdges == 0
+	topo.IsClosed = topo.NumBoundaryEdges == 0 && numIsolatedVertices == 0 && topo.NumFaces > 0
+
+	// For closed orientable surfaces, each component contributes 2 - 2g to the Euler characteristic.
+	if topo.IsClosed && topo.IsManifold {
+		topo.Genus = (2*topo.NumComponents - topo.EulerCharacteristic) / 2
+	}
+
+	logDebug("ComputeMeshTopology: V=%d, E=%d, F=%d, components=%d, genus=%d.", topo.NumVertices, topo.NumEdges, topo.NumFaces, topo.NumComponents, topo.Genus)
+
+	return topo, nil
+}
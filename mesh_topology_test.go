@@ -0,0 +1,92 @@
+package neuro
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestComputeMeshTopologyCube(t *testing.T) {
+	topo, err := ComputeMeshTopology(GenerateCube())
+	if err != nil {
+		t.Fatalf("ComputeMeshTopology failed: %s", err)
+	}
+
+	want := MeshTopology{NumVertices: 8, NumEdges: 18, NumFaces: 12, NumComponents: 1, EulerCharacteristic: 2, IsClosed: true, IsManifold: true, Genus: 0}
+	if diff := cmp.Diff(want, topo); diff != "" {
+		t.Errorf("ComputeMeshTopology() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestComputeMeshTopologyOpenMesh(t *testing.T) {
+	// A cube with one face removed has a hole, so it is not closed and has no defined genus.
+	cube := GenerateCube()
+	cube.Faces = cube.Faces[3:]
+
+	topo, err := ComputeMeshTopology(cube)
+	if err != nil {
+		t.Fatalf("ComputeMeshTopology failed: %s", err)
+	}
+	if topo.IsClosed {
+		t.Errorf("got IsClosed=true for open mesh")
+	}
+	if topo.NumBoundaryEdges != 3 {
+		t.Errorf("got %d boundary edges, wanted 3", topo.NumBoundaryEdges)
+	}
+	if topo.Genus != -1 {
+		t.Errorf("got genus %d for open mesh, wanted -1", topo.Genus)
+	}
+}
+
+func TestComputeMeshTopologyTwoComponents(t *testing.T) {
+	cube := GenerateCube()
+	twoCubes := Mesh{Vertices: append(append([]float32{}, cube.Vertices...), cube.Vertices...)}
+	twoCubes.Faces = append([]int32{}, cube.Faces...)
+	for _, v := range cube.Faces {
+		twoCubes.Faces = append(twoCubes.Faces, v+8)
+	}
+
+	topo, err := ComputeMeshTopology(twoCubes)
+	if err != nil {
+		t.Fatalf("ComputeMeshTopology failed: %s", err)
+	}
+	if topo.NumComponents != 2 || topo.EulerCharacteristic != 4 || topo.Genus != 0 {
+		t.Errorf("got %d components, Euler characteristic %d, genus %d, wanted 2, 4, 0", topo.NumComponents, topo.EulerCharacteristic, topo.Genus)
+	}
+
+	components, _ := ConnectedComponents(twoCubes)
+	if components[0] != 0 || components[15] != 1 {
+		t.Errorf("got components %v", components)
+	}
+}
+
+func TestComputeMeshTopologyInvalidIndex(t *testing.T) {
+	cube := GenerateCube()
+	cube.Faces[0] = 8
+
+	if _, err := ComputeMeshTopology(cube); err == nil {
+		t.Errorf("expected error for face referencing non-existent vertex")
+	}
+}
+
+func TestComputeMeshTopologyBrainSurface(t *testing.T) {
+	mesh, err := ReadFsSurface("testdata/lh.white")
+	if err != nil {
+		t.Fatalf("could not read surface: %s", err)
+	}
+
+	topo, err := ComputeMeshTopology(mesh)
+	if err != nil {
+		t.Fatalf("ComputeMeshTopology failed: %s", err)
+	}
+	if !topo.IsClosed || !topo.IsManifold || topo.NumComponents != 1 || topo.Genus != 0 {
+		t.Errorf("expected closed manifold genus 0 surface, got %+v", topo)
+	}
+}
+
+func ExampleComputeMeshTopology() {
+	topo, _ := ComputeMeshTopology(GenerateCube())
+	fmt.Printf("Closed: %t, components: %d, genus: %d.\n", topo.IsClosed, topo.NumComponents, topo.Genus)
+	// Output: Closed: true, components: 1, genus: 0.
+}
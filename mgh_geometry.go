@@ -0,0 +1,39 @@
+package neuro
+
+import (
+	"fmt"
+)
+
+// MghVox2Ras computes the vox2ras matrix of an MGH file from its header.
+//
+// The vox2ras matrix is the 4x4 affine transformation matrix that maps voxel indices (i, j, k) to RAS coordinates (x, y, z) in scanner space, in mm.
+// It is computed from the voxel sizes, the orientation matrix Mdc and the coordinates of the central voxel Pxyz_c, in the same way as FreeSurfer does.
+//
+// Parameters:
+//   - hdr: the MGH header, see ReadFsMghHeader
+//
+// Returns:
+//   - [16]float32: the vox2ras matrix in row-major order, i.e., [m11, m12, m13, m14, m21, ...]. The last row is always [0, 0, 0, 1].
+//   - error: an error if the header contains no valid RAS information, i.e., RasGoodFlag is not 1
+func MghVox2Ras(hdr MghHeader) ([16]float32, error) {
+	var vox2ras [16]float32
+	if hdr.RasGoodFlag != 1 {
+		return vox2ras, fmt.Errorf("MghVox2Ras: MGH header contains no valid RAS information (RasGoodFlag=%d)", hdr.RasGoodFlag)
+	}
+
+	delta := [3]float32{hdr.XSize, hdr.YSize, hdr.ZSize}
+	halfDims := [3]float32{float32(hdr.Dim1Length) / 2, float32(hdr.Dim2Length) / 2, float32(hdr.Dim3Length) / 2}
+
+	// The Mdc field stores the direction cosines of the x, y and z axes one after the other, so they are the columns of the rotation matrix.
+	for i := 0; i < 3; i++ {
+		p0 := hdr.Pxyz_c[i]
+		for j := 0; j < 3; j++ {
+			m := hdr.Mdc[j*3+i] * delta[j]
+			vox2ras[i*4+j] = m
+			p0 -= m * halfDims[j]
+		}
+		vox2ras[i*4+3] = p0
+	}
+	vox2ras[15] = 1
+	return vox2ras, nil
+}
@@ -0,0 +1,34 @@
+package neuro
+
+import (
+	"testing"
+)
+
+func TestMghVox2Ras(t *testing.T) {
+	hdr, err := ReadFsMghHeader("testdata/brain.mgh", "auto")
+	if err != nil {
+		t.Fatalf("could not read MGH header: %s", err)
+	}
+
+	vox2ras, err := MghVox2Ras(hdr)
+	if err != nil {
+		t.Fatalf("MghVox2Ras failed: %s", err)
+	}
+
+	// Conformed FreeSurfer volume: LIA orientation, 1 mm voxels, shifted by the center RAS coordinates.
+	want := [16]float32{-1, 0, 0, 127.5, 0, 0, 1, -98.62726, 0, -1, 0, 79.09527, 0, 0, 0, 1}
+	for i := range want {
+		if !almostEqualF32(vox2ras[i], want[i], 1e-4) {
+			t.Errorf("vox2ras element %d: got %f, wanted %f", i, vox2ras[i], want[i])
+		}
+	}
+}
+
+func TestMghVox2RasInvalidRas(t *testing.T) {
+	hdr, _ := ReadFsMghHeader("testdata/brain.mgh", "auto")
+	hdr.RasGoodFlag = 0
+
+	if _, err := MghVox2Ras(hdr); err == nil {
+		t.Errorf("expected error for header without valid RAS information")
+	}
+}
@@ -11,5 +11,5 @@
 // are also included.
 package neuro
 
-// Verbosity is the verbosity level of the package. 0 = silent, 1 = info, 2 = debug.
+// Verbosity is the verbosity level of the package. 0 = silent, 1 = info, 2 = debug. Messages are sent to the Logger set with SetLogger, which writes to STDOUT by default.
 var Verbosity int = 0 // WARNING: If you increase Verbosity here and run the unit tests, the examples included with the tests will fail, because they expect a defined output on STDOUT, and increasing verbosity will produce extra output.
@@ -0,0 +1,158 @@
+package neuro
+
+import (
+	"fmt"
+	"os"
+)
+
+// PolyMesh is a struct that holds a polygon mesh, whose faces can have any number of vertices (at least 3), like the quads used by many modelling tools.
+//
+// Use ImportPolyMesh to read PLY and OBJ files without splitting their polygons into triangles, and TriangulatePolyMesh to convert to a triangle Mesh.
+//
+// Fields:
+//   - Vertices  : the vertex coordinates, as a flat array [x1, y1, z1, x2, ...], like in Mesh
+//   - Faces     : the vertex indices of all faces, concatenated. The first vertex has index 0.
+//   - FaceSizes : the number of vertices of each face, e.g., 4 for a quad. Face i consists of the FaceSizes[i] indices in Faces after those of faces 0 to i-1.
+type PolyMesh struct {
+	Vertices  []float32
+	Faces     []int32
+	FaceSizes []int32
+}
+
+// appendPolygon adds a polygon to the faces of a polygon mesh. Polygons with less than 3 vertices are skipped.
+func (pm *PolyMesh) appendPolygon(polygon []int32) {
+	if len(polygon) < 3 {
+		return
+	}
+	pm.Faces = append(pm.Faces, polygon...)
+	pm.FaceSizes = append(pm.FaceSizes, int32(len(polygon)))
+}
+
+// validatePolyMeshFaceIndices checks that the face sizes of a polygon mesh match its faces, and that all faces reference existing vertices.
+func validatePolyMeshFaceIndices(pm PolyMesh) error {
+	total := 0
+	for i, size := range pm.FaceSizes {
+		if size < 3 {
+			return fmt.Errorf("face %d has %d vertices, but at least 3 are required", i, size)
+		}
+		total += int(size)
+	}
+	if total != len(pm.Faces) {
+		return fmt.Errorf("face sizes sum up to %d vertex indices, but there are %d", total, len(pm.Faces))
+	}
+	numVertices := int32(len(pm.Vertices) / 3)
+	face, faceEnd := 0, 0
+	for i, vertexIndex := range pm.Faces {
+		for i >= faceEnd {
+			faceEnd += int(pm.FaceSizes[face])
+			face++
+		}
+		if vertexIndex < 0 || vertexIndex >= numVertices {
+			return fmt.Errorf("face %d references vertex %d, but the mesh only has %d vertices", face-1, vertexIndex, numVertices)
+		}
+	}
+	return nil
+}
+
+// NumPolygons computes the number of faces of a polygon mesh.
+//
+// Parameters:
+//   - pm : the polygon mesh
+//
+// Returns:
+//   - int : the number of faces
+func NumPolygons(pm PolyMesh) int {
+	return len(pm.FaceSizes)
+}
+
+// PolyMeshFromMesh converts a triangle mesh into a polygon mesh.
+//
+// Parameters:
+//   - mesh : the triangle mesh
+//
+// Returns:
+//   - PolyMesh : the polygon mesh, in which all faces have 3 vertices
+func PolyMeshFromMesh(mesh Mesh) PolyMesh {
+	pm := PolyMesh{Vertices: mesh.Vertices, Faces: mesh.Faces, FaceSizes: make([]int32, NumFaces(mesh))}
+	for i := range pm.FaceSizes {
+		pm.FaceSizes[i] = 3
+	}
+	return pm
+}
+
+// TriangulatePolyMesh converts a polygon mesh into a triangle mesh, by splitting each face with more than 3 vertices into a fan of triangles around its first vertex.
+//
+// The fan triangulation is exact for convex polygons like the quads of typical quad meshes.
+//
+// Parameters:
+//   - pm : the polygon mesh
+//
+// Returns:
+//   - Mesh  : the triangle mesh, with the same vertices. A face with n vertices becomes n-2 triangles.
+//   - error : an error if the face sizes do not match the faces, or a face references a vertex that does not exist
+func TriangulatePolyMesh(pm PolyMesh) (Mesh, error) {
+	if err := validatePolyMeshFaceIndices(pm); err != nil {
+		return Mesh{}, fmt.Errorf("TriangulatePolyMesh: %s", err)
+	}
+	mesh := Mesh{Vertices: pm.Vertices, Faces: make([]int32, 0, len(pm.Faces)*3)}
+	start := 0
+	for _, size := range pm.FaceSizes {
+		mesh.Faces = appendPolygonAsTriangles(mesh.Faces, pm.Faces[start:start+int(size)])
+		start += int(size)
+	}
+	return mesh, nil
+}
+
+// PolyMeshFromBytes parses a polygon mesh from the contents of a mesh file, keeping faces with more than 3 vertices.
+//
+// Parameters:
+//   - bs     : the file contents
+//   - format : the mesh file format, see ImportMesh. Polygons are only supported by the 'ply' and 'obj' formats, the other formats store triangles.
+//
+// Returns:
+//   - PolyMesh : the polygon mesh
+//   - error    : the error if one occured, or nil otherwise
+func PolyMeshFromBytes(bs []byte, format string) (PolyMesh, error) {
+	format, err := normalizeMeshFormat(format)
+	if err != nil {
+		return PolyMesh{}, fmt.Errorf("PolyMeshFromBytes: %s", err)
+	}
+	switch format {
+	case "ply":
+		return readPlyPolyMeshFromBytes(bs)
+	case "obj":
+		return readObjPolyMeshFromBytes(bs)
+	default:
+		mesh, err := MeshFromBytes(bs, format)
+		if err != nil {
+			return PolyMesh{}, err
+		}
+		return PolyMeshFromMesh(mesh), nil
+	}
+}
+
+// ImportPolyMesh reads a polygon mesh from a file in any of the supported mesh file formats, keeping faces with more than 3 vertices.
+//
+// Unlike ImportMesh, quads and other polygons in PLY and OBJ files are read losslessly. Use TriangulatePolyMesh to split them into triangles later.
+//
+// Parameters:
+//   - filepath : the path of the mesh file
+//   - format   : the mesh file format, see ImportMesh. Use 'auto' to determine the format from the file extension.
+//
+// Returns:
+//   - PolyMesh : the polygon mesh
+//   - error    : the error if one occured, or nil otherwise
+func ImportPolyMesh(filepath string, format string) (PolyMesh, error) {
+	if format == "auto" {
+		format = guessMeshFormat(filepath)
+	}
+	bs, err := os.ReadFile(filepath)
+	if err != nil {
+		return PolyMesh{}, fmt.Errorf("ImportPolyMesh: could not read mesh file '%s': %s", filepath, err)
+	}
+	pm, err := PolyMeshFromBytes(bs, format)
+	if err != nil {
+		return pm, fmt.Errorf("ImportPolyMesh: failed to read mesh file '%s': %s", filepath, err)
+	}
+	return pm, nil
+}
@@ -0,0 +1,95 @@
+package neuro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPolyMeshFromBytesObjMixedPolygons(t *testing.T) {
+	// A quad and a triangle sharing an edge, and a pentagon.
+	obj := "v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nv 2 0 0\nv 3 0 0\nv 3 1 0\nf 1 2 3 4\nf 2 5 3\nf 5 6 7 3 2\n"
+
+	got, err := PolyMeshFromBytes([]byte(obj), "obj")
+	if err != nil {
+		t.Fatalf("PolyMeshFromBytes failed: %v", err)
+	}
+	want := PolyMesh{
+		Vertices:  []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0, 2, 0, 0, 3, 0, 0, 3, 1, 0},
+		Faces:     []int32{0, 1, 2, 3, 1, 4, 2, 4, 5, 6, 2, 1},
+		FaceSizes: []int32{4, 3, 5},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+	if NumPolygons(got) != 3 {
+		t.Errorf("got %d polygons, want 3", NumPolygons(got))
+	}
+
+	mesh, err := TriangulatePolyMesh(got)
+	if err != nil {
+		t.Fatalf("TriangulatePolyMesh failed: %v", err)
+	}
+	if diff := cmp.Diff([]int32{0, 1, 2, 0, 2, 3, 1, 4, 2, 4, 5, 6, 4, 6, 2, 4, 2, 1}, mesh.Faces); diff != "" {
+		t.Error(diff)
+	}
+	triangulated, _ := MeshFromBytes([]byte(obj), "obj")
+	if diff := cmp.Diff(triangulated, mesh); diff != "" {
+		t.Errorf("TriangulatePolyMesh result differs from MeshFromBytes: %s", diff)
+	}
+}
+
+func TestImportPolyMeshPly(t *testing.T) {
+	ply := "ply\nformat ascii 1.0\nelement vertex 4\nproperty float x\nproperty float y\nproperty float z\n" +
+		"element face 1\nproperty list uchar int vertex_indices\nend_header\n" +
+		"0 0 0\n1 0 0\n1 1 0\n0 1 0\n4 0 1 2 3\n"
+	plyFile := filepath.Join(t.TempDir(), "quad.ply")
+	os.WriteFile(plyFile, []byte(ply), 0644)
+
+	got, err := ImportPolyMesh(plyFile, "auto")
+	if err != nil {
+		t.Fatalf("ImportPolyMesh failed: %v", err)
+	}
+	if diff := cmp.Diff([]int32{4}, got.FaceSizes); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]int32{0, 1, 2, 3}, got.Faces); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestPolyMeshFromMesh(t *testing.T) {
+	cube := GenerateCube()
+	pm := PolyMeshFromMesh(cube)
+	if NumPolygons(pm) != NumFaces(cube) {
+		t.Errorf("got %d polygons, want %d", NumPolygons(pm), NumFaces(cube))
+	}
+	mesh, err := TriangulatePolyMesh(pm)
+	if err != nil {
+		t.Fatalf("TriangulatePolyMesh failed: %v", err)
+	}
+	if diff := cmp.Diff(cube, mesh); diff != "" {
+		t.Error(diff)
+	}
+
+	stl, _ := MeshToBytes(cube, "stl", true, nil)
+	pm, err = PolyMeshFromBytes(stl, "stl")
+	if err != nil || NumPolygons(pm) != 12 {
+		t.Errorf("got %d polygons and error %v for STL data, want 12 triangles", NumPolygons(pm), err)
+	}
+}
+
+func TestTriangulatePolyMeshInvalid(t *testing.T) {
+	vertices := []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0}
+	for name, pm := range map[string]PolyMesh{
+		"face sizes too large":  {Vertices: vertices, Faces: []int32{0, 1, 2}, FaceSizes: []int32{4}},
+		"face with 2 vertices":  {Vertices: vertices, Faces: []int32{0, 1}, FaceSizes: []int32{2}},
+		"vertex index too high": {Vertices: vertices, Faces: []int32{0, 1, 2, 0, 2, 4}, FaceSizes: []int32{3, 3}},
+	} {
+		if _, err := TriangulatePolyMesh(pm); err == nil {
+			t.Errorf("got no error for %s, wanted one", name)
+		}
+	}
+}
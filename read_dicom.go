@@ -0,0 +1,494 @@
+package neuro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DICOM transfer syntaxes supported by ReadDicomSeries. Compressed transfer syntaxes like JPEG are not supported.
+const (
+	dicomImplicitVRLittleEndian = "1.2.840.10008.1.2"
+	dicomExplicitVRLittleEndian = "1.2.840.10008.1.2.1"
+)
+
+// DICOM tags used by ReadDicomSeries, as group << 16 | element.
+const (
+	dicomTagTransferSyntaxUID       uint32 = 0x00020010
+	dicomTagSliceThickness          uint32 = 0x00180050
+	dicomTagSeriesInstanceUID       uint32 = 0x0020000E
+	dicomTagInstanceNumber          uint32 = 0x00200013
+	dicomTagImagePositionPatient    uint32 = 0x00200032
+	dicomTagImageOrientationPatient uint32 = 0x00200037
+	dicomTagSamplesPerPixel         uint32 = 0x00280002
+	dicomTagNumberOfFrames          uint32 = 0x00280008
+	dicomTagRows                    uint32 = 0x00280010
+	dicomTagColumns                 uint32 = 0x00280011
+	dicomTagPixelSpacing            uint32 = 0x00280030
+	dicomTagBitsAllocated           uint32 = 0x00280100
+	dicomTagPixelRepresentation     uint32 = 0x00280103
+	dicomTagRescaleIntercept        uint32 = 0x00281052
+	dicomTagRescaleSlope            uint32 = 0x00281053
+	dicomTagPixelData               uint32 = 0x7FE00010
+	dicomTagSequenceDelimitation    uint32 = 0xFFFEE0DD
+)
+
+// dicomSlice holds the information of a single DICOM file that is needed to assemble a volume from a series of slices.
+type dicomSlice struct {
+	path                string
+	seriesUID           string
+	instanceNumber      int
+	position            []float64 // ImagePositionPatient: the LPS coordinates of the center of the first pixel, empty if not set
+	orientation         []float64 // ImageOrientationPatient: the LPS direction cosines of the rows and columns, empty if not set
+	pixelSpacing        []float64 // PixelSpacing: the distance between rows and between columns, in mm
+	sliceThickness      float64
+	rows                int
+	columns             int
+	samplesPerPixel     int
+	numFrames           int
+	bitsAllocated       int
+	pixelRepresentation int // 0 for unsigned, 1 for signed pixel values
+	slope               float64
+	intercept           float64
+	pixelData           []byte
+}
+
+// dicomReader reads the data elements of a DICOM data set in little endian byte order.
+type dicomReader struct {
+	bs       []byte
+	pos      int
+	explicit bool // whether the value representation is given explicitly in each element
+}
+
+// dicomLongVRs are the value representations that use a 4 byte length field with explicit VR.
+var dicomLongVRs = map[string]bool{"OB": true, "OD": true, "OF": true, "OL": true, "OV": true, "OW": true, "SQ": true, "SV": true, "UC": true, "UN": true, "UR": true, "UT": true, "UV": true}
+
+// next reads the next data element and returns its tag and value.
+//
+// Sequences of undefined length are skipped and returned with a nil value. Item tags with undefined length only consume the item header,
+// so the elements of the item are returned by the following calls.
+func (r *dicomReader) next() (uint32, []byte, error) {
+	if r.pos+8 > len(r.bs) {
+		return 0, nil, fmt.Errorf("truncated data element at offset %d", r.pos)
+	}
+	tag := uint32(binary.LittleEndian.Uint16(r.bs[r.pos:]))<<16 | uint32(binary.LittleEndian.Uint16(r.bs[r.pos+2:]))
+	headerSize, length := 8, binary.LittleEndian.Uint32(r.bs[r.pos+4:])
+	if r.explicit && tag>>16 != 0xFFFE {
+		vr := string(r.bs[r.pos+4 : r.pos+6])
+		if dicomLongVRs[vr] {
+			if r.pos+12 > len(r.bs) {
+				return 0, nil, fmt.Errorf("truncated data element at offset %d", r.pos)
+			}
+			headerSize, length = 12, binary.LittleEndian.Uint32(r.bs[r.pos+8:])
+		} else {
+			length = uint32(binary.LittleEndian.Uint16(r.bs[r.pos+6:]))
+		}
+	}
+	r.pos += headerSize
+
+	if length == 0xFFFFFFFF {
+		if tag == dicomTagPixelData {
+			return tag, nil, fmt.Errorf("pixel data is encapsulated, i.e., compressed, which is not supported")
+		}
+		if tag>>16 != 0xFFFE {
+			if err := r.skipSequence(); err != nil {
+				return tag, nil, err
+			}
+		}
+		return tag, nil, nil
+	}
+	if int64(r.pos)+int64(length) > int64(len(r.bs)) {
+		return tag, nil, fmt.Errorf("data element (%04X,%04X) at offset %d has length %d, which exceeds the data", tag>>16, tag&0xFFFF, r.pos-headerSize, length)
+	}
+	value := r.bs[r.pos : r.pos+int(length)]
+	r.pos += int(length)
+	return tag, value, nil
+}
+
+// skipSequence skips the items of a sequence of undefined length, up to and including the sequence delimitation item.
+func (r *dicomReader) skipSequence() error {
+	for {
+		tag, _, err := r.next()
+		if err != nil {
+			return err
+		}
+		if tag == dicomTagSequenceDelimitation {
+			return nil
+		}
+	}
+}
+
+// dicomString converts the value of a text element to a string, removing the padding.
+func dicomString(value []byte) string {
+	return strings.TrimRight(strings.TrimSpace(string(value)), "\x00")
+}
+
+// dicomNumbers parses the value of a decimal string (DS) or integer string (IS) element, which may contain several values separated by backslashes.
+func dicomNumbers(value []byte) ([]float64, error) {
+	var numbers []float64
+	for _, field := range strings.Split(dicomString(value), "\\") {
+		x, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", field)
+		}
+		numbers = append(numbers, x)
+	}
+	return numbers, nil
+}
+
+// dicomUint16 parses the value of an unsigned short (US) element.
+func dicomUint16(value []byte) (int, error) {
+	if len(value) < 2 {
+		return 0, fmt.Errorf("value has %d bytes, need 2", len(value))
+	}
+	return int(binary.LittleEndian.Uint16(value)), nil
+}
+
+// readDicomSliceFromBytes parses the elements of a DICOM file that are needed by ReadDicomSeries.
+func readDicomSliceFromBytes(bs []byte) (dicomSlice, error) {
+	slice := dicomSlice{samplesPerPixel: 1, numFrames: 1, slope: 1}
+	if len(bs) < 132 || string(bs[128:132]) != "DICM" {
+		return slice, fmt.Errorf("this is not a DICOM file, it has no 'DICM' magic bytes after the preamble")
+	}
+
+	// The file meta information (group 0x0002) is always encoded with explicit VR, it defines the encoding of the rest of the file.
+	r := &dicomReader{bs: bs, pos: 132, explicit: true}
+	transferSyntax := ""
+	for r.pos+2 <= len(bs) && binary.LittleEndian.Uint16(bs[r.pos:]) == 0x0002 {
+		tag, value, err := r.next()
+		if err != nil {
+			return slice, fmt.Errorf("invalid file meta information: %s", err)
+		}
+		if tag == dicomTagTransferSyntaxUID {
+			transferSyntax = dicomString(value)
+		}
+	}
+	switch transferSyntax {
+	case dicomImplicitVRLittleEndian:
+		r.explicit = false
+	case dicomExplicitVRLittleEndian:
+	default:
+		return slice, fmt.Errorf("unsupported transfer syntax '%s', only uncompressed little endian data (%s, %s) is supported", transferSyntax, dicomImplicitVRLittleEndian, dicomExplicitVRLittleEndian)
+	}
+
+	for r.pos < len(bs) {
+		tag, value, err := r.next()
+		if err != nil {
+			return slice, err
+		}
+		if len(value) == 0 && tag != dicomTagPixelData {
+			continue // Many elements are allowed to be empty, treat them as not set.
+		}
+		switch tag {
+		case dicomTagSeriesInstanceUID:
+			slice.seriesUID = dicomString(value)
+		case dicomTagInstanceNumber, dicomTagNumberOfFrames:
+			var n []float64
+			if n, err = dicomNumbers(value); err == nil {
+				if tag == dicomTagInstanceNumber {
+					slice.instanceNumber = int(n[0])
+				} else {
+					slice.numFrames = int(n[0])
+				}
+			}
+		case dicomTagImagePositionPatient:
+			slice.position, err = dicomNumbers(value)
+			if err == nil && len(slice.position) != 3 {
+				err = fmt.Errorf("got %d values, need 3", len(slice.position))
+			}
+		case dicomTagImageOrientationPatient:
+			slice.orientation, err = dicomNumbers(value)
+			if err == nil && len(slice.orientation) != 6 {
+				err = fmt.Errorf("got %d values, need 6", len(slice.orientation))
+			}
+		case dicomTagPixelSpacing:
+			slice.pixelSpacing, err = dicomNumbers(value)
+			if err == nil && len(slice.pixelSpacing) != 2 {
+				err = fmt.Errorf("got %d values, need 2", len(slice.pixelSpacing))
+			}
+		case dicomTagSliceThickness, dicomTagRescaleSlope, dicomTagRescaleIntercept:
+			var n []float64
+			if n, err = dicomNumbers(value); err == nil {
+				switch tag {
+				case dicomTagSliceThickness:
+					slice.sliceThickness = n[0]
+				case dicomTagRescaleSlope:
+					slice.slope = n[0]
+				default:
+					slice.intercept = n[0]
+				}
+			}
+		case dicomTagRows:
+			slice.rows, err = dicomUint16(value)
+		case dicomTagColumns:
+			slice.columns, err = dicomUint16(value)
+		case dicomTagSamplesPerPixel:
+			slice.samplesPerPixel, err = dicomUint16(value)
+		case dicomTagBitsAllocated:
+			slice.bitsAllocated, err = dicomUint16(value)
+		case dicomTagPixelRepresentation:
+			slice.pixelRepresentation, err = dicomUint16(value)
+		case dicomTagPixelData:
+			slice.pixelData = value
+		}
+		if err != nil {
+			return slice, fmt.Errorf("invalid value of data element (%04X,%04X): %s", tag>>16, tag&0xFFFF, err)
+		}
+	}
+	return slice, nil
+}
+
+// pixelValues decodes the pixel data of a slice and applies the rescale slope and intercept.
+func (s dicomSlice) pixelValues() ([]float64, error) {
+	numPixels := s.rows * s.columns
+	bytesPerPixel := s.bitsAllocated / 8
+	if s.bitsAllocated != 8 && s.bitsAllocated != 16 && s.bitsAllocated != 32 {
+		return nil, fmt.Errorf("unsupported number of bits allocated per pixel (%d), must be 8, 16 or 32", s.bitsAllocated)
+	}
+	if len(s.pixelData) < numPixels*bytesPerPixel {
+		return nil, fmt.Errorf("pixel data has %d bytes, need %d for %dx%d pixels with %d bits", len(s.pixelData), numPixels*bytesPerPixel, s.columns, s.rows, s.bitsAllocated)
+	}
+	signed := s.pixelRepresentation == 1
+	values := make([]float64, numPixels)
+	for i := range values {
+		var v float64
+		switch {
+		case s.bitsAllocated == 8 && signed:
+			v = float64(int8(s.pixelData[i]))
+		case s.bitsAllocated == 8:
+			v = float64(s.pixelData[i])
+		case s.bitsAllocated == 16 && signed:
+			v = float64(int16(binary.LittleEndian.Uint16(s.pixelData[i*2:])))
+		case s.bitsAllocated == 16:
+			v = float64(binary.LittleEndian.Uint16(s.pixelData[i*2:]))
+		case signed:
+			v = float64(int32(binary.LittleEndian.Uint32(s.pixelData[i*4:])))
+		default:
+			v = float64(binary.LittleEndian.Uint32(s.pixelData[i*4:]))
+		}
+		values[i] = v*s.slope + s.intercept
+	}
+	return values, nil
+}
+
+// dicomMghData stores voxel values in the smallest MGH data type that can represent them exactly.
+func dicomMghData(values []float64) MghData {
+	integral, minValue, maxValue := true, math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		integral = integral && v == math.Trunc(v)
+		minValue, maxValue = math.Min(minValue, v), math.Max(maxValue, v)
+	}
+	var data MghData
+	switch {
+	case integral && minValue >= 0 && maxValue <= math.MaxUint8:
+		data.MghDataType = MRI_UCHAR
+		data.DataMriUchar = make([]uint8, len(values))
+		for i, v := range values {
+			data.DataMriUchar[i] = uint8(v)
+		}
+	case integral && minValue >= math.MinInt16 && maxValue <= math.MaxInt16:
+		data.MghDataType = MRI_SHORT
+		data.DataMriShort = make([]int16, len(values))
+		for i, v := range values {
+			data.DataMriShort[i] = int16(v)
+		}
+	case integral && minValue >= math.MinInt32 && maxValue <= math.MaxInt32:
+		data.MghDataType = MRI_INT
+		data.DataMriInt = make([]int32, len(values))
+		for i, v := range values {
+			data.DataMriInt[i] = int32(v)
+		}
+	default:
+		data.MghDataType = MRI_FLOAT
+		data.DataMriFloat = make([]float32, len(values))
+		for i, v := range values {
+			data.DataMriFloat[i] = float32(v)
+		}
+	}
+	return data
+}
+
+// ReadDicomSeries reads a directory containing the DICOM files of a single image series, e.g., a T1-weighted scan, into a volume.
+//
+// See ReadDicomSeriesFromFiles for details. Files in the directory that are not DICOM files or contain no image, like a DICOMDIR file, are ignored.
+// Subdirectories are not searched.
+//
+// Parameters:
+//   - dir : the directory containing the DICOM files, one file per slice
+//
+// Returns:
+//   - Mgh : the volume
+//   - error : an error if the directory could not be read, or the files do not form a single volume
+func ReadDicomSeries(dir string) (Mgh, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return Mgh{}, fmt.Errorf("ReadDicomSeries: could not read directory '%s': %s", dir, err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	mgh, err := readDicomSeries(paths)
+	if err != nil {
+		return mgh, fmt.Errorf("ReadDicomSeries: failed to read DICOM series in directory '%s': %s", dir, err)
+	}
+	return mgh, nil
+}
+
+// ReadDicomSeriesFromFiles reads the DICOM files of a single image series into a volume, like dcm2niix does for a single series.
+//
+// The slices are sorted by their position along the slice normal, or by their instance number if they contain no position information.
+// The orientation of the volume is computed from the ImageOrientationPatient, ImagePositionPatient and PixelSpacing tags, converted from the
+// LPS coordinates of DICOM to the RAS coordinates of FreeSurfer, so MghVox2Ras returns the vox2ras matrix of the volume. The rescale slope and
+// intercept are applied to the pixel values, and the smallest MGH data type that holds the values exactly is used.
+//
+// Only uncompressed single-frame files with little endian transfer syntax and a single sample per pixel (grayscale) are supported, which covers
+// the output of most MRI scanners. Convert other files with a tool like gdcmconv first. Volumes acquired with a gantry tilt are not supported.
+//
+// Parameters:
+//   - paths : the paths to the DICOM files, in any order. Files that are not DICOM files or contain no pixel data are ignored.
+//
+// Returns:
+//   - Mgh : the volume. If the files contain no orientation information, RasGoodFlag in its header is 0.
+//   - error : an error if a file could not be read, no slices were found, or the slices do not form a single volume, e.g., because they belong to several series
+func ReadDicomSeriesFromFiles(paths []string) (Mgh, error) {
+	mgh, err := readDicomSeries(paths)
+	if err != nil {
+		return mgh, fmt.Errorf("ReadDicomSeriesFromFiles: %s", err)
+	}
+	return mgh, nil
+}
+
+// readDicomSeries reads the DICOM files of a single image series into a volume, see ReadDicomSeriesFromFiles.
+func readDicomSeries(paths []string) (Mgh, error) {
+	var slices []dicomSlice
+	for _, path := range paths {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return Mgh{}, fmt.Errorf("could not read file '%s': %s", path, err)
+		}
+		if len(bs) < 132 || string(bs[128:132]) != "DICM" {
+			logDebug("readDicomSeries: ignoring file '%s', it is not a DICOM file.", path)
+			continue
+		}
+		slice, err := readDicomSliceFromBytes(bs)
+		if err != nil {
+			return Mgh{}, fmt.Errorf("failed to parse DICOM file '%s': %s", path, err)
+		}
+		if slice.pixelData == nil {
+			logDebug("readDicomSeries: ignoring DICOM file '%s', it contains no pixel data.", path)
+			continue
+		}
+		slice.path = path
+		slices = append(slices, slice)
+	}
+	if len(slices) == 0 {
+		return Mgh{}, fmt.Errorf("found no DICOM files with pixel data")
+	}
+
+	first := slices[0]
+	if first.samplesPerPixel != 1 || first.numFrames != 1 {
+		return Mgh{}, fmt.Errorf("file '%s' has %d samples per pixel and %d frames, only grayscale single-frame files are supported", first.path, first.samplesPerPixel, first.numFrames)
+	}
+	hasGeometry := true
+	for _, s := range slices {
+		if s.seriesUID != first.seriesUID {
+			return Mgh{}, fmt.Errorf("files '%s' and '%s' belong to different series ('%s' and '%s')", first.path, s.path, first.seriesUID, s.seriesUID)
+		}
+		if s.rows != first.rows || s.columns != first.columns || s.bitsAllocated != first.bitsAllocated || s.samplesPerPixel != first.samplesPerPixel || s.numFrames != first.numFrames {
+			return Mgh{}, fmt.Errorf("files '%s' and '%s' have different image sizes or pixel formats", first.path, s.path)
+		}
+		hasGeometry = hasGeometry && len(s.position) == 3 && len(s.orientation) == 6 && len(s.pixelSpacing) == 2
+	}
+
+	hdr := MghHeader{MghVersion: 1, Dim1Length: int32(first.columns), Dim2Length: int32(first.rows), Dim3Length: int32(len(slices)), Dim4Length: 1,
+		XSize: 1, YSize: 1, ZSize: 1}
+	if first.sliceThickness > 0 {
+		hdr.ZSize = float32(first.sliceThickness)
+	}
+	if hasGeometry {
+		if err := dicomSortSlices(slices, &hdr); err != nil {
+			return Mgh{}, err
+		}
+	} else {
+		logInfo("readDicomSeries: DICOM files contain no orientation information, sorting %d slices by instance number.", len(slices))
+		sort.SliceStable(slices, func(i, j int) bool { return slices[i].instanceNumber < slices[j].instanceNumber })
+	}
+
+	numSliceValues := first.rows * first.columns
+	values := make([]float64, numSliceValues*len(slices))
+	for i, s := range slices {
+		sliceValues, err := s.pixelValues()
+		if err != nil {
+			return Mgh{}, fmt.Errorf("invalid pixel data in file '%s': %s", s.path, err)
+		}
+		// The pixel data is stored row by row, so the column index varies fastest, like the first dimension of MGH data.
+		copy(values[i*numSliceValues:], sliceValues)
+	}
+	data := dicomMghData(values)
+	hdr.MghDataType = data.MghDataType
+	return Mgh{Header: hdr, Data: data}, nil
+}
+
+// dicomSortSlices sorts slices with orientation information along the slice normal, and sets the RAS information of the volume header.
+func dicomSortSlices(slices []dicomSlice, hdr *MghHeader) error {
+	first := slices[0]
+	row := [3]float64{first.orientation[0], first.orientation[1], first.orientation[2]}
+	col := [3]float64{first.orientation[3], first.orientation[4], first.orientation[5]}
+	normal := [3]float64{row[1]*col[2] - row[2]*col[1], row[2]*col[0] - row[0]*col[2], row[0]*col[1] - row[1]*col[0]}
+	for _, s := range slices {
+		for k := 0; k < 6; k++ {
+			if math.Abs(s.orientation[k]-first.orientation[k]) > 1e-4 {
+				return fmt.Errorf("files '%s' and '%s' have different image orientations", first.path, s.path)
+			}
+		}
+	}
+
+	sliceDist := func(s dicomSlice) float64 {
+		return s.position[0]*normal[0] + s.position[1]*normal[1] + s.position[2]*normal[2]
+	}
+	sort.SliceStable(slices, func(i, j int) bool { return sliceDist(slices[i]) < sliceDist(slices[j]) })
+
+	// The pixel spacing gives the distance between rows first, i.e., the spacing along the column direction.
+	spacing := [3]float64{first.pixelSpacing[1], first.pixelSpacing[0], float64(hdr.ZSize)}
+	n := len(slices)
+	if n > 1 {
+		spacing[2] = (sliceDist(slices[n-1]) - sliceDist(slices[0])) / float64(n-1)
+		if spacing[2] < 1e-4 {
+			return fmt.Errorf("several files have the same slice position, the files contain more than one volume")
+		}
+		for i := 1; i < n; i++ {
+			if gap := sliceDist(slices[i]) - sliceDist(slices[i-1]); math.Abs(gap-spacing[2]) > 0.01*spacing[2] {
+				return fmt.Errorf("slice spacing is not uniform, got a gap of %f mm between files '%s' and '%s' but an average of %f mm", gap, slices[i-1].path, slices[i].path, spacing[2])
+			}
+		}
+		for k := 0; k < 3; k++ {
+			if step := (slices[n-1].position[k] - slices[0].position[k]) / float64(n-1); math.Abs(step-normal[k]*spacing[2]) > 0.01*spacing[2] {
+				return fmt.Errorf("slices are not stacked along the slice normal, e.g., due to a gantry tilt, which is not supported")
+			}
+		}
+	}
+
+	// Convert the axis directions and the center of the volume from LPS to RAS.
+	axes := [3][3]float64{row, col, normal}
+	dims := [3]float64{float64(hdr.Dim1Length), float64(hdr.Dim2Length), float64(hdr.Dim3Length)}
+	lpsToRas := [3]float64{-1, -1, 1}
+	for k := 0; k < 3; k++ {
+		center := slices[0].position[k]
+		for j := 0; j < 3; j++ {
+			hdr.Mdc[j*3+k] = float32(axes[j][k] * lpsToRas[k])
+			center += dims[j] / 2 * spacing[j] * axes[j][k]
+		}
+		hdr.Pxyz_c[k] = float32(center * lpsToRas[k])
+	}
+	hdr.XSize, hdr.YSize, hdr.ZSize = float32(spacing[0]), float32(spacing[1]), float32(spacing[2])
+	hdr.RasGoodFlag = 1
+	return nil
+}
@@ -0,0 +1,201 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeDicomElement appends a data element in little endian byte order to a buffer, padding the value to even length.
+func writeDicomElement(buf *bytes.Buffer, explicit bool, tag uint32, vr string, value []byte) {
+	if len(value)%2 == 1 {
+		pad := byte(' ')
+		if vr == "UI" || vr == "OB" {
+			pad = 0
+		}
+		value = append(append([]byte{}, value...), pad)
+	}
+	binary.Write(buf, binary.LittleEndian, []uint16{uint16(tag >> 16), uint16(tag)})
+	if explicit {
+		buf.WriteString(vr)
+		if dicomLongVRs[vr] {
+			binary.Write(buf, binary.LittleEndian, uint16(0))
+			binary.Write(buf, binary.LittleEndian, uint32(len(value)))
+		} else {
+			binary.Write(buf, binary.LittleEndian, uint16(len(value)))
+		}
+	} else {
+		binary.Write(buf, binary.LittleEndian, uint32(len(value)))
+	}
+	buf.Write(value)
+}
+
+// testDicomSlice describes a slice of a synthetic DICOM series, see testDicomBytes.
+type testDicomSlice struct {
+	transferSyntax string
+	seriesUID      string
+	instanceNumber int
+	sliceZ         float64 // the z coordinate of ImagePositionPatient
+	slope          string  // the RescaleSlope, empty if not set
+	pixels         []int16 // 3 columns x 2 rows of pixels, stored as signed 16 bit values
+}
+
+// testDicomBytes creates a DICOM file for an axial slice with 3 columns and 2 rows, with a pixel spacing of 0.8 mm between columns and 0.5 mm between rows.
+func testDicomBytes(s testDicomSlice) []byte {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 128))
+	buf.WriteString("DICM")
+	writeDicomElement(&buf, true, dicomTagTransferSyntaxUID, "UI", []byte(s.transferSyntax))
+
+	explicit := s.transferSyntax != dicomImplicitVRLittleEndian
+	us := func(v uint16) []byte { return binary.LittleEndian.AppendUint16(nil, v) }
+	writeDicomElement(&buf, explicit, 0x00080060, "CS", []byte("MR"))
+	// A referenced image sequence of undefined length, with an item of undefined length, which has to be skipped.
+	binary.Write(&buf, binary.LittleEndian, []uint16{0x0008, 0x1140})
+	if explicit {
+		buf.WriteString("SQ")
+		binary.Write(&buf, binary.LittleEndian, uint16(0))
+	}
+	binary.Write(&buf, binary.LittleEndian, []uint32{0xFFFFFFFF, 0xE000FFFE, 0xFFFFFFFF})
+	writeDicomElement(&buf, explicit, 0x00081150, "UI", []byte("1.2.3"))
+	binary.Write(&buf, binary.LittleEndian, []uint32{0xE00DFFFE, 0, 0xE0DDFFFE, 0})
+	writeDicomElement(&buf, explicit, dicomTagSliceThickness, "DS", nil)
+	writeDicomElement(&buf, explicit, dicomTagSeriesInstanceUID, "UI", []byte(s.seriesUID))
+	writeDicomElement(&buf, explicit, dicomTagInstanceNumber, "IS", []byte(fmt.Sprint(s.instanceNumber)))
+	writeDicomElement(&buf, explicit, dicomTagImagePositionPatient, "DS", []byte(fmt.Sprintf("-5\\-6\\%g", s.sliceZ)))
+	writeDicomElement(&buf, explicit, dicomTagImageOrientationPatient, "DS", []byte("1\\0\\0\\0\\1\\0"))
+	writeDicomElement(&buf, explicit, dicomTagSamplesPerPixel, "US", us(1))
+	writeDicomElement(&buf, explicit, dicomTagRows, "US", us(2))
+	writeDicomElement(&buf, explicit, dicomTagColumns, "US", us(3))
+	writeDicomElement(&buf, explicit, dicomTagPixelSpacing, "DS", []byte("0.5\\0.8"))
+	writeDicomElement(&buf, explicit, dicomTagBitsAllocated, "US", us(16))
+	writeDicomElement(&buf, explicit, dicomTagPixelRepresentation, "US", us(1))
+	if s.slope != "" {
+		writeDicomElement(&buf, explicit, dicomTagRescaleSlope, "DS", []byte(s.slope))
+	}
+	var pixels bytes.Buffer
+	binary.Write(&pixels, binary.LittleEndian, s.pixels)
+	writeDicomElement(&buf, explicit, dicomTagPixelData, "OW", pixels.Bytes())
+	return buf.Bytes()
+}
+
+// writeTestDicomSeries writes a series of 4 slices at z = 10, 12, 14, 16 mm to a directory, in shuffled order and with instance numbers that do not match the slice order.
+// The pixel at column i and row j of slice k has value 100*k + 10*j + i.
+func writeTestDicomSeries(t *testing.T, transferSyntax string) string {
+	dir := t.TempDir()
+	for n, k := range []int{2, 0, 3, 1} {
+		pixels := make([]int16, 6)
+		for j := 0; j < 2; j++ {
+			for i := 0; i < 3; i++ {
+				pixels[j*3+i] = int16(100*k + 10*j + i)
+			}
+		}
+		bs := testDicomBytes(testDicomSlice{transferSyntax: transferSyntax, seriesUID: "1.2.840.1", instanceNumber: n + 1, sliceZ: 10 + 2*float64(k), pixels: pixels})
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("IM%04d", n)), bs, 0644); err != nil {
+			t.Fatalf("could not write DICOM file: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestReadDicomSeries(t *testing.T) {
+	for _, transferSyntax := range []string{dicomExplicitVRLittleEndian, dicomImplicitVRLittleEndian} {
+		dir := writeTestDicomSeries(t, transferSyntax)
+		os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a DICOM file"), 0644)
+
+		mgh, err := ReadDicomSeries(dir)
+		if err != nil {
+			t.Fatalf("ReadDicomSeries failed for transfer syntax %s: %v", transferSyntax, err)
+		}
+		hdr := mgh.Header
+		if hdr.Dim1Length != 3 || hdr.Dim2Length != 2 || hdr.Dim3Length != 4 || hdr.Dim4Length != 1 || hdr.MghDataType != MRI_SHORT {
+			t.Fatalf("got unexpected dimensions %dx%dx%dx%d or data type %d", hdr.Dim1Length, hdr.Dim2Length, hdr.Dim3Length, hdr.Dim4Length, hdr.MghDataType)
+		}
+		for k := 0; k < 4; k++ {
+			for j := 0; j < 2; j++ {
+				for i := 0; i < 3; i++ {
+					if v := mgh.Data.DataMriShort[i+j*3+k*6]; v != int16(100*k+10*j+i) {
+						t.Fatalf("got value %d for voxel (%d, %d, %d), want %d", v, i, j, k, 100*k+10*j+i)
+					}
+				}
+			}
+		}
+
+		// The first pixel is at LPS (-5, -6, 10), the columns go to the left and the rows to the posterior.
+		vox2ras, err := MghVox2Ras(hdr)
+		if err != nil {
+			t.Fatalf("MghVox2Ras failed: %v", err)
+		}
+		want := [16]float32{-0.8, 0, 0, 5, 0, -0.5, 0, 6, 0, 0, 2, 10, 0, 0, 0, 1}
+		if diff := cmp.Diff(want, vox2ras, cmp.Comparer(func(a, b float32) bool { return math.Abs(float64(a-b)) < 1e-4 })); diff != "" {
+			t.Errorf("vox2ras mismatch for transfer syntax %s (-want +got):\n%s", transferSyntax, diff)
+		}
+	}
+}
+
+func TestReadDicomSeriesRescale(t *testing.T) {
+	dir := t.TempDir()
+	bs := testDicomBytes(testDicomSlice{transferSyntax: dicomExplicitVRLittleEndian, seriesUID: "1", sliceZ: 0, slope: "0.5", pixels: []int16{1, 2, 3, 4, 5, 6}})
+	path := filepath.Join(dir, "slice.dcm")
+	os.WriteFile(path, bs, 0644)
+
+	mgh, err := ReadDicomSeriesFromFiles([]string{path})
+	if err != nil {
+		t.Fatalf("ReadDicomSeriesFromFiles failed: %v", err)
+	}
+	if diff := cmp.Diff([]float32{0.5, 1, 1.5, 2, 2.5, 3}, mgh.Data.DataMriFloat); diff != "" || mgh.Header.MghDataType != MRI_FLOAT {
+		t.Errorf("got data type %d and unexpected rescaled values (-want +got):\n%s", mgh.Header.MghDataType, diff)
+	}
+}
+
+func TestDicomMghData(t *testing.T) {
+	tests := []struct {
+		values   []float64
+		dataType int32
+	}{
+		{[]float64{0, 255}, MRI_UCHAR},
+		{[]float64{-1, 255}, MRI_SHORT},
+		{[]float64{0, 40000}, MRI_INT},
+		{[]float64{0, 0.5}, MRI_FLOAT},
+	}
+	for _, test := range tests {
+		if got := dicomMghData(test.values).MghDataType; got != test.dataType {
+			t.Errorf("got data type %d for values %v, want %d", got, test.values, test.dataType)
+		}
+	}
+}
+
+func TestReadDicomSeriesInvalid(t *testing.T) {
+	if _, err := ReadDicomSeries(t.TempDir()); err == nil {
+		t.Errorf("got no error for empty directory, wanted one")
+	}
+
+	dir := writeTestDicomSeries(t, dicomExplicitVRLittleEndian)
+	other := testDicomBytes(testDicomSlice{transferSyntax: dicomExplicitVRLittleEndian, seriesUID: "1.2.840.2", sliceZ: 18, pixels: make([]int16, 6)})
+	os.WriteFile(filepath.Join(dir, "other_series"), other, 0644)
+	if _, err := ReadDicomSeries(dir); err == nil {
+		t.Errorf("got no error for files from several series, wanted one")
+	}
+
+	dir = writeTestDicomSeries(t, dicomExplicitVRLittleEndian)
+	duplicate := testDicomBytes(testDicomSlice{transferSyntax: dicomExplicitVRLittleEndian, seriesUID: "1.2.840.1", sliceZ: 12, pixels: make([]int16, 6)})
+	os.WriteFile(filepath.Join(dir, "duplicate"), duplicate, 0644)
+	if _, err := ReadDicomSeries(dir); err == nil {
+		t.Errorf("got no error for non-uniform slice spacing, wanted one")
+	}
+
+	jpeg := testDicomBytes(testDicomSlice{transferSyntax: "1.2.840.10008.1.2.4.50", seriesUID: "1", pixels: make([]int16, 6)})
+	if _, err := readDicomSliceFromBytes(jpeg); err == nil {
+		t.Errorf("got no error for compressed transfer syntax, wanted one")
+	}
+	truncated := testDicomBytes(testDicomSlice{transferSyntax: dicomExplicitVRLittleEndian, seriesUID: "1", pixels: make([]int16, 6)})
+	if _, err := readDicomSliceFromBytes(truncated[:len(truncated)-4]); err == nil {
+		t.Errorf("got no error for truncated file, wanted one")
+	}
+}
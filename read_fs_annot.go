@@ -0,0 +1,245 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// FsColortable models the color lookup table of a FreeSurfer annotation. It assigns a name and a color to each region (also known as structure or label) of an atlas.
+// The slices are parallel, i.e., entry i of all slices belongs to region i.
+type FsColortable struct {
+	StructIndex []int32  // The index of the region in the atlas.
+	Name        []string // The region name, e.g., 'bankssts' or 'superiorfrontal' for the Desikan-Killiany atlas.
+	Red         []int32  // The red channel of the region color, in range 0-255.
+	Green       []int32  // The green channel of the region color, in range 0-255.
+	Blue        []int32  // The blue channel of the region color, in range 0-255.
+	Alpha       []int32  // The transparency channel of the region color, in range 0-255. Typically 0.
+	Code        []int32  // The label code of the region, computed from the color as Red + Green*2^8 + Blue*2^16 + Alpha*2^24. This is what is stored per vertex in the annotation.
+}
+
+// Struct modelling a FreeSurfer annotation, i.e., a brain parcellation that assigns each vertex of a surface to a region of an atlas.
+// Used for recon-all output files like `<subject>/label/lh.aparc.annot`.
+type FsAnnot struct {
+	VertexIndex []int32      // The vertex indices. The first vertex is 0.
+	Code        []int32      // The label code of each vertex, see FsColortable. Vertices whose code is not in the colortable are not assigned to any region.
+	Colortable  FsColortable // The regions of the atlas.
+}
+
+// Read a file in FreeSurfer annotation format.
+//
+// Parameters:
+//   - filepath: the path to the file, e.g., a recon-all output file like '<subject>/label/lh.aparc.annot'.
+//
+// Returns:
+//   - FsAnnot: the annotation
+//   - error: an error if one occurred
+func ReadFsAnnot(filepath string) (FsAnnot, error) {
+	bs, err := os.ReadFile(filepath)
+	if err != nil {
+		return FsAnnot{}, fmt.Errorf("ReadFsAnnot: could not read file '%s': %s", filepath, err)
+	}
+	annot, err := readFsAnnotFromBytes(bs)
+	if err != nil {
+		return FsAnnot{}, fmt.Errorf("ReadFsAnnot: annotation file '%s': %s", filepath, err)
+	}
+	return annot, nil
+}
+
+// Read an annotation in FreeSurfer annotation format from a reader.
+//
+// This is useful if the data does not come from a file on disk, e.g., when it was received over the network or in the browser.
+//
+// Parameters:
+//   - r: the reader providing the file contents
+//
+// Returns:
+//   - FsAnnot: the annotation
+//   - error: an error if one occurred
+func ReadFsAnnotFromReader(r io.Reader) (FsAnnot, error) {
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return FsAnnot{}, fmt.Errorf("ReadFsAnnotFromReader: could not read data: %s", err)
+	}
+	annot, err := readFsAnnotFromBytes(bs)
+	if err != nil {
+		return FsAnnot{}, fmt.Errorf("ReadFsAnnotFromReader: %s", err)
+	}
+	return annot, nil
+}
+
+// annotReader reads the big endian values of an annotation file, and remembers the first error.
+type annotReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (ar *annotReader) int32() int32 {
+	var v int32
+	if ar.err == nil {
+		ar.err = binary.Read(ar.r, binary.BigEndian, &v)
+	}
+	return v
+}
+
+// count reads an int32 that gives the number of following items, each at least minItemSize bytes long, and checks it against the remaining data.
+func (ar *annotReader) count(what string, minItemSize int) int {
+	n := ar.int32()
+	if ar.err == nil && (n < 0 || int64(n)*int64(minItemSize) > int64(ar.r.Len())) {
+		ar.err = fmt.Errorf("invalid %s %d for %d bytes of remaining data", what, n, ar.r.Len())
+	}
+	if ar.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// string reads a string stored as its int32 length followed by the characters, typically including a terminating NUL character.
+func (ar *annotReader) string() string {
+	n := ar.count("string length", 1)
+	bs := make([]byte, n)
+	if ar.err == nil {
+		_, ar.err = io.ReadFull(ar.r, bs)
+	}
+	return strings.TrimRight(string(bs), "\x00")
+}
+
+// readColortableEntry reads the name and color of a region into the colortable.
+func (ar *annotReader) readColortableEntry(ct *FsColortable, structIndex int32) {
+	name := ar.string()
+	r, g, b, a := ar.int32(), ar.int32(), ar.int32(), ar.int32()
+	ct.StructIndex = append(ct.StructIndex, structIndex)
+	ct.Name = append(ct.Name, name)
+	ct.Red = append(ct.Red, r)
+	ct.Green = append(ct.Green, g)
+	ct.Blue = append(ct.Blue, b)
+	ct.Alpha = append(ct.Alpha, a)
+	ct.Code = append(ct.Code, r+g*256+b*65536+a*16777216)
+}
+
+// readFsAnnotFromBytes parses the contents of a FreeSurfer annotation file.
+//
+// The format is: the number of vertices, followed by (vertex index, label code) pairs, a flag indicating whether a colortable is present,
+// and the colortable in the old format (version 1) or in the current format (version 2, written by recon-all).
+func readFsAnnotFromBytes(bs []byte) (FsAnnot, error) {
+	var annot FsAnnot
+	ar := &annotReader{r: bytes.NewReader(bs)}
+
+	numVertices := ar.count("number of vertices", 8)
+	annot.VertexIndex = make([]int32, numVertices)
+	annot.Code = make([]int32, numVertices)
+	for i := 0; i < numVertices; i++ {
+		annot.VertexIndex[i] = ar.int32()
+		annot.Code[i] = ar.int32()
+	}
+	if ar.err != nil {
+		return annot, fmt.Errorf("could not read vertex data: %s", ar.err)
+	}
+
+	if ar.r.Len() == 0 || ar.int32() == 0 {
+		return annot, nil // no colortable
+	}
+
+	ct := &annot.Colortable
+	numEntries := ar.int32()
+	if numEntries > 0 {
+		// Old colortable format: the entries are stored in order of their struct index.
+		ar.string() // the filename of the colortable
+		for i := int32(0); i < numEntries && ar.err == nil; i++ {
+			ar.readColortableEntry(ct, i)
+		}
+	} else {
+		if version := -numEntries; version != 2 {
+			return annot, fmt.Errorf("unsupported colortable version %d", version)
+		}
+		ar.int32()  // the maximal struct index
+		ar.string() // the filename of the colortable
+		numEntriesToRead := ar.count("number of colortable entries", 24)
+		for i := 0; i < numEntriesToRead && ar.err == nil; i++ {
+			ar.readColortableEntry(ct, ar.int32())
+		}
+	}
+	if ar.err != nil {
+		return annot, fmt.Errorf("could not read colortable: %s", ar.err)
+	}
+	return annot, nil
+}
+
+// annotLabel extracts the vertices with the given label code from an annotation as a label.
+func annotLabel(annot FsAnnot, mesh Mesh, code int32) FsLabel {
+	var label FsLabel
+	useCoords := len(mesh.Vertices) > 0
+	for i, c := range annot.Code {
+		if c != code {
+			continue
+		}
+		v := annot.VertexIndex[i]
+		var x, y, z float32
+		if useCoords {
+			x, y, z = mesh.Vertices[v*3], mesh.Vertices[v*3+1], mesh.Vertices[v*3+2]
+		}
+		label.ElementIndex = append(label.ElementIndex, v)
+		label.CoordX = append(label.CoordX, x)
+		label.CoordY = append(label.CoordY, y)
+		label.CoordZ = append(label.CoordZ, z)
+		label.Value = append(label.Value, 0)
+	}
+	return label
+}
+
+// checkAnnotMesh checks that the vertex indices of an annotation are valid for a mesh, unless the mesh is empty.
+func checkAnnotMesh(annot FsAnnot, mesh Mesh) error {
+	if len(mesh.Vertices) == 0 {
+		return nil
+	}
+	for _, v := range annot.VertexIndex {
+		if err := checkVertexIndex(mesh, v); err != nil {
+			return fmt.Errorf("annotation is invalid for this mesh: %s", err)
+		}
+	}
+	return nil
+}
+
+// AnnotRegionToLabel extracts a region of an annotation by its name as a label, like FreeSurfer's mri_annotation2label.
+//
+// This allows using individual regions of a parcellation, like the aparc regions, as masks (see VertexIsPartOfLabel) or seeds.
+//
+// Parameters:
+//   - annot: the annotation, see ReadFsAnnot
+//   - mesh: the surface the annotation belongs to, typically '<subject>/surf/lh.white'. Its vertex coordinates are stored in the label. Pass an empty Mesh{} to set all coordinates to 0.
+//   - regionName: the name of the region in the colortable of the annotation, e.g., 'superiorfrontal'
+//
+// Returns:
+//   - FsLabel: the label, containing all vertices of the region. The values are all 0.
+//   - error: an error if the region name is not in the colortable, or the annotation does not match the mesh
+func AnnotRegionToLabel(annot FsAnnot, mesh Mesh, regionName string) (FsLabel, error) {
+	for i, name := range annot.Colortable.Name {
+		if name == regionName {
+			if err := checkAnnotMesh(annot, mesh); err != nil {
+				return FsLabel{}, fmt.Errorf("AnnotRegionToLabel: %s", err)
+			}
+			return annotLabel(annot, mesh, annot.Colortable.Code[i]), nil
+		}
+	}
+	return FsLabel{}, fmt.Errorf("AnnotRegionToLabel: no region named '%s' in colortable of annotation, valid names are: %s", regionName, strings.Join(annot.Colortable.Name, ", "))
+}
+
+// AnnotCodeToLabel extracts all vertices with the given label code from an annotation as a label. See AnnotRegionToLabel for extracting a region by name.
+//
+// Parameters:
+//   - annot: the annotation, see ReadFsAnnot
+//   - mesh: the surface the annotation belongs to, see AnnotRegionToLabel. Pass an empty Mesh{} to set all coordinates to 0.
+//   - code: the label code, see FsColortable
+//
+// Returns:
+//   - FsLabel: the label, containing all vertices with the code. It is empty if no vertex has the code.
+//   - error: an error if the annotation does not match the mesh
+func AnnotCodeToLabel(annot FsAnnot, mesh Mesh, code int32) (FsLabel, error) {
+	if err := checkAnnotMesh(annot, mesh); err != nil {
+		return FsLabel{}, fmt.Errorf("AnnotCodeToLabel: %s", err)
+	}
+	return annotLabel(annot, mesh, code), nil
+}
@@ -0,0 +1,125 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testAnnotBytes creates the contents of an annotation file for 4 vertices and 2 regions, with a colortable in the given format version (1 or 2).
+func testAnnotBytes(colortableVersion int) []byte {
+	var buf bytes.Buffer
+	write := func(values ...interface{}) {
+		for _, v := range values {
+			binary.Write(&buf, binary.BigEndian, v)
+		}
+	}
+	writeString := func(s string) {
+		write(int32(len(s) + 1))
+		buf.WriteString(s)
+		buf.WriteByte(0)
+	}
+	codeA := int32(20 + 30*256 + 140*65536)
+	codeB := int32(220 + 180*256 + 140*65536)
+	write(int32(4), int32(0), codeA, int32(1), codeB, int32(2), codeA, int32(3), int32(0))
+	write(int32(1)) // has colortable
+	if colortableVersion == 1 {
+		write(int32(2))
+		writeString("colortable.txt")
+		writeString("bankssts")
+		write(int32(20), int32(30), int32(140), int32(0))
+		writeString("cuneus")
+		write(int32(220), int32(180), int32(140), int32(0))
+	} else {
+		write(int32(-2), int32(36))
+		writeString("colortable.txt")
+		write(int32(2), int32(1))
+		writeString("bankssts")
+		write(int32(20), int32(30), int32(140), int32(0), int32(5))
+		writeString("cuneus")
+		write(int32(220), int32(180), int32(140), int32(0))
+	}
+	return buf.Bytes()
+}
+
+func TestReadFsAnnotFromReader(t *testing.T) {
+	for _, version := range []int{1, 2} {
+		annot, err := ReadFsAnnotFromReader(bytes.NewReader(testAnnotBytes(version)))
+		if err != nil {
+			t.Fatalf("ReadFsAnnotFromReader failed for colortable version %d: %v", version, err)
+		}
+		if diff := cmp.Diff([]int32{0, 1, 2, 3}, annot.VertexIndex); diff != "" {
+			t.Error(diff)
+		}
+		want := FsColortable{
+			StructIndex: []int32{1, 5},
+			Name:        []string{"bankssts", "cuneus"},
+			Red:         []int32{20, 220},
+			Green:       []int32{30, 180},
+			Blue:        []int32{140, 140},
+			Alpha:       []int32{0, 0},
+			Code:        []int32{20 + 30*256 + 140*65536, 220 + 180*256 + 140*65536},
+		}
+		if version == 1 {
+			want.StructIndex = []int32{0, 1}
+		}
+		if diff := cmp.Diff(want, annot.Colortable); diff != "" {
+			t.Errorf("colortable version %d mismatch (-want +got):\n%s", version, diff)
+		}
+		if annot.Code[1] != want.Code[1] {
+			t.Errorf("got code %d for vertex 1, want %d", annot.Code[1], want.Code[1])
+		}
+	}
+}
+
+func TestReadFsAnnotInvalid(t *testing.T) {
+	bs := testAnnotBytes(2)
+	if _, err := ReadFsAnnotFromReader(bytes.NewReader(bs[:20])); err == nil {
+		t.Errorf("got no error for truncated vertex data, wanted one")
+	}
+	if _, err := ReadFsAnnotFromReader(bytes.NewReader(bs[:len(bs)-6])); err == nil {
+		t.Errorf("got no error for truncated colortable, wanted one")
+	}
+	if _, err := ReadFsAnnot("testdata/does_not_exist.annot"); err == nil {
+		t.Errorf("got no error for missing file, wanted one")
+	}
+}
+
+func TestAnnotRegionToLabel(t *testing.T) {
+	annotFile := filepath.Join(t.TempDir(), "lh.test.annot")
+	os.WriteFile(annotFile, testAnnotBytes(2), 0644)
+	annot, err := ReadFsAnnot(annotFile)
+	if err != nil {
+		t.Fatalf("ReadFsAnnot failed: %v", err)
+	}
+	mesh := Mesh{Vertices: []float32{0, 0, 0, 1, 1, 1, 2, 2, 2, 3, 3, 3}, Faces: []int32{0, 1, 2, 1, 3, 2}}
+
+	label, err := AnnotRegionToLabel(annot, mesh, "bankssts")
+	if err != nil {
+		t.Fatalf("AnnotRegionToLabel failed: %v", err)
+	}
+	want := FsLabel{ElementIndex: []int32{0, 2}, CoordX: []float32{0, 2}, CoordY: []float32{0, 2}, CoordZ: []float32{0, 2}, Value: []float32{0, 0}}
+	if diff := cmp.Diff(want, label); diff != "" {
+		t.Error(diff)
+	}
+
+	label, err = AnnotCodeToLabel(annot, Mesh{}, annot.Colortable.Code[1])
+	if err != nil {
+		t.Fatalf("AnnotCodeToLabel failed: %v", err)
+	}
+	want = FsLabel{ElementIndex: []int32{1}, CoordX: []float32{0}, CoordY: []float32{0}, CoordZ: []float32{0}, Value: []float32{0}}
+	if diff := cmp.Diff(want, label); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := AnnotRegionToLabel(annot, mesh, "precuneus"); err == nil {
+		t.Errorf("got no error for unknown region name, wanted one")
+	}
+	if _, err := AnnotRegionToLabel(annot, Mesh{Vertices: mesh.Vertices[:9], Faces: []int32{0, 1, 2}}, "bankssts"); err == nil {
+		t.Errorf("got no error for mesh with too few vertices, wanted one")
+	}
+}
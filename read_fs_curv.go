@@ -1,7 +1,6 @@
 package neuro
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/binary"
 	"fmt"
@@ -9,103 +8,133 @@ import (
 	"os"
 )
 
+// fsCurvMagic holds the 3 magic bytes at the start of a FreeSurfer curv file, i.e., the 3-byte integer -1 (NEW_VERSION_MAGIC_NUMBER in FreeSurfer).
+var fsCurvMagic = [3]uint8{255, 255, 255}
+
 // Read a binary file in FreeSurfer curv format.
 //
 // Curv files are used to store per-vertex descriptors like cortical thickness in native space (i.e., for a single subject, not mapped to a group template).
+// FreeSurfer writes these files in big endian byte order. Byte-swapped files written by third-party tools on little endian machines are detected and read correctly.
 //
 // Parameters:
-//  - filepath: the path to the file, must be a FreeSurfer curv file from recon-all output, like subject/surf/lh.thickness.
+//   - filepath: the path to the file, must be a FreeSurfer curv file from recon-all output, like subject/surf/lh.thickness.
 //
 // Returns:
-//  - pervertex_data: float32 array of per-vertex descriptor values (e.g. cortical thickness)
-//  - error: an error if one occurred
+//   - pervertex_data: float32 array of per-vertex descriptor values (e.g. cortical thickness)
+//   - error: an error if one occurred
 func ReadFsCurv(filepath string) ([]float32, error) {
-	//b := []byte{0x18, 0x2d, 0x44, 0x54, 0xfb, 0x21, 0x09, 0x40, 0xff, 0x01, 0x02, 0x03, 0xbe, 0xef}
-	//r := bytes.NewReader(b)
 
-	endian := binary.BigEndian
 	pervertex_data := []float32{}
 
-	if _, err := os.Stat(filepath); err != nil {
-		fmt.Printf("Could not stat file '%s'\n.", filepath)
+	bs, err := os.ReadFile(filepath)
+	if err != nil {
+		err = fmt.Errorf("ReadFsCurv: could not read file '%s': %s", filepath, err)
 		return pervertex_data, err
 	}
 
-	file, err := os.Open(filepath)
+	pervertex_data, err = readFsCurvFromBytes(bs)
 	if err != nil {
-   		panic(err)
+		err = fmt.Errorf("ReadFsCurv: failed to parse curv file '%s': %s", filepath, err)
+		return pervertex_data, err
 	}
-	defer file.Close()
+	return pervertex_data, nil
+}
 
-	// Get the file size
-	stat, err := file.Stat()
+// ReadFsCurvFromReader reads per-vertex data in FreeSurfer curv format from a reader.
+//
+// This is useful if the data does not come from a file on disk, e.g., when it was received over the network or in the browser. Use bytes.NewReader for data in a byte slice.
+//
+// Parameters:
+//   - r: the reader providing the file contents
+//
+// Returns:
+//   - pervertex_data: float32 array of per-vertex descriptor values (e.g. cortical thickness)
+//   - error: an error if one occurred
+func ReadFsCurvFromReader(r io.Reader) ([]float32, error) {
+	bs, err := io.ReadAll(r)
 	if err != nil {
-	   fmt.Println(err)
-	   return pervertex_data, err
-	}
-
-	// Read the file into a byte slice
-	bs := make([]byte, stat.Size())
-	_, err = bufio.NewReader(file).Read(bs)
-	if err != nil && err != io.EOF {
-	   fmt.Println(err)
-	   return pervertex_data, err
+		return []float32{}, fmt.Errorf("ReadFsCurvFromReader: could not read data: %s", err)
 	}
-
-	// Read the byte slice
-	r := bytes.NewReader(bs)
-
-	type curvHeaderPart1 struct {
-		MagicB1 uint8
-		MagicB2 uint8
-		MagicB3 uint8
+	pervertex_data, err := readFsCurvFromBytes(bs)
+	if err != nil {
+		return pervertex_data, fmt.Errorf("ReadFsCurvFromReader: failed to parse curv data: %s", err)
 	}
+	return pervertex_data, nil
+}
 
-	hdr1 := curvHeaderPart1{}
+// readFsCurvFromBytes parses the contents of a FreeSurfer curv file.
+//
+// The magic bytes of the curv format are the same in both byte orders, so the byte order is
+// detected from the NumValuesPerVertex header field, which is always 1.
+//
+// Parameters:
+//   - bs: the full file contents
+//
+// Returns:
+//   - pervertex_data: float32 array of per-vertex descriptor values (e.g. cortical thickness)
+//   - error: an error if one occurred
+func readFsCurvFromBytes(bs []byte) ([]float32, error) {
 
+	pervertex_data := []float32{}
+	r := bytes.NewReader(bs)
 
-	if err := binary.Read(r, endian, &hdr1); err != nil {
-		fmt.Println("ReadFsCurv: binary.Read failed on curv header part 1:", err)
+	var magic [3]uint8
+	if err := binary.Read(r, binary.BigEndian, &magic); err != nil {
+		err = fmt.Errorf("binary.Read failed on curv header part 1: %s", err)
 		return pervertex_data, err
 	}
 
-	if Verbosity > 0 {
-		fmt.Printf("ReadFsCurv: Curv header magic bytes: %d %d %d.\n", hdr1.MagicB1, hdr1.MagicB2, hdr1.MagicB3)
-	}
-
+	logInfo("ReadFsCurv: Curv header magic bytes: %d %d %d.", magic[0], magic[1], magic[2])
 
-	if ! (hdr1.MagicB1 == 255 && hdr1.MagicB2 == 255 && hdr1.MagicB3 == 255) {
-		fmt.Println("ReadFsCurv: Error: Curv magic bytes are not 255 255 255, this is not a FreeSurfer curv file. Provide a recon-all output file like '<subject>/surf/lh.thickness'.")
+	if magic != fsCurvMagic {
+		err := fmt.Errorf("curv magic bytes are not 255 255 255, this is not a FreeSurfer curv file. Provide a recon-all output file like '<subject>/surf/lh.thickness'")
 		return pervertex_data, err
 	}
 
 	type curvHeaderPart2 struct {
-		NumVertices int32
-		NumFaces int32
+		NumVertices        int32
+		NumFaces           int32
 		NumValuesPerVertex int32
 	}
 
 	hdr2 := curvHeaderPart2{}
 
-
+	var endian binary.ByteOrder = binary.BigEndian
 	if err := binary.Read(r, endian, &hdr2); err != nil {
-		fmt.Println("ReadFsCurv: binary.Read failed on curv header part 2:", err)
+		err = fmt.Errorf("binary.Read failed on curv header part 2: %s", err)
 		return pervertex_data, err
 	}
 
-	if Verbosity > 0 {
-		fmt.Println("ReadFsCurv: NumVertices:", hdr2.NumVertices)
-		fmt.Println("ReadFsCurv: NumFaces:", hdr2.NumFaces)
-		fmt.Println("ReadFsCurv: NumValuesPerVertex:", hdr2.NumValuesPerVertex)
+	if hdr2.NumValuesPerVertex != 1 {
+		// Try the other byte order.
+		endian = binary.LittleEndian
+		r.Seek(3, io.SeekStart)
+		if err := binary.Read(r, endian, &hdr2); err != nil {
+			err = fmt.Errorf("binary.Read failed on curv header part 2: %s", err)
+			return pervertex_data, err
+		}
+		if hdr2.NumValuesPerVertex != 1 {
+			err := fmt.Errorf("curv header field NumValuesPerVertex must be 1 in either byte order, this is not a valid FreeSurfer curv file")
+			return pervertex_data, err
+		}
+	}
+
+	logInfo("ReadFsCurv: Byte order: %s", endian)
+	logInfo("ReadFsCurv: NumVertices: %d", hdr2.NumVertices)
+	logInfo("ReadFsCurv: NumFaces: %d", hdr2.NumFaces)
+	logInfo("ReadFsCurv: NumValuesPerVertex: %d", hdr2.NumValuesPerVertex)
+
+	if hdr2.NumVertices < 0 || int64(hdr2.NumVertices)*4 > int64(r.Len()) {
+		err := fmt.Errorf("curv header declares %d vertices, but only %d bytes of data are left", hdr2.NumVertices, r.Len())
+		return pervertex_data, err
 	}
 
 	// read per-vertex data
-	pervertex_data = make([]float32, hdr2.NumVertices) 	// one descriptor value per vertex
+	pervertex_data = make([]float32, hdr2.NumVertices) // one descriptor value per vertex
 	if err := binary.Read(r, endian, &pervertex_data); err != nil {
-		fmt.Println("ReadFsCurv: binary.Read failed on per-vertex descriptor slice:", err)
+		err = fmt.Errorf("binary.Read failed on per-vertex descriptor slice: %s", err)
 		return pervertex_data, err
 	}
 
 	return pervertex_data, nil
 }
-
@@ -3,8 +3,13 @@ package neuro
 // https://pkg.go.dev/testing
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"os"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestReadFsCurv(t *testing.T){
@@ -47,3 +52,46 @@ func ExampleReadFsCurv() {
 	fmt.Printf("Read %d values from curv file '%s'.\n", len(pvdata), curvFile)
 	// Output: Read 149244 values from curv file 'testdata/lh.thickness'.
 }
+
+func TestReadFsCurvLittleEndian(t *testing.T) {
+	data := []float32{1.0, 2.0, 3.0, 4.0, 5.0}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{255, 255, 255})
+	binary.Write(&buf, binary.LittleEndian, []int32{int32(len(data)), 0, 1})
+	binary.Write(&buf, binary.LittleEndian, data)
+
+	got, err := readFsCurvFromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("got error %v when reading little endian curv data", err)
+	}
+
+	if diff := cmp.Diff(data, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReadFsCurvInvalidMagic(t *testing.T) {
+	var surfFile string = "testdata/lh.white"
+
+	_, err := ReadFsCurv(surfFile)
+	if err == nil {
+		t.Errorf("got no error when reading a surface file as curv, wanted one")
+	}
+}
+
+func TestReadFsCurvFromReader(t *testing.T) {
+	want, err := ReadFsCurv("testdata/lh.thickness")
+	if err != nil {
+		t.Fatalf("ReadFsCurv failed: %s", err)
+	}
+
+	bs, _ := os.ReadFile("testdata/lh.thickness")
+	got, err := ReadFsCurvFromReader(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatalf("ReadFsCurvFromReader failed: %s", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadFsCurvFromReader() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -3,6 +3,7 @@ package neuro
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 )
@@ -32,15 +33,11 @@ type FsLabel struct {
 //
 // Returns:
 //  - is_part_of_label: a bool array of length meshNumVertices, where each element is true if the vertex is part of the label, and false otherwise.
-//  - error: an error if one occurred, e.g., the number of vertices in the mesh is less than the number of elements in the label.
+//  - error: an error if one occurred, e.g., the label contains a vertex index that is out of range for the mesh.
 func VertexIsPartOfLabel(label FsLabel, meshNumVertices int32) ([]bool, error) {
-	if meshNumVertices  < int32(len(label.ElementIndex)) {
-		err := fmt.Errorf("vertexIsPartOfLabel: number of vertices in mesh (%d) is less than number of elements in label (%d), label invalid for this mesh. ", meshNumVertices, len(label.ElementIndex))
-		return nil, err
-	}
-	is_part_of_label := make([]bool, meshNumVertices)  // default value is false
-	for _, element_index := range label.ElementIndex {
-		is_part_of_label[element_index] = true
+	is_part_of_label, err := LabelToMask(label, int(meshNumVertices))
+	if err != nil {
+		return nil, fmt.Errorf("vertexIsPartOfLabel: label invalid for this mesh: %s", err)
 	}
 	return is_part_of_label, nil
 }
@@ -70,6 +67,40 @@ func ReadFsLabel(filepath string) (FsLabel, error) {
 	if err != nil {
 		return label, err
 	}
+	return readFsLabelFromLines(lines, filepath)
+}
+
+// Read a label in FreeSurfer label format from a reader.
+//
+// This is useful if the data does not come from a file on disk, e.g., when it was received over the network or in the browser. See ReadFsLabel for details on the format.
+//
+// Parameters:
+//  - r: the reader providing the file contents
+//
+// Returns:
+//  - FsLabel: the label
+//  - error: an error if one occurred
+func ReadFsLabelFromReader(r io.Reader) (FsLabel, error) {
+	lines, err := readLinesFromReader(r)
+	if err != nil {
+		return FsLabel{}, err
+	}
+	return readFsLabelFromLines(lines, "<reader>")
+}
+
+// readFsLabelFromLines parses the lines of a FreeSurfer label file.
+//
+// Parameters:
+//  - lines: the lines of the file
+//  - filepath: the name of the file, used in error messages
+//
+// Returns:
+//  - FsLabel: the label
+//  - error: an error if one occurred
+func readFsLabelFromLines(lines []string, filepath string) (FsLabel, error) {
+
+	var label FsLabel
+	var err error
 	if len(lines) <= 2 {
 		err = fmt.Errorf("readFsLabel: label file '%s' contains %d lines, but at least 3 required. ", filepath, len(lines))
 		return label, err
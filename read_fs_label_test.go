@@ -3,8 +3,12 @@ package neuro
 // https://pkg.go.dev/testing
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestReadFsLabel(t *testing.T){
@@ -47,3 +51,19 @@ func ExampleReadFsLabel() {
 	fmt.Printf("Read label containing %d vertices from label file '%s'.\n", len(label.ElementIndex), labelFile)
 	// Output: Read label containing 140891 vertices from label file 'testdata/lh.cortex.label'.
 }
+
+func TestReadFsLabelFromReader(t *testing.T) {
+	want, err := ReadFsLabel("testdata/lh.cortex.label")
+	if err != nil {
+		t.Fatalf("ReadFsLabel failed: %s", err)
+	}
+
+	bs, _ := os.ReadFile("testdata/lh.cortex.label")
+	got, err := ReadFsLabelFromReader(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatalf("ReadFsLabelFromReader failed: %s", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadFsLabelFromReader() mismatch (-want +got):\n%s", diff)
+	}
+}
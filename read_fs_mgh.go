@@ -58,7 +58,7 @@ type MghData struct {
 	MghDataType  int32     // The MRI data type code. See MRI_UCHAR, MRI_INT, MRI_FLOAT, MRI_SHORT. Use this to determine which of the data fields above is valid.
 }
 
-// getMghDataTypeName translates an MRI data type code (int32) into the respective name (string).
+// MghDataTypeName translates an MRI data type code (int32) into the respective name (string).
 //
 // Parameters:
 //   - dtCode: The MRI data type code, e.g., integer constants MRI_UCHAR, MRI_INT, MRI_FLOAT, MRI_SHORT.
@@ -66,7 +66,7 @@ type MghData struct {
 // Returns:
 //   - string: The MRI data type name, e.g., "MRI_UCHAR", "MRI_INT", "MRI_FLOAT", "MRI_SHORT".
 //   - error: Error if any, e.g., if the data type code is invalid or unsupported.
-func getMghDataTypeName(dtCode int32) (string, error) {
+func MghDataTypeName(dtCode int32) (string, error) {
 
 	switch dt := dtCode; dt {
 	case MRI_UCHAR:
@@ -78,7 +78,7 @@ func getMghDataTypeName(dtCode int32) (string, error) {
 	case MRI_SHORT:
 		return "MRI_SHORT", nil
 	default:
-		err := fmt.Errorf("Invalid or unsupported MGH data type code '%d'. Supported are 0=uchar/uint8, 1=int32, 3=float32, 4=uint16.\n", dtCode)
+		err := fmt.Errorf("MghDataTypeName: invalid or unsupported MGH data type code '%d'. Supported are 0=MRI_UCHAR (uint8), 1=MRI_INT (int32), 3=MRI_FLOAT (float32), 4=MRI_SHORT (int16)", dtCode)
 		return "", err
 	}
 }
@@ -165,7 +165,7 @@ func readFileIntoByteSlice(filepath string, treatGzipped bool) ([]byte, error) {
 	bs := make([]byte, 0)
 
 	if _, err := os.Stat(filepath); err != nil {
-		fmt.Printf("Could not stat file '%s'\n.", filepath)
+		err = fmt.Errorf("Could not stat file '%s': %s", filepath, err)
 		return bs, err
 	}
 
@@ -179,7 +179,6 @@ func readFileIntoByteSlice(filepath string, treatGzipped bool) ([]byte, error) {
 	// Get the file size
 	stat, err := file.Stat()
 	if err != nil {
-		fmt.Println(err)
 		return bs, err
 	}
 
@@ -197,9 +196,7 @@ func readFileIntoByteSlice(filepath string, treatGzipped bool) ([]byte, error) {
 			return bs, err
 		}
 	} else {
-		_, err := bufio.NewReader(file).Read(bs)
-		if err != nil && err != io.EOF {
-			fmt.Println(err)
+		if _, err := io.ReadFull(file, bs); err != nil {
 			return bs, err
 		}
 	}
@@ -218,45 +215,104 @@ func readFileIntoByteSlice(filepath string, treatGzipped bool) ([]byte, error) {
 //   - MghHeader: an MghHeader struct containing the header data
 //   - error: an error if one occurred
 func ReadFsMghHeader(filepath string, isGzipped string) (MghHeader, error) {
-	endian := binary.BigEndian
-
-	hdr := MghHeader{}
-
 	isGzipped = getIsGzippedMgh(isGzipped)
 	treatGzipped := getIsGzipped(filepath, isGzipped)
 	bs, err := readFileIntoByteSlice(filepath, treatGzipped)
 	if err != nil {
 		err = fmt.Errorf("Could not read file '%s' into byte slice: %s", filepath, err)
-		return hdr, err
+		return MghHeader{}, err
+	}
+	return readFsMghHeaderFromBytes(bs, filepath)
+}
+
+// ReadFsMghHeaderFromReader reads the header of an MGH file from a reader, without reading or decompressing the voxel data.
+//
+// This is useful for inspecting untrusted data, e.g., data received over the network: only the 284 header bytes are read and decompressed,
+// so the memory needed does not depend on the volume dimensions declared in the header.
+//
+// Parameters:
+//   - r: the reader providing the file contents. Only the start of the data is consumed, the voxel data is never decompressed.
+//   - isGzipped: Whether to treat the data as gzip-compressed (MGZ format). If "auto", this is determined from the gzip magic bytes at the start of the data. If not "auto", it has to be "yes"/"mgz" or "no"/"mgh" to force MGZ or MGH format, respectively.
+//
+// Returns:
+//   - MghHeader: an MghHeader struct containing the header data
+//   - error: an error if one occurred
+func ReadFsMghHeaderFromReader(r io.Reader, isGzipped string) (MghHeader, error) {
+	isGzipped = getIsGzippedMgh(isGzipped)
+	if isGzipped != "yes" && isGzipped != "no" && isGzipped != "auto" {
+		return MghHeader{}, fmt.Errorf("ReadFsMghHeaderFromReader: isGzipped must be one of 'yes'/'mgz', 'no'/'mgh', or 'auto', got '%s'", isGzipped)
+	}
+
+	br := bufio.NewReader(r)
+	treatGzipped := isGzipped == "yes"
+	if isGzipped == "auto" {
+		magic, _ := br.Peek(2)
+		treatGzipped = len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b
+	}
+	var hr io.Reader = br
+	if treatGzipped {
+		gzipReader, err := gzip.NewReader(br)
+		if err != nil {
+			return MghHeader{}, fmt.Errorf("ReadFsMghHeaderFromReader: could not decompress data: %s", err)
+		}
+		defer gzipReader.Close()
+		hr = gzipReader
+	}
+
+	bs := make([]byte, binary.Size(MghHeader{}))
+	if _, err := io.ReadFull(hr, bs); err != nil {
+		return MghHeader{}, fmt.Errorf("ReadFsMghHeaderFromReader: could not read MGH header: %s", err)
 	}
+	hdr, err := readFsMghHeaderFromBytes(bs, "<reader>")
+	if err != nil {
+		return hdr, fmt.Errorf("ReadFsMghHeaderFromReader: %s", err)
+	}
+	return hdr, nil
+}
+
+// readFsMghHeaderFromBytes parses the header of an MGH file.
+//
+// Parameters:
+//   - bs: the uncompressed file contents. Only the first 284 bytes are used.
+//   - source: the name of the file, used in messages
+//
+// Returns:
+//   - MghHeader: an MghHeader struct containing the header data
+//   - error: an error if one occurred
+func readFsMghHeaderFromBytes(bs []byte, source string) (MghHeader, error) {
+	endian := binary.BigEndian
+
+	hdr := MghHeader{}
 	r := bytes.NewReader(bs)
 
 	if err := binary.Read(r, endian, &hdr); err != nil {
-		fmt.Println("ReadFsMghHeader: Read failed on MGH header:", err)
+		err = fmt.Errorf("ReadFsMghHeader: Read failed on MGH header: %s", err)
 		return hdr, err
 	}
 
-	dataTypeName, err := getMghDataTypeName(hdr.MghDataType)
-	if err != nil {
+	if hdr.MghVersion == 1<<24 {
+		err := fmt.Errorf("MGH file '%s' seems to be stored in little endian byte order, but MGH files must be big endian.\n", source)
 		return hdr, err
 	}
 
-	if Verbosity > 0 {
-		fmt.Printf("ReadFsMghHeader: Mgh version=%d, dimensions: %d %d %d %d.\n", hdr.MghVersion, hdr.Dim1Length, hdr.Dim2Length, hdr.Dim3Length, hdr.Dim4Length)
-		fmt.Printf("ReadFsMghHeader: Mgh data type=%d (%s), DoF=%d, RAS good=%d.\n", hdr.MghDataType, dataTypeName, hdr.DoF, hdr.RasGoodFlag)
+	dataTypeName, err := MghDataTypeName(hdr.MghDataType)
+	if err != nil {
+		return hdr, err
 	}
 
+	logInfo("ReadFsMghHeader: Mgh version=%d, dimensions: %d %d %d %d.", hdr.MghVersion, hdr.Dim1Length, hdr.Dim2Length, hdr.Dim3Length, hdr.Dim4Length)
+	logInfo("ReadFsMghHeader: Mgh data type=%d (%s), DoF=%d, RAS good=%d.", hdr.MghDataType, dataTypeName, hdr.DoF, hdr.RasGoodFlag)
+
 	if hdr.MghVersion != 1 {
-		err := fmt.Errorf("MGH file '%s' is not a valid MGH file or has unsupported file format version (%d), while only version 1 is supported.\n", filepath, hdr.MghVersion)
+		err := fmt.Errorf("MGH file '%s' is not a valid MGH file or has unsupported file format version (%d), while only version 1 is supported.\n", source, hdr.MghVersion)
 		return hdr, err
 	}
 
-	if Verbosity > 0 && hdr.RasGoodFlag == 1 {
-		fmt.Printf("ReadFsMghHeader: Mgh voxel size x y z: %f, %f, %f.\n", hdr.XSize, hdr.YSize, hdr.ZSize)
-		fmt.Printf("ReadFsMghHeader: Mgh Mdc: row0=%f, %f, %f. row1=%f, %f, %f. row2=%f, %f, %f.\n", hdr.Mdc[0], hdr.Mdc[1], hdr.Mdc[2], hdr.Mdc[3], hdr.Mdc[4], hdr.Mdc[5], hdr.Mdc[6], hdr.Mdc[7], hdr.Mdc[8])
-		fmt.Printf("ReadFsMghHeader: Mgh Pxyz_c: %f, %f, %f.\n", hdr.Pxyz_c[0], hdr.Pxyz_c[1], hdr.Pxyz_c[2])
+	if hdr.RasGoodFlag == 1 {
+		logInfo("ReadFsMghHeader: Mgh voxel size x y z: %f, %f, %f.", hdr.XSize, hdr.YSize, hdr.ZSize)
+		logInfo("ReadFsMghHeader: Mgh Mdc: row0=%f, %f, %f. row1=%f, %f, %f. row2=%f, %f, %f.", hdr.Mdc[0], hdr.Mdc[1], hdr.Mdc[2], hdr.Mdc[3], hdr.Mdc[4], hdr.Mdc[5], hdr.Mdc[6], hdr.Mdc[7], hdr.Mdc[8])
+		logInfo("ReadFsMghHeader: Mgh Pxyz_c: %f, %f, %f.", hdr.Pxyz_c[0], hdr.Pxyz_c[1], hdr.Pxyz_c[2])
 	}
-
 	return hdr, nil
 }
 
@@ -278,14 +334,74 @@ type Mgh struct {
 // Returns:
 //   - Mgh: an Mgh struct containing the MghHeader and MghData
 func ReadFsMgh(filepath string, isGzipped string) (Mgh, error) {
+	isGzipped = getIsGzippedMgh(isGzipped)
+	treatGzipped := getIsGzipped(filepath, isGzipped)
+	bs, err := readFileIntoByteSlice(filepath, treatGzipped)
+	if err != nil {
+		err = fmt.Errorf("Could not read file '%s' into byte slice: %s", filepath, err)
+		return Mgh{}, err
+	}
+	return readFsMghFromBytes(bs, filepath)
+}
+
+// ReadFsMghFromReader reads a full MGH file, including header and data, from a reader.
+//
+// This is useful if the data does not come from a file on disk, e.g., when it was received over the network or in the browser. Use bytes.NewReader for data in a byte slice.
+//
+// Parameters:
+//   - r: the reader providing the file contents
+//   - isGzipped: Whether to treat the data as gzip-compressed (MGZ format). If "auto", this is determined from the gzip magic bytes at the start of the data. If not "auto", it has to be "yes"/"mgz" or "no"/"mgh" to force MGZ or MGH format, respectively.
+//
+// Returns:
+//   - Mgh: an Mgh struct containing the MghHeader and MghData
+//   - error: an error if one occurred
+func ReadFsMghFromReader(r io.Reader, isGzipped string) (Mgh, error) {
+	isGzipped = getIsGzippedMgh(isGzipped)
+	if isGzipped != "yes" && isGzipped != "no" && isGzipped != "auto" {
+		return Mgh{}, fmt.Errorf("ReadFsMghFromReader: isGzipped must be one of 'yes'/'mgz', 'no'/'mgh', or 'auto', got '%s'", isGzipped)
+	}
+
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return Mgh{}, fmt.Errorf("ReadFsMghFromReader: could not read data: %s", err)
+	}
+
+	treatGzipped := isGzipped == "yes"
+	if isGzipped == "auto" {
+		treatGzipped = len(bs) >= 2 && bs[0] == 0x1f && bs[1] == 0x8b // gzip magic bytes. Valid MGH files start with the version 1 as big endian int32, i.e., with a zero byte.
+	}
+	if treatGzipped {
+		gzipReader, err := gzip.NewReader(bytes.NewReader(bs))
+		if err != nil {
+			return Mgh{}, fmt.Errorf("ReadFsMghFromReader: could not decompress data: %s", err)
+		}
+		defer gzipReader.Close()
+		bs, err = io.ReadAll(gzipReader)
+		if err != nil {
+			return Mgh{}, fmt.Errorf("ReadFsMghFromReader: could not decompress data: %s", err)
+		}
+	}
+	return readFsMghFromBytes(bs, "<reader>")
+}
+
+// readFsMghFromBytes parses the header and data of an MGH file.
+//
+// Parameters:
+//   - bs: the uncompressed file contents
+//   - source: the name of the file, used in messages
+//
+// Returns:
+//   - Mgh: an Mgh struct containing the MghHeader and MghData
+//   - error: an error if one occurred
+func readFsMghFromBytes(bs []byte, source string) (Mgh, error) {
 	var mgh Mgh
-	hdr, err := ReadFsMghHeader(filepath, isGzipped)
+	hdr, err := readFsMghHeaderFromBytes(bs, source)
 	if err != nil {
 		err := fmt.Errorf("Failed to read MGH header: %s.", err)
 		return mgh, err
 	}
 	mgh.Header = hdr
-	data, err := ReadFsMghData(filepath, hdr, isGzipped)
+	data, err := readFsMghDataFromBytes(bs, hdr, source)
 	if err != nil {
 		err := fmt.Errorf("Failed to read MGH data: %s.", err)
 		return mgh, err
@@ -307,231 +423,206 @@ func ReadFsMgh(filepath string, isGzipped string) (Mgh, error) {
 //   - MghData: an MghData struct containing the data
 //   - error: an error if one occurred, nil otherwise
 func ReadFsMghData(filepath string, hdr MghHeader, isGzipped string) (MghData, error) {
-
-	readMghData := MghData{}
-	readMghData.MghDataType = -1
 	isGzipped = getIsGzippedMgh(isGzipped)
 	treatGzipped := getIsGzipped(filepath, isGzipped)
-
-	switch dt := hdr.MghDataType; dt {
-	case MRI_INT:
-		arr, err := readFsMghDataMriInt(filepath, hdr, treatGzipped)
-		if err != nil {
-			err := fmt.Errorf("Failed to read MRI_INT data from MGH file '%s': %s.\n", filepath, err)
-			return readMghData, err
-		}
-		readMghData.DataMriInt = arr
-	case MRI_FLOAT:
-		arr, err := readFsMghDataMriFloat(filepath, hdr, treatGzipped)
-		if err != nil {
-			err := fmt.Errorf("Failed to read MRI_FLOAT data from MGH file '%s': %s.\n", filepath, err)
-			return readMghData, err
-		}
-		readMghData.DataMriFloat = arr
-	case MRI_UCHAR:
-		arr, err := readFsMghDataMriUchar(filepath, hdr, treatGzipped)
-		if err != nil {
-			err := fmt.Errorf("Failed to read MRI_UCHAR data from MGH file '%s': %s.\n", filepath, err)
-			return readMghData, err
-		}
-		readMghData.DataMriUchar = arr
-	case MRI_SHORT:
-		arr, err := readFsMghDataMriShort(filepath, hdr, treatGzipped)
-		if err != nil {
-			err := fmt.Errorf("Failed to read MRI_SHORT data from MGH file '%s': %s.\n", filepath, err)
-			return readMghData, err
-		}
-		readMghData.DataMriShort = arr
-	default:
-		return readMghData, fmt.Errorf("Header of MGH file '%s' declares unsupported MGH data type code: %d.\n", filepath, dt)
+	bs, err := readFileIntoByteSlice(filepath, treatGzipped)
+	if err != nil {
+		err = fmt.Errorf("Could not read file '%s' into byte slice: %s", filepath, err)
+		return MghData{MghDataType: -1}, err
 	}
-	readMghData.MghDataType = hdr.MghDataType
-	return readMghData, nil
+	return readFsMghDataFromBytes(bs, hdr, filepath)
 }
 
-// readFsMghDataMriInt reads the MRI_INT data array part of an MGH format file.
+// readFsMghDataFromBytes parses the data part of an MGH file.
 //
 // Parameters:
-//   - filepath: path to readable input file in MGH or MGZ format
+//   - bs: the uncompressed file contents, including the header
 //   - hdr: MghHeader struct containing the header data
-//   - treatGzipped: boolean indicating whether the input file is gzipped or not
+//   - source: the name of the file, used in messages
 //
 // Returns:
-//   - []int32: an array of int32 values. You will have to reshape this 1D array to a 4D array with the dimensions given in the MghHeader.
+//   - MghData: an MghData struct containing the data
 //   - error: an error if one occurred, nil otherwise
-func readFsMghDataMriInt(filepath string, hdr MghHeader, treatGzipped bool) ([]int32, error) {
+func readFsMghDataFromBytes(bs []byte, hdr MghHeader, source string) (MghData, error) {
 
-	var numValues int64 = (int64)(hdr.Dim1Length * hdr.Dim2Length * hdr.Dim3Length * hdr.Dim4Length)
-	dataArr := make([]int32, numValues)
-	var mghDataType string = "MRI_INT"
+	readMghData := MghData{}
+	readMghData.MghDataType = -1
 
-	if Verbosity >= 1 {
-		fmt.Printf("Reading %d values of type %s from MGH file '%s', treatGzipped=%t\n", numValues, mghDataType, filepath, treatGzipped)
+	// The header is followed directly by the data, so skip it.
+	const numBytesHeader = 284
+	if len(bs) < numBytesHeader {
+		return readMghData, fmt.Errorf("MGH file '%s' is too short to contain a header", source)
 	}
+	r := bytes.NewReader(bs[numBytesHeader:])
+	numValues := int64(hdr.Dim1Length) * int64(hdr.Dim2Length) * int64(hdr.Dim3Length) * int64(hdr.Dim4Length)
 
-	endian := binary.BigEndian
-
-	bs, err := readFileIntoByteSlice(filepath, treatGzipped)
+	dataTypeName, err := MghDataTypeName(hdr.MghDataType)
 	if err != nil {
-		err = fmt.Errorf("Could not read file '%s' into byte slice: %s", filepath, err)
-		return dataArr, err
+		return readMghData, fmt.Errorf("Header of MGH file '%s' declares unsupported MGH data type code: %d.\n", source, hdr.MghDataType)
 	}
+	logInfo("Reading %d values of type %s from MGH file '%s'", numValues, dataTypeName, source)
 
-	// Read the byte slice
-	r := bytes.NewReader(bs)
-
-	// Skip the header
-	numBytesHeader := 284
-	skippedHeaderData := make([]uint8, numBytesHeader)
-	if err := binary.Read(r, endian, &skippedHeaderData); err != nil {
-		err := fmt.Errorf("ReadFsMghData: binary.Read failed on header part of MGH file %s: %s", filepath, err)
-		return dataArr, err
+	switch hdr.MghDataType {
+	case MRI_INT:
+		readMghData.DataMriInt, err = readMghValues[int32](r, numValues)
+	case MRI_FLOAT:
+		readMghData.DataMriFloat, err = readMghValues[float32](r, numValues)
+	case MRI_UCHAR:
+		readMghData.DataMriUchar, err = readMghValues[uint8](r, numValues)
+	case MRI_SHORT:
+		readMghData.DataMriShort, err = readMghValues[int16](r, numValues)
 	}
-
-	// Read the data
-	if err := binary.Read(r, endian, &dataArr); err != nil {
-		err := fmt.Errorf("ReadFsMghData: binary.Read failed on %d values of %s data: %s", numValues, mghDataType, err)
-		return dataArr, err
+	if err != nil {
+		err := fmt.Errorf("Failed to read %s data from MGH file '%s': %s.\n", dataTypeName, source, err)
+		return readMghData, err
 	}
-
-	return dataArr, nil
+	readMghData.MghDataType = hdr.MghDataType
+	return readMghData, nil
 }
 
-// readFsMghDataMriFloat reads the MRI_FLOAT data array part of an MGH format file.
+// readMghValues reads numValues big endian values of type T from the data part of an MGH file.
 //
 // Parameters:
-//   - filepath: path to readable input file in MGH or MGZ format
-//   - hdr: MghHeader struct containing the header data
-//   - treatGzipped: boolean indicating whether the input file is gzipped or not
+//   - r: reader positioned at the start of the data
+//   - numValues: the number of values to read, i.e., the product of the 4 dimensions from the header
 //
 // Returns:
-//   - []float32: an array of float32 values. You will have to reshape this 1D array to a 4D array with the dimensions given in the MghHeader.
-//   - error: an error if one occurred, nil otherwise
-func readFsMghDataMriFloat(filepath string, hdr MghHeader, treatGzipped bool) ([]float32, error) {
-
-	var numValues int64 = (int64)(hdr.Dim1Length * hdr.Dim2Length * hdr.Dim3Length * hdr.Dim4Length)
-	dataArr := make([]float32, numValues)
-	var mghDataType string = "MRI_FLOAT"
-
-	if Verbosity >= 1 {
-		fmt.Printf("Reading %d values of type %s from MGH file '%s', treatGzipped=%t\n", numValues, mghDataType, filepath, treatGzipped)
+//   - []T: the values. You will have to reshape this 1D array to a 4D array with the dimensions given in the MghHeader.
+//   - error: an error if one occurred, e.g., the data is shorter than declared in the header
+func readMghValues[T uint8 | int16 | int32 | float32](r *bytes.Reader, numValues int64) ([]T, error) {
+	var value T
+	valueSize := int64(binary.Size(value))
+	if numValues < 0 || numValues*valueSize > int64(r.Len()) {
+		return nil, fmt.Errorf("header declares %d values of %d bytes each, but only %d bytes of data are left", numValues, valueSize, r.Len())
+	}
+	dataArr := make([]T, numValues)
+	if err := binary.Read(r, binary.BigEndian, &dataArr); err != nil {
+		return dataArr, fmt.Errorf("binary.Read failed on %d values: %s", numValues, err)
 	}
+	return dataArr, nil
+}
 
-	endian := binary.BigEndian
-	bs, err := readFileIntoByteSlice(filepath, treatGzipped)
+// MghPerVertexData returns the data of an MGH volume that stores per-vertex data (a "surface-encoded volume") as a per-vertex slice.
+//
+// FreeSurfer stores per-vertex data in MGH format with the vertices along one dimension, e.g., in files like
+// 'lh.thickness.fwhm10.fsaverage.mgh' with dimensions 1x1x163842 or 163842x1x1. The data is converted to float32, whatever its MGH data type.
+//
+// Parameters:
+//   - mgh: the MGH volume, e.g., from ReadFsMgh. It must have a single frame, i.e., Dim4Length must be 1.
+//   - numVertices: the number of vertices of the surface the data belongs to, e.g., NumVertices(mesh). Pass -1 to skip this check.
+//
+// Returns:
+//   - []float32: the per-vertex data
+//   - error: an error if the volume has several frames, its data does not match its dimensions, or the number of values does not match numVertices
+func MghPerVertexData(mgh Mgh, numVertices int) ([]float32, error) {
+	hdr := mgh.Header
+	if hdr.Dim4Length != 1 {
+		return nil, fmt.Errorf("MghPerVertexData: volume has %d frames, but per-vertex data must have a single frame", hdr.Dim4Length)
+	}
+	numValues := int(hdr.Dim1Length) * int(hdr.Dim2Length) * int(hdr.Dim3Length)
+	if numVertices >= 0 && numValues != numVertices {
+		return nil, fmt.Errorf("MghPerVertexData: volume with dimensions %dx%dx%d contains %d values, but the surface has %d vertices", hdr.Dim1Length, hdr.Dim2Length, hdr.Dim3Length, numValues, numVertices)
+	}
+	numData, err := mghDataLength(mgh.Data)
 	if err != nil {
-		err = fmt.Errorf("Could not read file '%s' into byte slice: %s", filepath, err)
-		return dataArr, err
+		return nil, fmt.Errorf("MghPerVertexData: %s", err)
+	}
+	if numData != numValues {
+		return nil, fmt.Errorf("MghPerVertexData: volume with dimensions %dx%dx%d must contain %d values, but its data has %d", hdr.Dim1Length, hdr.Dim2Length, hdr.Dim3Length, numValues, numData)
 	}
-	// Read the byte slice
-	r := bytes.NewReader(bs)
 
-	// Skip the header
-	numBytesHeader := 284
-	skippedHeaderData := make([]uint8, numBytesHeader)
-	if err := binary.Read(r, endian, &skippedHeaderData); err != nil {
-		err := fmt.Errorf("ReadFsMghData: binary.Read failed on header part of MGH file %s: %s", filepath, err)
-		return dataArr, err
+	data := make([]float32, numValues)
+	switch mgh.Data.MghDataType {
+	case MRI_FLOAT:
+		copy(data, mgh.Data.DataMriFloat)
+	case MRI_INT:
+		for i, v := range mgh.Data.DataMriInt {
+			data[i] = float32(v)
+		}
+	case MRI_SHORT:
+		for i, v := range mgh.Data.DataMriShort {
+			data[i] = float32(v)
+		}
+	case MRI_UCHAR:
+		for i, v := range mgh.Data.DataMriUchar {
+			data[i] = float32(v)
+		}
 	}
+	return data, nil
+}
 
-	// Read the data
-	if err := binary.Read(r, endian, &dataArr); err != nil {
-		err := fmt.Errorf("ReadFsMghData: binary.Read failed on %d values of %s data: %s", numValues, mghDataType, err)
-		return dataArr, err
+// mghDataLength returns the number of values in the data slice of an MGH volume that is valid for its data type.
+func mghDataLength(data MghData) (int, error) {
+	switch data.MghDataType {
+	case MRI_FLOAT:
+		return len(data.DataMriFloat), nil
+	case MRI_INT:
+		return len(data.DataMriInt), nil
+	case MRI_SHORT:
+		return len(data.DataMriShort), nil
+	case MRI_UCHAR:
+		return len(data.DataMriUchar), nil
+	default:
+		return 0, fmt.Errorf("unsupported MGH data type code %d", data.MghDataType)
 	}
-	return dataArr, nil
 }
 
-// readFsMghDataMriuchar reads the MRI_UCHAR data array part of an MGH format file.
+// MghVoxelValue returns the value of a single voxel of an MGH volume, converted to float32 whatever its MGH data type.
 //
 // Parameters:
-//   - filepath: path to readable input file in MGH or MGZ format
-//   - hdr: MghHeader struct containing the header data
-//   - treatGzipped: boolean indicating whether the input file is gzipped or not
+//   - mgh: the MGH volume, e.g., from ReadFsMgh
+//   - i, j, k: the voxel indices along the first, second and third dimension
+//   - frame: the index along the fourth dimension, 0 for volumes with a single frame
 //
 // Returns:
-//   - []uint8: an array of uint8 values. You will have to reshape this 1D array to a 4D array with the dimensions given in the MghHeader.
-//   - error: an error if one occurred, nil otherwise
-func readFsMghDataMriUchar(filepath string, hdr MghHeader, treatGzipped bool) ([]uint8, error) {
-
-	var numValues int64 = (int64)(hdr.Dim1Length * hdr.Dim2Length * hdr.Dim3Length * hdr.Dim4Length)
-	dataArr := make([]uint8, numValues)
-	var mghDataType string = "MRI_UCHAR"
-
-	if Verbosity >= 1 {
-		fmt.Printf("Reading %d values of type %s from MGH file '%s', treatGzipped=%t\n", numValues, mghDataType, filepath, treatGzipped)
+//   - float32: the voxel value
+//   - error: an error if the indices are outside the volume, or its data does not match its dimensions
+func MghVoxelValue(mgh Mgh, i int, j int, k int, frame int) (float32, error) {
+	hdr := mgh.Header
+	dims := [4]int{int(hdr.Dim1Length), int(hdr.Dim2Length), int(hdr.Dim3Length), int(hdr.Dim4Length)}
+	for d, idx := range [4]int{i, j, k, frame} {
+		if idx < 0 || idx >= dims[d] {
+			return 0, fmt.Errorf("MghVoxelValue: index %d is out of range for dimension %d with length %d", idx, d+1, dims[d])
+		}
 	}
-
-	endian := binary.BigEndian
-	bs, err := readFileIntoByteSlice(filepath, treatGzipped)
+	numData, err := mghDataLength(mgh.Data)
 	if err != nil {
-		err = fmt.Errorf("Could not read file '%s' into byte slice: %s", filepath, err)
-		return dataArr, err
+		return 0, fmt.Errorf("MghVoxelValue: %s", err)
 	}
-	// Read the byte slice
-	r := bytes.NewReader(bs)
-
-	// Skip the header
-	numBytesHeader := 284
-	skippedHeaderData := make([]uint8, numBytesHeader)
-	if err := binary.Read(r, endian, &skippedHeaderData); err != nil {
-		err := fmt.Errorf("ReadFsMghData: binary.Read failed on header part of MGH file %s: %s", filepath, err)
-		return dataArr, err
+	// The first dimension varies fastest in MGH data.
+	idx := i + dims[0]*(j+dims[1]*(k+dims[2]*frame))
+	if idx >= numData {
+		return 0, fmt.Errorf("MghVoxelValue: volume with dimensions %dx%dx%dx%d has only %d values", dims[0], dims[1], dims[2], dims[3], numData)
 	}
-
-	// Read the data
-	if err := binary.Read(r, endian, &dataArr); err != nil {
-		err := fmt.Errorf("ReadFsMghData: binary.Read failed on %d values of %s data: %s", numValues, mghDataType, err)
-		return dataArr, err
+	switch mgh.Data.MghDataType {
+	case MRI_FLOAT:
+		return mgh.Data.DataMriFloat[idx], nil
+	case MRI_INT:
+		return float32(mgh.Data.DataMriInt[idx]), nil
+	case MRI_SHORT:
+		return float32(mgh.Data.DataMriShort[idx]), nil
+	default:
+		return float32(mgh.Data.DataMriUchar[idx]), nil
 	}
-
-	return dataArr, nil
 }
 
-// readFsMghDataMriShort reads the MRI_SHORT data array part of an MGH format file.
+// ReadFsMghPerVertex reads per-vertex data stored in an MGH or MGZ file, like 'lh.thickness.fwhm10.fsaverage.mgh', see MghPerVertexData.
 //
 // Parameters:
-//   - filepath: path to readable input file in MGH or MGZ format
-//   - hdr: MghHeader struct containing the header data
-//   - treatGzipped: boolean indicating whether the input file is gzipped or not
+//   - filepath: path to the MGH or MGZ file. Files with extension '.mgz' are treated as gzip-compressed.
+//   - numVertices: the number of vertices of the surface the data belongs to, e.g., NumVertices(mesh). Pass -1 to skip this check.
 //
 // Returns:
-//   - []int16: an array of int16 values. You will have to reshape this 1D array to a 4D array with the dimensions given in the MghHeader.
-//   - error: an error if one occurred, nil otherwise
-func readFsMghDataMriShort(filepath string, hdr MghHeader, treatGzipped bool) ([]int16, error) {
-
-	var numValues int64 = (int64)(hdr.Dim1Length * hdr.Dim2Length * hdr.Dim3Length * hdr.Dim4Length)
-	dataArr := make([]int16, numValues)
-	var mghDataType string = "MRI_SHORT"
-
-	if Verbosity >= 1 {
-		fmt.Printf("Reading %d values of type %s from MGH file '%s', treatGzipped=%t\n", numValues, mghDataType, filepath, treatGzipped)
-	}
-
-	endian := binary.BigEndian
-
-	bs, err := readFileIntoByteSlice(filepath, treatGzipped)
+//   - []float32: the per-vertex data
+//   - error: an error if the file could not be read, or it does not contain per-vertex data for numVertices vertices
+func ReadFsMghPerVertex(filepath string, numVertices int) ([]float32, error) {
+	mgh, err := ReadFsMgh(filepath, "auto")
 	if err != nil {
-		err = fmt.Errorf("Could not read file '%s' into byte slice: %s", filepath, err)
-		return dataArr, err
-	}
-
-	// Read the byte slice
-	r := bytes.NewReader(bs)
-
-	// Skip the header
-	numBytesHeader := 284
-	skippedHeaderData := make([]uint8, numBytesHeader)
-	if err := binary.Read(r, endian, &skippedHeaderData); err != nil {
-		err := fmt.Errorf("ReadFsMghDataMriShort: binary.Read failed on header part of MGH file %s: %s", filepath, err)
-		return dataArr, err
+		return nil, fmt.Errorf("ReadFsMghPerVertex: %s", err)
 	}
-
-	// Read the data
-	if err := binary.Read(r, endian, &dataArr); err != nil {
-		err := fmt.Errorf("ReadFsMghDataMriShort: binary.Read failed on %d values of %s data: %s", numValues, mghDataType, err)
-		return dataArr, err
+	data, err := MghPerVertexData(mgh, numVertices)
+	if err != nil {
+		return nil, fmt.Errorf("ReadFsMghPerVertex: file '%s': %s", filepath, err)
 	}
-
-	return dataArr, nil
+	return data, nil
 }
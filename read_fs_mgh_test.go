@@ -3,7 +3,12 @@ package neuro
 // https://pkg.go.dev/testing
 
 import (
+	"bytes"
+	"os"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestReadFsMghHeader(t *testing.T) {
@@ -122,3 +127,194 @@ func TestReadFsMghPervertex(t *testing.T) {
 		t.Errorf("got mean thickness=%f, wanted between %f and %f", mean_thickness, lower_border, upper_border)
 	}
 }
+
+func TestReadFsMghPerVertexValidatesVertexCount(t *testing.T) {
+	data, err := ReadFsMghPerVertex("testdata/lh.thickness.fwhm5.fsaverage.mgh", 163842)
+	if err != nil {
+		t.Fatalf("ReadFsMghPerVertex failed: %s", err)
+	}
+	mgh, _ := ReadFsMgh("testdata/lh.thickness.fwhm5.fsaverage.mgh", "auto")
+	if diff := cmp.Diff(mgh.Data.DataMriFloat, data); diff != "" {
+		t.Errorf("ReadFsMghPerVertex() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadFsMghPerVertex("testdata/lh.thickness.fwhm5.fsaverage.mgh", 149244); err == nil {
+		t.Errorf("got no error for wrong number of vertices, wanted one")
+	}
+	if data, err := ReadFsMghPerVertex("testdata/lh.thickness.fwhm5.fsaverage.mgh", -1); err != nil || len(data) != 163842 {
+		t.Errorf("got %d values and error %v without vertex count check", len(data), err)
+	}
+}
+
+func TestMghPerVertexDataConvertsTypes(t *testing.T) {
+	mgh := Mgh{
+		Header: MghHeader{Dim1Length: 3, Dim2Length: 1, Dim3Length: 1, Dim4Length: 1, MghDataType: MRI_SHORT},
+		Data:   MghData{DataMriShort: []int16{-1, 0, 7}, MghDataType: MRI_SHORT},
+	}
+	data, err := MghPerVertexData(mgh, 3)
+	if err != nil {
+		t.Fatalf("MghPerVertexData failed: %s", err)
+	}
+	if diff := cmp.Diff([]float32{-1, 0, 7}, data); diff != "" {
+		t.Errorf("MghPerVertexData() mismatch (-want +got):\n%s", diff)
+	}
+
+	mgh.Header.Dim4Length = 2
+	if _, err := MghPerVertexData(mgh, 3); err == nil {
+		t.Errorf("got no error for volume with several frames, wanted one")
+	}
+}
+
+func TestMghPerVertexDataValidatesDataLength(t *testing.T) {
+	mgh := Mgh{
+		Header: MghHeader{Dim1Length: 3, Dim2Length: 1, Dim3Length: 1, Dim4Length: 1, MghDataType: MRI_FLOAT},
+		Data:   MghData{DataMriFloat: []float32{1, 2}, MghDataType: MRI_FLOAT},
+	}
+	if _, err := MghPerVertexData(mgh, -1); err == nil {
+		t.Errorf("got no error for data shorter than the dimensions, wanted one")
+	}
+	mgh.Data.DataMriFloat = []float32{1, 2, 3, 4}
+	if _, err := MghPerVertexData(mgh, -1); err == nil {
+		t.Errorf("got no error for data longer than the dimensions, wanted one")
+	}
+	mgh.Data = MghData{DataMriInt: []int32{1, 2, 3}, MghDataType: MRI_INT}
+	if _, err := MghPerVertexData(mgh, 3); err != nil {
+		t.Errorf("got error for data that matches the dimensions: %s", err)
+	}
+}
+
+func TestMghVoxelValue(t *testing.T) {
+	mgh, err := ReadFsMgh("testdata/brain.mgh", "no")
+	if err != nil {
+		t.Fatalf("ReadFsMgh failed: %s", err)
+	}
+	dims := [3]int{int(mgh.Header.Dim1Length), int(mgh.Header.Dim2Length), int(mgh.Header.Dim3Length)}
+	i, j, k := 99, 120, 130
+	got, err := MghVoxelValue(mgh, i, j, k, 0)
+	if err != nil {
+		t.Fatalf("MghVoxelValue failed: %s", err)
+	}
+	if want := float32(mgh.Data.DataMriUchar[i+j*dims[0]+k*dims[0]*dims[1]]); got != want {
+		t.Errorf("got voxel value %f, wanted %f", got, want)
+	}
+
+	if _, err := MghVoxelValue(mgh, dims[0], 0, 0, 0); err == nil {
+		t.Errorf("got no error for index outside the volume, wanted one")
+	}
+	if _, err := MghVoxelValue(mgh, 0, 0, 0, 1); err == nil {
+		t.Errorf("got no error for frame outside the volume, wanted one")
+	}
+	mgh.Data.DataMriUchar = mgh.Data.DataMriUchar[:100]
+	if _, err := MghVoxelValue(mgh, i, j, k, 0); err == nil {
+		t.Errorf("got no error for data shorter than the dimensions, wanted one")
+	}
+}
+
+func TestReadFsMghFromReader(t *testing.T) {
+	want, err := ReadFsMgh("testdata/brain.mgz", "auto")
+	if err != nil {
+		t.Fatalf("ReadFsMgh failed: %s", err)
+	}
+
+	for _, tc := range []struct {
+		file      string
+		isGzipped string
+	}{
+		{"testdata/brain.mgz", "auto"},
+		{"testdata/brain.mgz", "mgz"},
+		{"testdata/brain.mgh", "auto"},
+		{"testdata/brain.mgh", "no"},
+	} {
+		bs, _ := os.ReadFile(tc.file)
+		got, err := ReadFsMghFromReader(bytes.NewReader(bs), tc.isGzipped)
+		if err != nil {
+			t.Fatalf("ReadFsMghFromReader failed for '%s' with isGzipped=%s: %s", tc.file, tc.isGzipped, err)
+		}
+		if diff := cmp.Diff(want.Header, got.Header); diff != "" {
+			t.Errorf("ReadFsMghFromReader() header mismatch for '%s' with isGzipped=%s (-want +got):\n%s", tc.file, tc.isGzipped, diff)
+		}
+		if got.Data.MghDataType != MRI_UCHAR || !bytes.Equal(want.Data.DataMriUchar, got.Data.DataMriUchar) {
+			t.Errorf("ReadFsMghFromReader() data mismatch for '%s' with isGzipped=%s", tc.file, tc.isGzipped)
+		}
+	}
+}
+
+func TestReadFsMghFromReaderTruncatedData(t *testing.T) {
+	bs, _ := os.ReadFile("testdata/brain.mgh")
+	_, err := ReadFsMghFromReader(bytes.NewReader(bs[:len(bs)/2]), "no")
+	if err == nil || !strings.Contains(err.Error(), "bytes of data are left") {
+		t.Errorf("expected error for data shorter than declared in header, got: %v", err)
+	}
+}
+
+func TestReadFsMghHeaderFromReader(t *testing.T) {
+	want, err := ReadFsMghHeader("testdata/brain.mgz", "auto")
+	if err != nil {
+		t.Fatalf("ReadFsMghHeader failed: %s", err)
+	}
+
+	for _, tc := range []struct {
+		file      string
+		isGzipped string
+	}{
+		{"testdata/brain.mgz", "auto"},
+		{"testdata/brain.mgz", "mgz"},
+		{"testdata/brain.mgh", "auto"},
+		{"testdata/brain.mgh", "no"},
+	} {
+		bs, _ := os.ReadFile(tc.file)
+		got, err := ReadFsMghHeaderFromReader(bytes.NewReader(bs), tc.isGzipped)
+		if err != nil {
+			t.Fatalf("ReadFsMghHeaderFromReader failed for '%s' with isGzipped=%s: %s", tc.file, tc.isGzipped, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("ReadFsMghHeaderFromReader() mismatch for '%s' with isGzipped=%s (-want +got):\n%s", tc.file, tc.isGzipped, diff)
+		}
+	}
+
+	// The voxel data is never read, so the header of a truncated file is fine, while a truncated header is not.
+	bs, _ := os.ReadFile("testdata/brain.mgh")
+	if _, err := ReadFsMghHeaderFromReader(bytes.NewReader(bs[:300]), "no"); err != nil {
+		t.Errorf("got error for file with truncated data: %s", err)
+	}
+	if _, err := ReadFsMghHeaderFromReader(bytes.NewReader(bs[:100]), "no"); err == nil {
+		t.Errorf("got no error for file with truncated header, wanted one")
+	}
+}
+
+func TestReadFsMghHeaderLittleEndian(t *testing.T) {
+	bs, err := os.ReadFile("testdata/brain.mgh")
+	if err != nil {
+		t.Fatalf("could not read MGH file: %s", err)
+	}
+
+	// Byte-swap the 7 int32 fields at the start of the header, as a little endian writer would store them.
+	swapped := make([]byte, 284)
+	copy(swapped, bs[:284])
+	for i := 0; i < 7*4; i += 4 {
+		swapped[i], swapped[i+1], swapped[i+2], swapped[i+3] = swapped[i+3], swapped[i+2], swapped[i+1], swapped[i]
+	}
+
+	f, err := os.CreateTemp("", "brain_le_*.mgh")
+	if err != nil {
+		t.Fatalf("could not create temp file: %s", err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(swapped)
+	f.Close()
+
+	_, err = ReadFsMghHeader(f.Name(), "no")
+	if err == nil || !strings.Contains(err.Error(), "little endian") {
+		t.Errorf("expected little endian error, got: %v", err)
+	}
+}
+
+func TestMghDataTypeName(t *testing.T) {
+	name, err := MghDataTypeName(MRI_FLOAT)
+	if err != nil || name != "MRI_FLOAT" {
+		t.Errorf("got name '%s' and error %v for MRI_FLOAT, wanted 'MRI_FLOAT' and no error", name, err)
+	}
+	if _, err := MghDataTypeName(2); err == nil {
+		t.Errorf("got no error for unsupported data type code 2, wanted one")
+	}
+}
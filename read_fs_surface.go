@@ -4,35 +4,100 @@ package neuro
 // https://github.com/dfsp-spirit/libfs/blob/main/include/libfs.h#L2023 for the fs surface file format
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 )
 
+// fsSurfaceTriangleMagic holds the 3 magic bytes at the start of a FreeSurfer triangular surface file, i.e., the 3-byte integer -2 (TRIANGLE_FILE_MAGIC_NUMBER in FreeSurfer).
+var fsSurfaceTriangleMagic = [3]uint8{255, 255, 254}
+
+// fsSurfaceQuadMagic holds the 3 magic bytes at the start of an old FreeSurfer quad surface file with int16 vertex coordinates, i.e., the 3-byte integer -1 (QUAD_FILE_MAGIC_NUMBER in FreeSurfer).
+var fsSurfaceQuadMagic = [3]uint8{255, 255, 255}
+
+// fsSurfaceNewQuadMagic holds the 3 magic bytes at the start of a FreeSurfer quad surface file with float32 vertex coordinates, i.e., the 3-byte integer -3 (NEW_QUAD_FILE_MAGIC_NUMBER in FreeSurfer).
+var fsSurfaceNewQuadMagic = [3]uint8{255, 255, 253}
+
+// Tags of the optional data following the faces in a FreeSurfer triangular surface file, see tags.h in FreeSurfer.
+const (
+	fsSurfaceTagOldUseRealRas int32 = 2  // followed by an int32 flag
+	fsSurfaceTagCmdline       int32 = 3  // followed by an int64 length and a NUL-terminated command line
+	fsSurfaceTagOldSurfGeom   int32 = 20 // followed by 8 text lines describing the volume geometry
+)
+
+// FsSurfaceHeader holds the metadata of a FreeSurfer triangular surface file, which documents the provenance of the surface.
+// Quad surface files have no metadata.
+type FsSurfaceHeader struct {
+	CreatedLine    string   // The 'created by <user> on <date>' line, without the newline.
+	CommentLine    string   // The comment line, without the newline. FreeSurfer always writes an empty comment line, and FreeSurfer tools cannot read files with a non-empty one.
+	UseRealRas     bool     // Whether the vertex coordinates are in scanner RAS instead of tkregister RAS space. Only written if VolumeGeometry is set.
+	VolumeGeometry string   // The geometry of the volume the surface was created from, as 8 text lines like 'valid = 1  # volume info valid', each terminated by a newline. Empty if not present.
+	CmdLines       []string // The command lines of the programs that created or modified the surface, in order.
+}
+
+// fsMagicByteOrder determines the byte order of a FreeSurfer binary file from its 3 magic bytes.
+//
+// FreeSurfer always writes big endian files, but files produced by third-party software on little endian
+// machines sometimes have their byte order swapped, which is detected here.
+//
+// Parameters:
+//   - magic: the 3 magic bytes read from the start of the file
+//   - expected: the 3 magic bytes expected for the file format, in big endian order
+//
+// Returns:
+//   - binary.ByteOrder: binary.BigEndian or binary.LittleEndian
+//   - error: an error if the magic bytes match neither byte order
+func fsMagicByteOrder(magic [3]uint8, expected [3]uint8) (binary.ByteOrder, error) {
+	if magic == expected {
+		return binary.BigEndian, nil
+	}
+	if magic == [3]uint8{expected[2], expected[1], expected[0]} {
+		return binary.LittleEndian, nil
+	}
+	return nil, fmt.Errorf("magic bytes %d %d %d do not match expected magic bytes %d %d %d in either byte order", magic[0], magic[1], magic[2], expected[0], expected[1], expected[2])
+}
+
+// readFsInt3 reads a 3-byte integer, as used by FreeSurfer for magic numbers and some counts.
+//
+// Parameters:
+//   - r: a bytes.Reader
+//   - endian: the byte order, e.g. binary.BigEndian
+//
+// Returns:
+//   - int32: the value
+//   - error: an error if one occurred
+func readFsInt3(r *bytes.Reader, endian binary.ByteOrder) (int32, error) {
+	var b [3]uint8
+	if err := binary.Read(r, endian, &b); err != nil {
+		return 0, err
+	}
+	if endian == binary.LittleEndian {
+		b[0], b[2] = b[2], b[0]
+	}
+	return int32(b[0])<<16 | int32(b[1])<<8 | int32(b[2]), nil
+}
+
 // Read a newline-terminated string from a bytes.Reader.
 //
 // Parameters:
-//  - r: a bytes.Reader
-//  - endian: the byte order, e.g. binary.BigEndian
-//  - do_strip_newline: if true, strip the newline character from the end of the string
+//   - r: a bytes.Reader
+//   - endian: the byte order, e.g. binary.BigEndian
+//   - do_strip_newline: if true, strip the newline character from the end of the string
 //
 // Returns:
-//  - string: the string
-//  - error: an error if one occurred
+//   - string: the string
+//   - error: an error if one occurred
 func readNewlineTerminatedString(r *bytes.Reader, endian binary.ByteOrder, do_strip_newline bool) (string, error) {
 
-	//endian = binary.BigEndian // TODO: make this a parameter
-
-	//return "not implemented yet", nil
 	var line string = ""
 	char := make([]byte, 1)
 	var char_num int = 0
 	for string(char[:]) != "\n" {
 		if err := binary.Read(r, endian, &char); err != nil {
-			fmt.Printf("binary.Read failed on character %d of newline-terminated string: %s\n", char_num, err)
+			err = fmt.Errorf("binary.Read failed on character %d of newline-terminated string: %s", char_num, err)
 			return "", err
 		} else {
 			if !(do_strip_newline && string(char[:]) == "\n") {
@@ -44,84 +109,153 @@ func readNewlineTerminatedString(r *bytes.Reader, endian binary.ByteOrder, do_st
 	return line, nil
 }
 
-
 // ReadFsSurface reads a FreeSurfer surface file and returns a Mesh struct.
 //
 // A surface file is a binary file containing the reconstructed surface of a brain hemisphere.
+// FreeSurfer writes these files in big endian byte order. The byte order is detected from
+// the magic bytes at the start of the file, so byte-swapped files written by third-party
+// tools on little endian machines can also be read.
+//
+// Both the current triangle format and the old quad formats found in older datasets are supported.
+// Quad faces are split into two triangles each, in the same way FreeSurfer does it.
 //
 // Parameters:
-//  - filepath: path to the FreeSurfer mesh file, e.g. '<subject>/surf/lh.white'
+//   - filepath: path to the FreeSurfer mesh file, e.g. '<subject>/surf/lh.white'
 //
 // Returns:
-//  - Mesh: a Mesh struct containing the mesh data
-//  - error: an error if one occurred
+//   - Mesh: a Mesh struct containing the mesh data
+//   - error: an error if one occurred
 func ReadFsSurface(filepath string) (Mesh, error) {
 
-	endian := binary.BigEndian
 	surface := Mesh{}
 
-	if _, err := os.Stat(filepath); err != nil {
-		err := fmt.Errorf("Could not stat surface file '%s': %s\n", filepath, err)
+	bs, err := os.ReadFile(filepath)
+	if err != nil {
+		err = fmt.Errorf("ReadFsSurface: could not read surface file '%s': %s", filepath, err)
 		return surface, err
 	}
 
-	file, err := os.Open(filepath)
+	surface, err = readFsSurfaceFromBytes(bs)
 	if err != nil {
-   		panic(err)
+		err = fmt.Errorf("ReadFsSurface: failed to parse surface file '%s': %s", filepath, err)
+		return surface, err
 	}
-	defer file.Close()
+	return surface, nil
+}
 
-	// Get the file size
-	stat, err := file.Stat()
+// ReadFsSurfaceWithHeader reads a FreeSurfer surface file, like ReadFsSurface, and also returns the metadata from the file header and the tags following the mesh data.
+//
+// Use WriteFsSurfaceWithHeader to write a modified surface without losing its provenance information.
+//
+// Parameters:
+//   - filepath: path to the FreeSurfer mesh file, e.g. '<subject>/surf/lh.white'
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - FsSurfaceHeader: the metadata. Empty for quad surface files.
+//   - error: an error if one occurred
+func ReadFsSurfaceWithHeader(filepath string) (Mesh, FsSurfaceHeader, error) {
+	bs, err := os.ReadFile(filepath)
 	if err != nil {
-	   fmt.Println(err)
-	   return surface, err
+		return Mesh{}, FsSurfaceHeader{}, fmt.Errorf("ReadFsSurfaceWithHeader: could not read surface file '%s': %s", filepath, err)
 	}
-
-	// Read the file into a byte slice
-	bs := make([]byte, stat.Size())
-	_, err = bufio.NewReader(file).Read(bs)
-	if err != nil && err != io.EOF {
-	   fmt.Println(err)
-	   return surface, err
+	surface, header, err := readFsSurfaceWithHeaderFromBytes(bs)
+	if err != nil {
+		return surface, header, fmt.Errorf("ReadFsSurfaceWithHeader: failed to parse surface file '%s': %s", filepath, err)
 	}
+	return surface, header, nil
+}
 
-	// Read the byte slice
-	r := bytes.NewReader(bs)
-
-	type header_part1 struct {
-		MagicB1 uint8
-		MagicB2 uint8
-		MagicB3 uint8
+// ReadFsSurfaceFromReader reads a mesh in FreeSurfer surface format from a reader.
+//
+// This is useful if the data does not come from a file on disk, e.g., when it was received over the network or in the browser. Use bytes.NewReader for data in a byte slice.
+// See ReadFsSurface for details on the supported formats.
+//
+// Parameters:
+//   - r: the reader providing the file contents
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - error: an error if one occurred
+func ReadFsSurfaceFromReader(r io.Reader) (Mesh, error) {
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return Mesh{}, fmt.Errorf("ReadFsSurfaceFromReader: could not read data: %s", err)
+	}
+	surface, err := readFsSurfaceFromBytes(bs)
+	if err != nil {
+		return surface, fmt.Errorf("ReadFsSurfaceFromReader: failed to parse surface data: %s", err)
 	}
+	return surface, nil
+}
+
+// readFsSurfaceFromBytes parses the contents of a FreeSurfer surface file.
+//
+// Parameters:
+//   - bs: the full file contents
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - error: an error if one occurred, e.g., the magic bytes are invalid or the data is truncated
+func readFsSurfaceFromBytes(bs []byte) (Mesh, error) {
+	surface, _, err := readFsSurfaceWithHeaderFromBytes(bs)
+	return surface, err
+}
 
-	hdr1 := header_part1{}
+// readFsSurfaceWithHeaderFromBytes parses the contents of a FreeSurfer surface file, including the metadata.
+//
+// Parameters:
+//   - bs: the full file contents
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - FsSurfaceHeader: the metadata, empty for quad surface files
+//   - error: an error if one occurred, e.g., the magic bytes are invalid or the data is truncated
+func readFsSurfaceWithHeaderFromBytes(bs []byte) (Mesh, FsSurfaceHeader, error) {
 
+	surface := Mesh{}
+	header := FsSurfaceHeader{}
+	r := bytes.NewReader(bs)
 
-	if err := binary.Read(r, endian, &hdr1); err != nil {
-		fmt.Println("binary.Read failed on first part of fs surface header:", err)
-		return surface, err
+	var magic [3]uint8
+	if err := binary.Read(r, binary.BigEndian, &magic); err != nil {
+		err = fmt.Errorf("binary.Read failed on magic bytes of fs surface header: %s", err)
+		return surface, header, err
 	}
 
+	logInfo("Surface header magic bytes: %d %d %d.", magic[0], magic[1], magic[2])
 
-	if ! (hdr1.MagicB1 == 255 && hdr1.MagicB2 == 255 && hdr1.MagicB3 == 254) {
-		fmt.Println("Error: surface magic bytes are not 255 255 254, this is not a FreeSurfer surface file. Provide a recon-all output file like '<subject>/surf/lh.white'.")
-		return surface, err
+	if magic == fsSurfaceQuadMagic {
+		surface, err := readFsQuadSurface(r, binary.BigEndian, false)
+		return surface, header, err
+	}
+	if endian, err := fsMagicByteOrder(magic, fsSurfaceNewQuadMagic); err == nil {
+		surface, err := readFsQuadSurface(r, endian, true)
+		return surface, header, err
 	}
 
-
-	if Verbosity > 0 {
-		fmt.Printf("Surface header magic bytes: %d %d %d.\n", hdr1.MagicB1, hdr1.MagicB2, hdr1.MagicB3)
+	endian, err := fsMagicByteOrder(magic, fsSurfaceTriangleMagic)
+	if err != nil {
+		err = fmt.Errorf("this is not a FreeSurfer surface file, provide a recon-all output file like '<subject>/surf/lh.white': %s", err)
+		return surface, header, err
 	}
 
-	createdLine, err := readNewlineTerminatedString(r, endian, true);
-    commentLine, err := readNewlineTerminatedString(r, endian, true);
+	logInfo("Surface byte order: %s.", endian)
 
-	if Verbosity > 0 {
-		fmt.Printf("createdLine: '%s'\n", createdLine)
-		fmt.Printf("commentLine: '%s'\n", commentLine)
+	createdLine, err := readNewlineTerminatedString(r, endian, true)
+	if err != nil {
+		return surface, header, err
+	}
+	commentLine, err := readNewlineTerminatedString(r, endian, true)
+	if err != nil {
+		return surface, header, err
 	}
 
+	logInfo("createdLine: '%s'", createdLine)
+	logInfo("commentLine: '%s'", commentLine)
+	header.CreatedLine = createdLine
+	header.CommentLine = commentLine
+
 	type header_part2 struct {
 		NumVerts int32
 		NumFaces int32
@@ -130,29 +264,49 @@ func ReadFsSurface(filepath string) (Mesh, error) {
 	hdr2 := header_part2{}
 
 	if err := binary.Read(r, endian, &hdr2); err != nil {
-		fmt.Println("binary.Read failed on second part of fs surface header:", err)
-		return surface, err
+		err = fmt.Errorf("binary.Read failed on second part of fs surface header: %s", err)
+		return surface, header, err
 	}
 
-	if Verbosity > 0 {
-		fmt.Println("NumVerts:", hdr2.NumVerts)
-		fmt.Println("NumFaces:", hdr2.NumFaces)
+	logInfo("NumVerts: %d", hdr2.NumVerts)
+	logInfo("NumFaces: %d", hdr2.NumFaces)
+
+	// Validate the header before allocating, so garbage input (e.g., a wrong byte order) cannot trigger huge allocations.
+	if hdr2.NumVerts < 0 || hdr2.NumFaces < 0 {
+		err := fmt.Errorf("invalid fs surface header: negative number of vertices (%d) or faces (%d)", hdr2.NumVerts, hdr2.NumFaces)
+		return surface, header, err
+	}
+	numBytesRequired := (int64(hdr2.NumVerts) + int64(hdr2.NumFaces)) * 3 * 4
+	if numBytesRequired > int64(r.Len()) {
+		err := fmt.Errorf("fs surface header declares %d vertices and %d faces, which requires %d bytes of data, but only %d bytes are left", hdr2.NumVerts, hdr2.NumFaces, numBytesRequired, r.Len())
+		return surface, header, err
 	}
 
 	// read mesh data
-	surface.Vertices = make([]float32, hdr2.NumVerts * 3) // x,y,z coordinates for each vertex
-	surface.Faces = make([]int32, hdr2.NumFaces * 3)  // vertex 1, 2, 3 for each face
+	surface.Vertices = make([]float32, hdr2.NumVerts*3) // x,y,z coordinates for each vertex
+	surface.Faces = make([]int32, hdr2.NumFaces*3)      // vertex 1, 2, 3 for each face
 
 	// read vertices
 	if err := binary.Read(r, endian, &surface.Vertices); err != nil {
-		fmt.Println("binary.Read failed on mesh vertices array:", err)
-		return surface, err
+		err = fmt.Errorf("binary.Read failed on mesh vertices array: %s", err)
+		return surface, header, err
 	}
 
 	// read faces
 	if err := binary.Read(r, endian, &surface.Faces); err != nil {
-		fmt.Println("binary.Read failed on mesh faces array:", err)
-		return surface, err
+		err = fmt.Errorf("binary.Read failed on mesh faces array: %s", err)
+		return surface, header, err
+	}
+
+	for i, vertexIndex := range surface.Faces {
+		if vertexIndex < 0 || vertexIndex >= hdr2.NumVerts {
+			err := fmt.Errorf("face %d references vertex %d, but the mesh only has %d vertices", i/3, vertexIndex, hdr2.NumVerts)
+			return surface, header, err
+		}
+	}
+
+	if err := readFsSurfaceTags(r, endian, &header); err != nil {
+		return surface, header, fmt.Errorf("failed to read tags following the mesh data: %s", err)
 	}
 
 	if Verbosity >= 2 {
@@ -161,7 +315,7 @@ func ReadFsSurface(filepath string) (Mesh, error) {
 			// print first 5 vertices
 			for i := 0; i < numToPrint; i++ {
 				for j := 0; j < 3; j++ {
-					fmt.Println("surface.Vertices[", i, "][", j, "]:", surface.Vertices[i*3+j])
+					logDebug("surface.Vertices[%d][%d]: %f", i, j, surface.Vertices[i*3+j])
 				}
 			}
 		}
@@ -169,11 +323,142 @@ func ReadFsSurface(filepath string) (Mesh, error) {
 		if hdr2.NumFaces >= int32(numToPrint) {
 			for i := 0; i < numToPrint; i++ {
 				for j := 0; j < 3; j++ {
-					fmt.Println("surface.Faces[", i, "][", j, "]:", surface.Faces[i*3+j])
+					logDebug("surface.Faces[%d][%d]: %d", i, j, surface.Faces[i*3+j])
 				}
 			}
 		}
 	}
 
+	return surface, header, nil
+}
+
+// readFsSurfaceTags reads the optional tags following the faces of a FreeSurfer triangular surface file into the header.
+//
+// Reading stops at the end of the data or at the first unknown tag, whose data is ignored.
+//
+// Parameters:
+//   - r: a bytes.Reader, positioned directly after the faces
+//   - endian: the byte order, e.g. binary.BigEndian
+//   - header: the header to fill
+//
+// Returns:
+//   - error: an error if the data of a known tag is truncated
+func readFsSurfaceTags(r *bytes.Reader, endian binary.ByteOrder, header *FsSurfaceHeader) error {
+	for r.Len() >= 4 {
+		var tag int32
+		if err := binary.Read(r, endian, &tag); err != nil {
+			return err
+		}
+		switch tag {
+		case fsSurfaceTagOldUseRealRas:
+			var useRealRas int32
+			if err := binary.Read(r, endian, &useRealRas); err != nil {
+				return fmt.Errorf("could not read useRealRAS flag: %s", err)
+			}
+			header.UseRealRas = useRealRas != 0
+		case fsSurfaceTagOldSurfGeom:
+			var geom string
+			for i := 0; i < 8; i++ {
+				line, err := readNewlineTerminatedString(r, endian, false)
+				if err != nil {
+					return fmt.Errorf("could not read line %d of volume geometry: %s", i, err)
+				}
+				geom += line
+			}
+			header.VolumeGeometry = geom
+		case fsSurfaceTagCmdline:
+			var length int64
+			if err := binary.Read(r, endian, &length); err != nil {
+				return fmt.Errorf("could not read command line length: %s", err)
+			}
+			if length < 0 || length > int64(r.Len()) {
+				return fmt.Errorf("invalid command line length %d for %d bytes of remaining data", length, r.Len())
+			}
+			cmdline := make([]byte, length)
+			if _, err := io.ReadFull(r, cmdline); err != nil {
+				return fmt.Errorf("could not read command line: %s", err)
+			}
+			header.CmdLines = append(header.CmdLines, strings.TrimRight(string(cmdline), "\x00"))
+		default:
+			logInfo("Ignoring unknown surface tag %d and the remaining %d bytes of data.", tag, r.Len())
+			return nil
+		}
+	}
+	return nil
+}
+
+// readFsQuadSurface reads the part of a FreeSurfer quad surface file following the magic bytes.
+//
+// Quad files have no created and comment lines. The vertex and face counts and the vertex indices
+// of the faces are stored as 3-byte integers. Each quad is split into two triangles, using the same
+// scheme as FreeSurfer, which depends on whether the index of the first quad vertex is even or odd.
+//
+// Parameters:
+//   - r: a bytes.Reader, positioned directly after the magic bytes
+//   - endian: the byte order, e.g. binary.BigEndian
+//   - floatCoords: whether the vertex coordinates are stored as float32 (new quad format). If false, they are stored as int16 values in units of 0.01 mm (old quad format).
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the triangulated mesh
+//   - error: an error if one occurred
+func readFsQuadSurface(r *bytes.Reader, endian binary.ByteOrder, floatCoords bool) (Mesh, error) {
+
+	surface := Mesh{}
+
+	numVerts, err := readFsInt3(r, endian)
+	if err != nil {
+		return surface, fmt.Errorf("failed to read number of vertices of quad surface: %s", err)
+	}
+	numQuads, err := readFsInt3(r, endian)
+	if err != nil {
+		return surface, fmt.Errorf("failed to read number of faces of quad surface: %s", err)
+	}
+
+	logInfo("Quad surface: NumVerts: %d, NumQuads: %d, float coordinates: %t", numVerts, numQuads, floatCoords)
+
+	var bytesPerCoord int64 = 2
+	if floatCoords {
+		bytesPerCoord = 4
+	}
+	numBytesRequired := int64(numVerts)*3*bytesPerCoord + int64(numQuads)*4*3
+	if numBytesRequired > int64(r.Len()) {
+		err := fmt.Errorf("quad surface header declares %d vertices and %d quads, which requires %d bytes of data, but only %d bytes are left", numVerts, numQuads, numBytesRequired, r.Len())
+		return surface, err
+	}
+
+	surface.Vertices = make([]float32, numVerts*3)
+	if floatCoords {
+		if err := binary.Read(r, endian, &surface.Vertices); err != nil {
+			return surface, fmt.Errorf("binary.Read failed on quad surface vertices array: %s", err)
+		}
+	} else {
+		coords := make([]int16, numVerts*3)
+		if err := binary.Read(r, endian, &coords); err != nil {
+			return surface, fmt.Errorf("binary.Read failed on quad surface vertices array: %s", err)
+		}
+		for i, c := range coords {
+			surface.Vertices[i] = float32(c) / 100.0
+		}
+	}
+
+	surface.Faces = make([]int32, 0, numQuads*2*3)
+	var quad [4]int32
+	for i := int32(0); i < numQuads; i++ {
+		for j := 0; j < 4; j++ {
+			quad[j], err = readFsInt3(r, endian)
+			if err != nil {
+				return surface, fmt.Errorf("failed to read vertex %d of quad %d: %s", j, i, err)
+			}
+			if quad[j] >= numVerts {
+				return surface, fmt.Errorf("quad %d references vertex %d, but the mesh only has %d vertices", i, quad[j], numVerts)
+			}
+		}
+		if quad[0]%2 == 0 {
+			surface.Faces = append(surface.Faces, quad[0], quad[1], quad[3], quad[2], quad[3], quad[1])
+		} else {
+			surface.Faces = append(surface.Faces, quad[0], quad[1], quad[2], quad[0], quad[2], quad[3])
+		}
+	}
+
 	return surface, nil
-}
\ No newline at end of file
+}
@@ -3,8 +3,13 @@ package neuro
 // https://pkg.go.dev/testing
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"os"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestReadFsSurface(t *testing.T){
@@ -37,3 +42,118 @@ func ExampleReadFsSurface() {
 	fmt.Printf("Read mesh with %d vertices and %d faces from surface file '%s'.\n", len(mesh.Vertices)/3, len(mesh.Faces)/3, surfaceFile)
 	// Output: Read mesh with 149244 vertices and 298484 faces from surface file 'testdata/lh.white'.
 }
+
+func TestReadFsSurfaceLittleEndian(t *testing.T) {
+	var myCube Mesh = GenerateCube()
+
+	var buf bytes.Buffer
+	if err := writeFsSurface(&buf, myCube, binary.LittleEndian, FsSurfaceHeader{CreatedLine: "created by test"}); err != nil {
+		t.Fatalf("writeFsSurface failed: %v", err)
+	}
+
+	surf, err := readFsSurfaceFromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("got error %v when reading little endian surface", err)
+	}
+
+	if diff := cmp.Diff(myCube, surf); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReadFsSurfaceInvalidMagic(t *testing.T) {
+	var curvFile string = "testdata/lh.thickness"
+
+	_, err := ReadFsSurface(curvFile)
+	if err == nil {
+		t.Errorf("got no error when reading a curv file as a surface, wanted one")
+	}
+}
+
+func TestReadFsSurfaceTruncated(t *testing.T) {
+	var myCube Mesh = GenerateCube()
+
+	var buf bytes.Buffer
+	writeFsSurface(&buf, myCube, binary.BigEndian, FsSurfaceHeader{CreatedLine: "created by test"})
+
+	_, err := readFsSurfaceFromBytes(buf.Bytes()[:buf.Len()-10])
+	if err == nil {
+		t.Errorf("got no error when reading truncated surface, wanted one")
+	}
+}
+
+// quadSurfaceBytes creates the contents of a FreeSurfer quad surface file with a single quad, for testing.
+func quadSurfaceBytes(magic [3]uint8, floatCoords bool, firstVertex int) []byte {
+	var buf bytes.Buffer
+	buf.Write(magic[:])
+	buf.Write([]byte{0, 0, 5}) // 5 vertices, 3-byte int
+	buf.Write([]byte{0, 0, 1}) // 1 quad
+	coords := []float32{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 1.0, 0.0, 0.0, 1.0, 1.0, 0.0, 0.0, 1.0, 0.0}
+	if floatCoords {
+		binary.Write(&buf, binary.BigEndian, coords)
+	} else {
+		for _, c := range coords {
+			binary.Write(&buf, binary.BigEndian, int16(c*100))
+		}
+	}
+	for i := 0; i < 4; i++ {
+		buf.Write([]byte{0, 0, uint8(firstVertex + i)})
+	}
+	return buf.Bytes()
+}
+
+func TestReadFsSurfaceQuad(t *testing.T) {
+	surf, err := readFsSurfaceFromBytes(quadSurfaceBytes(fsSurfaceQuadMagic, false, 1))
+	if err != nil {
+		t.Fatalf("got error %v when reading old quad surface", err)
+	}
+
+	wantVertices := []float32{0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 1.0, 0.0, 0.0, 1.0, 1.0, 0.0, 0.0, 1.0, 0.0}
+	if diff := cmp.Diff(wantVertices, surf.Vertices); diff != "" {
+		t.Error(diff)
+	}
+	// The first quad vertex index is odd, so the quad is split along the diagonal from its first to its third vertex.
+	wantFaces := []int32{1, 2, 3, 1, 3, 4}
+	if diff := cmp.Diff(wantFaces, surf.Faces); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReadFsSurfaceNewQuad(t *testing.T) {
+	surf, err := readFsSurfaceFromBytes(quadSurfaceBytes(fsSurfaceNewQuadMagic, true, 0))
+	if err != nil {
+		t.Fatalf("got error %v when reading new quad surface", err)
+	}
+
+	if NumVertices(surf) != 5 {
+		t.Errorf("got %d vertices, wanted %d", NumVertices(surf), 5)
+	}
+	// The first quad vertex index is even, so the quad is split along the diagonal from its second to its fourth vertex.
+	wantFaces := []int32{0, 1, 3, 2, 3, 1}
+	if diff := cmp.Diff(wantFaces, surf.Faces); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReadFsSurfaceQuadInvalidIndex(t *testing.T) {
+	_, err := readFsSurfaceFromBytes(quadSurfaceBytes(fsSurfaceNewQuadMagic, true, 3))
+	if err == nil {
+		t.Errorf("got no error when reading quad surface with out of range vertex index, wanted one")
+	}
+}
+
+func TestReadFsSurfaceFromReader(t *testing.T) {
+	want, err := ReadFsSurface("testdata/lh.white")
+	if err != nil {
+		t.Fatalf("ReadFsSurface failed: %s", err)
+	}
+
+	bs, _ := os.ReadFile("testdata/lh.white")
+	got, err := ReadFsSurfaceFromReader(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatalf("ReadFsSurfaceFromReader failed: %s", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadFsSurfaceFromReader() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,213 @@
+package neuro
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// giftiMaxDecompressedBytes is the maximal size of a compressed GIFTI data array after decompression. It is far larger than any real surface needs.
+const giftiMaxDecompressedBytes = 1 << 30
+
+// giftiXML models the parts of a GIFTI file that are relevant for reading surfaces.
+type giftiXML struct {
+	XMLName    xml.Name            `xml:"GIFTI"`
+	DataArrays []giftiDataArrayXML `xml:"DataArray"`
+}
+
+// giftiDataArrayXML models a DataArray element of a GIFTI file.
+type giftiDataArrayXML struct {
+	Intent             string `xml:"Intent,attr"`
+	DataType           string `xml:"DataType,attr"`
+	ArrayIndexingOrder string `xml:"ArrayIndexingOrder,attr"`
+	Dimensionality     int    `xml:"Dimensionality,attr"`
+	Dim0               int    `xml:"Dim0,attr"`
+	Dim1               int    `xml:"Dim1,attr"`
+	Encoding           string `xml:"Encoding,attr"`
+	Endian             string `xml:"Endian,attr"`
+	ExternalFileName   string `xml:"ExternalFileName,attr"`
+	Data               string `xml:"Data"`
+}
+
+// ReadGiftiSurface reads a mesh from a file in GIFTI surface format, like the '*.surf.gii' files produced by many neuroimaging software packages.
+//
+// The first data arrays with intents NIFTI_INTENT_POINTSET and NIFTI_INTENT_TRIANGLE are used.
+// All GIFTI encodings except external files are supported.
+//
+// Parameters:
+//   - filepath: path to the GIFTI file
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - error: an error if one occurred
+func ReadGiftiSurface(filepath string) (Mesh, error) {
+	bs, err := os.ReadFile(filepath)
+	if err != nil {
+		return Mesh{}, fmt.Errorf("ReadGiftiSurface: could not read GIFTI file '%s': %s", filepath, err)
+	}
+	mesh, err := readGiftiSurfaceFromBytes(bs)
+	if err != nil {
+		return mesh, fmt.Errorf("ReadGiftiSurface: failed to parse GIFTI file '%s': %s", filepath, err)
+	}
+	return mesh, nil
+}
+
+// readGiftiSurfaceFromBytes parses the contents of a GIFTI surface file.
+//
+// Parameters:
+//   - bs: the full file contents
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - error: an error if one occurred
+func readGiftiSurfaceFromBytes(bs []byte) (Mesh, error) {
+	mesh := Mesh{}
+
+	var gii giftiXML
+	if err := xml.Unmarshal(bs, &gii); err != nil {
+		return mesh, fmt.Errorf("invalid GIFTI XML: %s", err)
+	}
+
+	foundVertices, foundFaces := false, false
+	for _, da := range gii.DataArrays {
+		if da.Intent == "NIFTI_INTENT_POINTSET" && !foundVertices {
+			values, err := decodeGiftiDataArray(da)
+			if err != nil {
+				return mesh, fmt.Errorf("failed to decode vertex data array: %s", err)
+			}
+			mesh.Vertices = make([]float32, len(values))
+			for i, v := range values {
+				mesh.Vertices[i] = float32(v)
+			}
+			foundVertices = true
+		}
+		if da.Intent == "NIFTI_INTENT_TRIANGLE" && !foundFaces {
+			values, err := decodeGiftiDataArray(da)
+			if err != nil {
+				return mesh, fmt.Errorf("failed to decode face data array: %s", err)
+			}
+			mesh.Faces = make([]int32, len(values))
+			for i, v := range values {
+				mesh.Faces[i] = int32(v)
+			}
+			foundFaces = true
+		}
+	}
+	if !foundVertices || !foundFaces {
+		return mesh, fmt.Errorf("file does not contain a surface, data arrays with intents NIFTI_INTENT_POINTSET and NIFTI_INTENT_TRIANGLE are required")
+	}
+	if len(mesh.Vertices)%3 != 0 || len(mesh.Faces)%3 != 0 {
+		return mesh, fmt.Errorf("vertex and face data arrays must have 3 columns")
+	}
+	for i, vertexIndex := range mesh.Faces {
+		if vertexIndex < 0 || int(vertexIndex) >= NumVertices(mesh) {
+			return mesh, fmt.Errorf("face %d references vertex %d, but the mesh only has %d vertices", i/3, vertexIndex, NumVertices(mesh))
+		}
+	}
+	return mesh, nil
+}
+
+// decodeGiftiDataArray decodes the values of a GIFTI data array into row major order.
+//
+// Parameters:
+//   - da: the data array
+//
+// Returns:
+//   - []float64: the values, in row major order. All supported GIFTI data types can be represented exactly as float64.
+//   - error: an error if one occurred
+func decodeGiftiDataArray(da giftiDataArrayXML) ([]float64, error) {
+	numValues := da.Dim0
+	if da.Dimensionality >= 2 {
+		numValues *= da.Dim1
+	}
+	if da.Dim0 < 0 || da.Dim1 < 0 || (da.Dimensionality >= 2 && da.Dim1 > 0 && numValues/da.Dim1 != da.Dim0) {
+		return nil, fmt.Errorf("invalid data array dimensions %d x %d", da.Dim0, da.Dim1)
+	}
+
+	// The dimensions are not trusted for allocating memory, they are only checked against the decoded values below.
+	values := make([]float64, 0)
+	switch da.Encoding {
+	case "ASCII":
+		for _, field := range strings.Fields(da.Data) {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ASCII value '%s': %s", field, err)
+			}
+			values = append(values, v)
+		}
+	case "Base64Binary", "GZipBase64Binary":
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(da.Data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 data: %s", err)
+		}
+		if da.Encoding == "GZipBase64Binary" {
+			// A few bytes of compressed data can expand to gigabytes, so never decompress more than the dimensions declare.
+			bytesPerValue := int64(4)
+			if da.DataType == "NIFTI_TYPE_UINT8" {
+				bytesPerValue = 1
+			}
+			maxBytes := int64(numValues) * bytesPerValue
+			if maxBytes > giftiMaxDecompressedBytes {
+				return nil, fmt.Errorf("data array dimensions %d x %d exceed the supported size of %d bytes", da.Dim0, da.Dim1, giftiMaxDecompressedBytes)
+			}
+			zr, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				return nil, fmt.Errorf("invalid compressed data: %s", err)
+			}
+			raw, err = io.ReadAll(io.LimitReader(zr, maxBytes+1))
+			if err != nil {
+				return nil, fmt.Errorf("invalid compressed data: %s", err)
+			}
+			if int64(len(raw)) > maxBytes {
+				return nil, fmt.Errorf("compressed data expands to more than the %d bytes declared by the data array dimensions", maxBytes)
+			}
+		}
+		var endian binary.ByteOrder = binary.LittleEndian
+		if da.Endian == "BigEndian" {
+			endian = binary.BigEndian
+		}
+		switch da.DataType {
+		case "NIFTI_TYPE_FLOAT32":
+			for i := 0; i+4 <= len(raw); i += 4 {
+				values = append(values, float64(math.Float32frombits(endian.Uint32(raw[i:]))))
+			}
+		case "NIFTI_TYPE_INT32":
+			for i := 0; i+4 <= len(raw); i += 4 {
+				values = append(values, float64(int32(endian.Uint32(raw[i:]))))
+			}
+		case "NIFTI_TYPE_UINT8":
+			for _, b := range raw {
+				values = append(values, float64(b))
+			}
+		default:
+			return nil, fmt.Errorf("unsupported data type '%s'", da.DataType)
+		}
+	case "ExternalFileBinary":
+		return nil, fmt.Errorf("external data files are not supported (file '%s')", da.ExternalFileName)
+	default:
+		return nil, fmt.Errorf("unsupported encoding '%s'", da.Encoding)
+	}
+
+	if len(values) != numValues {
+		return nil, fmt.Errorf("data array declares %d values, but contains %d", numValues, len(values))
+	}
+
+	if da.Dimensionality >= 2 && da.ArrayIndexingOrder == "ColumnMajorOrder" {
+		rowMajor := make([]float64, numValues)
+		for row := 0; row < da.Dim0; row++ {
+			for col := 0; col < da.Dim1; col++ {
+				rowMajor[row*da.Dim1+col] = values[col*da.Dim0+row]
+			}
+		}
+		values = rowMajor
+	}
+	return values, nil
+}
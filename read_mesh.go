@@ -0,0 +1,465 @@
+package neuro
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ImportMesh reads a mesh from a file in any of the supported mesh file formats.
+//
+// Faces with more than 3 vertices (in PLY and OBJ files) are split into triangles. Use ImportPolyMesh to keep them.
+//
+// Parameters:
+//   - filepath : the path of the mesh file
+//   - format   : the mesh file format, one of 'fs' (FreeSurfer surface), 'ply' (Stanford PLY format, ASCII or binary), 'obj' (Wavefront Object Format), 'stl' (StereoLithography format, ASCII or binary), 'gii' (GIFTI format). Use 'auto' to determine the format from the file extension.
+//
+// Returns:
+//   - Mesh  : the mesh
+//   - error : the error if one occured, or nil otherwise
+func ImportMesh(filepath string, format string) (Mesh, error) {
+	if format == "auto" {
+		format = guessMeshFormat(filepath)
+	}
+	bs, err := os.ReadFile(filepath)
+	if err != nil {
+		return Mesh{}, fmt.Errorf("ImportMesh: could not read mesh file '%s': %s", filepath, err)
+	}
+	mesh, err := MeshFromBytes(bs, format)
+	if err != nil {
+		return mesh, fmt.Errorf("ImportMesh: failed to read mesh file '%s': %s", filepath, err)
+	}
+	return mesh, nil
+}
+
+// MeshFromBytes parses a mesh from the contents of a mesh file, e.g., data received over the network.
+//
+// Parameters:
+//   - bs     : the file contents
+//   - format : the mesh file format, see ImportMesh. The value 'auto' is not supported here, as there is no file name.
+//
+// Returns:
+//   - Mesh  : the mesh
+//   - error : the error if one occured, or nil otherwise
+func MeshFromBytes(bs []byte, format string) (Mesh, error) {
+	format, err := normalizeMeshFormat(format)
+	if err != nil {
+		return Mesh{}, fmt.Errorf("MeshFromBytes: %s", err)
+	}
+	switch format {
+	case "fs":
+		return readFsSurfaceFromBytes(bs)
+	case "ply":
+		return readPlyFromBytes(bs)
+	case "obj":
+		return readObjFromBytes(bs)
+	case "stl":
+		return readStlFromBytes(bs)
+	default:
+		return readGiftiSurfaceFromBytes(bs)
+	}
+}
+
+// ReadPly reads a mesh from a file in Stanford PLY format. ASCII and binary PLY files are supported.
+//
+// Only the vertex coordinates and the faces are read, other properties and elements are skipped.
+//
+// Parameters:
+//   - filepath: path to the PLY file
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - error: an error if one occurred
+func ReadPly(filepath string) (Mesh, error) {
+	return ImportMesh(filepath, "ply")
+}
+
+// ReadObj reads a mesh from a file in Wavefront Object format.
+//
+// Only the vertex coordinates and the faces are read, texture coordinates, normals and other data are skipped.
+//
+// Parameters:
+//   - filepath: path to the OBJ file
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - error: an error if one occurred
+func ReadObj(filepath string) (Mesh, error) {
+	return ImportMesh(filepath, "obj")
+}
+
+// ReadStl reads a mesh from a file in StereoLithography format. ASCII and binary STL files are supported.
+//
+// STL files store each triangle with its own copy of the vertex coordinates, so vertices with identical coordinates are merged, see FromTriangleSoup.
+// Degenerate triangles with two or more identical corners collapse when merging and are dropped, so the mesh can have fewer faces than the file.
+//
+// Parameters:
+//   - filepath: path to the STL file
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - error: an error if one occurred
+func ReadStl(filepath string) (Mesh, error) {
+	return ImportMesh(filepath, "stl")
+}
+
+// appendPolygonAsTriangles adds a polygon to the faces of a mesh, splitting it into a fan of triangles if it has more than 3 vertices.
+func appendPolygonAsTriangles(faces []int32, polygon []int32) []int32 {
+	for i := 1; i+1 < len(polygon); i++ {
+		faces = append(faces, polygon[0], polygon[i], polygon[i+1])
+	}
+	return faces
+}
+
+// validateFaceIndices checks that all faces of a mesh reference existing vertices.
+func validateFaceIndices(mesh Mesh) error {
+	numVertices := int32(NumVertices(mesh))
+	for i, vertexIndex := range mesh.Faces {
+		if vertexIndex < 0 || vertexIndex >= numVertices {
+			return fmt.Errorf("face %d references vertex %d, but the mesh only has %d vertices", i/3, vertexIndex, numVertices)
+		}
+	}
+	return nil
+}
+
+// plyProperty models a property of an element in the header of a PLY file.
+type plyProperty struct {
+	name      string
+	dataType  string // the data type, or the item data type for list properties
+	isList    bool
+	countType string // the data type of the item count, for list properties
+}
+
+// plyElement models an element (like 'vertex' or 'face') in the header of a PLY file.
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// plySizes maps the PLY data types to their size in bytes.
+var plySizes = map[string]int{
+	"char": 1, "int8": 1, "uchar": 1, "uint8": 1,
+	"short": 2, "int16": 2, "ushort": 2, "uint16": 2,
+	"int": 4, "int32": 4, "uint": 4, "uint32": 4, "float": 4, "float32": 4,
+	"double": 8, "float64": 8,
+}
+
+// plyValueReader reads the values of the data part of a PLY file, in ASCII or binary format.
+type plyValueReader struct {
+	ascii  *bufio.Scanner
+	binary *bytes.Reader
+	endian binary.ByteOrder
+}
+
+// next reads the next value of the given PLY data type.
+func (r *plyValueReader) next(dataType string) (float64, error) {
+	if r.ascii != nil {
+		if !r.ascii.Scan() {
+			return 0, fmt.Errorf("unexpected end of data")
+		}
+		return strconv.ParseFloat(r.ascii.Text(), 64)
+	}
+	size := plySizes[dataType]
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(r.binary, buf); err != nil {
+		return 0, fmt.Errorf("unexpected end of data: %s", err)
+	}
+	switch dataType {
+	case "char", "int8":
+		return float64(int8(buf[0])), nil
+	case "uchar", "uint8":
+		return float64(buf[0]), nil
+	case "short", "int16":
+		return float64(int16(r.endian.Uint16(buf))), nil
+	case "ushort", "uint16":
+		return float64(r.endian.Uint16(buf)), nil
+	case "int", "int32":
+		return float64(int32(r.endian.Uint32(buf))), nil
+	case "uint", "uint32":
+		return float64(r.endian.Uint32(buf)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(r.endian.Uint32(buf))), nil
+	default:
+		return math.Float64frombits(r.endian.Uint64(buf)), nil
+	}
+}
+
+// readPlyFromBytes parses the contents of a PLY file into a triangle mesh. Polygons are split into triangles.
+func readPlyFromBytes(bs []byte) (Mesh, error) {
+	pm, err := readPlyPolyMeshFromBytes(bs)
+	if err != nil {
+		return Mesh{}, err
+	}
+	return TriangulatePolyMesh(pm)
+}
+
+// readPlyPolyMeshFromBytes parses the contents of a PLY file, keeping the polygons as they are.
+//
+// Parameters:
+//   - bs: the full file contents
+//
+// Returns:
+//   - PolyMesh: the mesh, with the polygons as stored in the file
+//   - error: an error if one occurred
+func readPlyPolyMeshFromBytes(bs []byte) (PolyMesh, error) {
+	mesh := PolyMesh{}
+
+	headerEnd := bytes.Index(bs, []byte("end_header"))
+	if !bytes.HasPrefix(bs, []byte("ply")) || headerEnd < 0 {
+		return mesh, fmt.Errorf("not a PLY file: missing 'ply' magic or 'end_header'")
+	}
+	dataStart := headerEnd + len("end_header")
+	if dataStart < len(bs) && bs[dataStart] == '\r' {
+		dataStart++
+	}
+	if dataStart < len(bs) && bs[dataStart] == '\n' {
+		dataStart++
+	}
+
+	var format string
+	var elements []plyElement
+	for _, line := range strings.Split(string(bs[:headerEnd]), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "format":
+			if len(fields) < 2 {
+				return mesh, fmt.Errorf("invalid PLY format line '%s'", line)
+			}
+			format = fields[1]
+		case "element":
+			if len(fields) != 3 {
+				return mesh, fmt.Errorf("invalid PLY element line '%s'", line)
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return mesh, fmt.Errorf("invalid PLY element count in line '%s'", line)
+			}
+			elements = append(elements, plyElement{name: fields[1], count: count})
+		case "property":
+			if len(elements) == 0 {
+				return mesh, fmt.Errorf("PLY property line '%s' outside of element", line)
+			}
+			var prop plyProperty
+			if len(fields) == 5 && fields[1] == "list" {
+				prop = plyProperty{name: fields[4], dataType: fields[3], isList: true, countType: fields[2]}
+			} else if len(fields) == 3 {
+				prop = plyProperty{name: fields[2], dataType: fields[1]}
+			} else {
+				return mesh, fmt.Errorf("invalid PLY property line '%s'", line)
+			}
+			if _, ok := plySizes[prop.dataType]; !ok {
+				return mesh, fmt.Errorf("unsupported PLY data type in line '%s'", line)
+			}
+			if _, ok := plySizes[prop.countType]; prop.isList && !ok {
+				return mesh, fmt.Errorf("unsupported PLY list count data type in line '%s'", line)
+			}
+			elements[len(elements)-1].properties = append(elements[len(elements)-1].properties, prop)
+		}
+	}
+
+	r := plyValueReader{}
+	switch format {
+	case "ascii":
+		r.ascii = bufio.NewScanner(bytes.NewReader(bs[dataStart:]))
+		r.ascii.Split(bufio.ScanWords)
+	case "binary_little_endian":
+		r.binary = bytes.NewReader(bs[dataStart:])
+		r.endian = binary.LittleEndian
+	case "binary_big_endian":
+		r.binary = bytes.NewReader(bs[dataStart:])
+		r.endian = binary.BigEndian
+	default:
+		return mesh, fmt.Errorf("unsupported PLY format '%s'", format)
+	}
+
+	polygon := make([]int32, 0, 4)
+	for _, element := range elements {
+		for i := 0; i < element.count; i++ {
+			var coords [3]float32
+			for _, prop := range element.properties {
+				if prop.isList {
+					count, err := r.next(prop.countType)
+					if err != nil {
+						return mesh, fmt.Errorf("failed to read list length of property '%s' of element '%s' %d: %s", prop.name, element.name, i, err)
+					}
+					polygon = polygon[:0]
+					for j := 0; j < int(count); j++ {
+						v, err := r.next(prop.dataType)
+						if err != nil {
+							return mesh, fmt.Errorf("failed to read property '%s' of element '%s' %d: %s", prop.name, element.name, i, err)
+						}
+						polygon = append(polygon, int32(v))
+					}
+					if element.name == "face" && (prop.name == "vertex_indices" || prop.name == "vertex_index") {
+						mesh.appendPolygon(polygon)
+					}
+					continue
+				}
+				v, err := r.next(prop.dataType)
+				if err != nil {
+					return mesh, fmt.Errorf("failed to read property '%s' of element '%s' %d: %s", prop.name, element.name, i, err)
+				}
+				if element.name == "vertex" {
+					switch prop.name {
+					case "x":
+						coords[0] = float32(v)
+					case "y":
+						coords[1] = float32(v)
+					case "z":
+						coords[2] = float32(v)
+					}
+				}
+			}
+			if element.name == "vertex" {
+				mesh.Vertices = append(mesh.Vertices, coords[0], coords[1], coords[2])
+			}
+		}
+	}
+
+	if err := validatePolyMeshFaceIndices(mesh); err != nil {
+		return mesh, err
+	}
+	return mesh, nil
+}
+
+// readObjFromBytes parses the contents of a Wavefront OBJ file into a triangle mesh. Polygons are split into triangles.
+func readObjFromBytes(bs []byte) (Mesh, error) {
+	pm, err := readObjPolyMeshFromBytes(bs)
+	if err != nil {
+		return Mesh{}, err
+	}
+	return TriangulatePolyMesh(pm)
+}
+
+// readObjPolyMeshFromBytes parses the contents of a Wavefront OBJ file, keeping the polygons as they are.
+//
+// Parameters:
+//   - bs: the full file contents
+//
+// Returns:
+//   - PolyMesh: the mesh, with the polygons as stored in the file
+//   - error: an error if one occurred
+func readObjPolyMeshFromBytes(bs []byte) (PolyMesh, error) {
+	mesh := PolyMesh{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(bs))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNum := 0
+	polygon := make([]int32, 0, 4)
+	for scanner.Scan() {
+		lineNum++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return mesh, fmt.Errorf("vertex in line %d has less than 3 coordinates", lineNum)
+			}
+			for _, field := range fields[1:4] {
+				v, err := strconv.ParseFloat(field, 32)
+				if err != nil {
+					return mesh, fmt.Errorf("invalid vertex coordinate '%s' in line %d: %s", field, lineNum, err)
+				}
+				mesh.Vertices = append(mesh.Vertices, float32(v))
+			}
+		case "f":
+			polygon = polygon[:0]
+			for _, field := range fields[1:] {
+				// Faces may reference texture coordinates and normals as well, like 'f 1/1/1 2/2/2 3/3/3'.
+				idx, err := strconv.Atoi(strings.SplitN(field, "/", 2)[0])
+				if err != nil || idx == 0 {
+					return mesh, fmt.Errorf("invalid vertex index '%s' in line %d", field, lineNum)
+				}
+				if idx < 0 {
+					// Negative indices are relative to the current end of the vertex list.
+					idx = len(mesh.Vertices)/3 + idx + 1
+				}
+				polygon = append(polygon, int32(idx-1))
+			}
+			if len(polygon) < 3 {
+				return mesh, fmt.Errorf("face in line %d has less than 3 vertices", lineNum)
+			}
+			mesh.appendPolygon(polygon)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return mesh, err
+	}
+
+	if err := validatePolyMeshFaceIndices(mesh); err != nil {
+		return mesh, err
+	}
+	return mesh, nil
+}
+
+// readStlFromBytes parses the contents of an ASCII or binary STL file.
+//
+// Parameters:
+//   - bs: the full file contents
+//
+// Returns:
+//   - Mesh: a Mesh struct containing the mesh data
+//   - error: an error if one occurred
+func readStlFromBytes(bs []byte) (Mesh, error) {
+	var soup []float32
+
+	// Binary STL files may also start with 'solid', so check whether the size matches the binary layout first.
+	if len(bs) >= 84 && 84+50*int(binary.LittleEndian.Uint32(bs[80:84])) == len(bs) {
+		numTriangles := int(binary.LittleEndian.Uint32(bs[80:84]))
+		soup = make([]float32, numTriangles*9)
+		for i := 0; i < numTriangles; i++ {
+			offset := 84 + i*50 + 12 // skip the normal
+			for j := 0; j < 9; j++ {
+				soup[i*9+j] = math.Float32frombits(binary.LittleEndian.Uint32(bs[offset+j*4:]))
+			}
+		}
+	} else {
+		if !bytes.HasPrefix(bytes.TrimSpace(bs), []byte("solid")) {
+			return Mesh{}, fmt.Errorf("not an STL file: neither ASCII STL nor valid binary STL")
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(bs))
+		lineNum := 0
+		for scanner.Scan() {
+			lineNum++
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 0 || fields[0] != "vertex" {
+				continue
+			}
+			if len(fields) != 4 {
+				return Mesh{}, fmt.Errorf("invalid vertex in line %d", lineNum)
+			}
+			for _, field := range fields[1:] {
+				v, err := strconv.ParseFloat(field, 32)
+				if err != nil {
+					return Mesh{}, fmt.Errorf("invalid vertex coordinate '%s' in line %d: %s", field, lineNum, err)
+				}
+				soup = append(soup, float32(v))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return Mesh{}, err
+		}
+		if len(soup)%9 != 0 {
+			return Mesh{}, fmt.Errorf("number of vertices in STL file is not a multiple of 3")
+		}
+	}
+
+	// STL files store each triangle separately, so merge the corners with identical coordinates.
+	mesh, stats, err := FromTriangleSoup(soup, 0)
+	if err == nil && stats.NumRemovedFaces > 0 {
+		logInfo("ReadStl: dropped %d degenerate triangles with identical corners.", stats.NumRemovedFaces)
+	}
+	return mesh, err
+}
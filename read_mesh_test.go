@@ -0,0 +1,219 @@
+package neuro
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMeshFromBytesRoundtrip(t *testing.T) {
+	var myCube Mesh = GenerateCube()
+
+	for _, format := range []string{"fs", "ply", "obj", "gii"} {
+		for _, asBinary := range []bool{false, true} {
+			bs, err := MeshToBytes(myCube, format, asBinary, nil)
+			if err != nil {
+				t.Fatalf("MeshToBytes failed for format %s, binary=%t: %v", format, asBinary, err)
+			}
+			got, err := MeshFromBytes(bs, format)
+			if err != nil {
+				t.Fatalf("MeshFromBytes failed for format %s, binary=%t: %v", format, asBinary, err)
+			}
+			if diff := cmp.Diff(myCube, got); diff != "" {
+				t.Errorf("format %s, binary=%t: %s", format, asBinary, diff)
+			}
+		}
+	}
+}
+
+func TestMeshFromBytesStl(t *testing.T) {
+	var myCube Mesh = GenerateCube()
+
+	for _, asBinary := range []bool{false, true} {
+		bs, _ := MeshToBytes(myCube, "stl", asBinary, nil)
+		got, err := MeshFromBytes(bs, "stl")
+		if err != nil {
+			t.Fatalf("MeshFromBytes failed for STL, binary=%t: %v", asBinary, err)
+		}
+		// STL has no shared vertices, so the vertex order may differ. The merged mesh must have the same size.
+		if NumVertices(got) != 8 || NumFaces(got) != 12 {
+			t.Errorf("got %d vertices and %d faces from STL, binary=%t, wanted 8 and 12", NumVertices(got), NumFaces(got), asBinary)
+		}
+		// Check that the first face has the same coordinates.
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				if got.Vertices[got.Faces[j]*3+int32(k)] != myCube.Vertices[myCube.Faces[j]*3+int32(k)] {
+					t.Errorf("got different coordinates for vertex %d of first face from STL, binary=%t", j, asBinary)
+				}
+			}
+		}
+	}
+}
+
+func TestMeshFromBytesStlDropsDegenerateTriangles(t *testing.T) {
+	stl := `solid test
+facet normal 0 0 1
+outer loop
+vertex 0 0 0
+vertex 1 0 0
+vertex 0 1 0
+endloop
+endfacet
+facet normal 0 0 0
+outer loop
+vertex 1 0 0
+vertex 1 0 0
+vertex 0 1 0
+endloop
+endfacet
+endsolid test
+`
+	mesh, err := MeshFromBytes([]byte(stl), "stl")
+	if err != nil {
+		t.Fatalf("MeshFromBytes failed for STL: %v", err)
+	}
+	if NumVertices(mesh) != 3 || NumFaces(mesh) != 1 {
+		t.Errorf("got %d vertices and %d faces, wanted 3 and 1", NumVertices(mesh), NumFaces(mesh))
+	}
+}
+
+func TestMeshFromBytesPlyWithColorsAndQuads(t *testing.T) {
+	ply := "ply\nformat ascii 1.0\nelement vertex 4\nproperty float x\nproperty float y\nproperty float z\nproperty uchar red\nproperty uchar green\nproperty uchar blue\n" +
+		"element face 1\nproperty list uchar int vertex_indices\nelement edge 1\nproperty int vertex1\nproperty int vertex2\nend_header\n" +
+		"0 0 0 255 0 0\n1 0 0 255 0 0\n1 1 0 255 0 0\n0 1 0 255 0 0\n4 0 1 2 3\n0 1\n"
+
+	got, err := MeshFromBytes([]byte(ply), "ply")
+	if err != nil {
+		t.Fatalf("MeshFromBytes failed: %v", err)
+	}
+	want := Mesh{Vertices: []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0}, Faces: []int32{0, 1, 2, 0, 2, 3}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestMeshFromBytesObjQuadsAndSlashes(t *testing.T) {
+	obj := "# comment\nv 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nvn 0 0 1\nf 1//1 2//1 3//1 -1//1\n"
+
+	got, err := MeshFromBytes([]byte(obj), "obj")
+	if err != nil {
+		t.Fatalf("MeshFromBytes failed: %v", err)
+	}
+	want := Mesh{Vertices: []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0}, Faces: []int32{0, 1, 2, 0, 2, 3}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestMeshFromBytesInvalidIndex(t *testing.T) {
+	obj := "v 0 0 0\nv 1 0 0\nv 1 1 0\nf 1 2 4\n"
+
+	_, err := MeshFromBytes([]byte(obj), "obj")
+	if err == nil {
+		t.Errorf("got no error for face with invalid vertex index, wanted one")
+	}
+}
+
+func TestImportMeshAuto(t *testing.T) {
+	var myCube Mesh = GenerateCube()
+
+	dir := t.TempDir()
+	for _, ext := range []string{"ply", "obj", "gii"} {
+		meshFile := filepath.Join(dir, "cube."+ext)
+		if err := ExportMesh(myCube, meshFile, "auto", true, nil); err != nil {
+			t.Fatalf("ExportMesh failed for extension %s: %v", ext, err)
+		}
+		got, err := ImportMesh(meshFile, "auto")
+		if err != nil {
+			t.Fatalf("ImportMesh failed for extension %s: %v", ext, err)
+		}
+		if diff := cmp.Diff(myCube, got); diff != "" {
+			t.Errorf("extension %s: %s", ext, diff)
+		}
+	}
+
+	surf, err := ImportMesh("testdata/lh.white", "auto")
+	if err != nil {
+		t.Fatalf("ImportMesh failed for FreeSurfer surface: %v", err)
+	}
+	if NumVertices(surf) != 149244 {
+		t.Errorf("got %d vertices in surface file, wanted %d", NumVertices(surf), 149244)
+	}
+}
+
+func TestExportMeshAutoUnknownExtension(t *testing.T) {
+	dir := t.TempDir()
+	if err := ExportMesh(GenerateCube(), filepath.Join(dir, "cube.vtk"), "auto", false, nil); err == nil {
+		t.Errorf("got no error for unknown output file extension, wanted one")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cube.vtk")); err == nil {
+		t.Errorf("file with unknown extension was written")
+	}
+
+	for _, name := range []string{"cube", "lh.white"} {
+		meshFile := filepath.Join(dir, name)
+		if err := ExportMesh(GenerateCube(), meshFile, "auto", false, nil); err != nil {
+			t.Fatalf("ExportMesh failed for file '%s': %v", name, err)
+		}
+		if _, err := ReadFsSurface(meshFile); err != nil {
+			t.Errorf("file '%s' was not written in FreeSurfer format: %v", name, err)
+		}
+	}
+}
+
+func TestMeshFromBytesGiftiHugeDimensions(t *testing.T) {
+	// A tiny file that declares huge data arrays must not make the reader allocate memory for them.
+	gii := `<?xml version="1.0" encoding="UTF-8"?>
+<GIFTI Version="1.0" NumberOfDataArrays="2">
+<DataArray Intent="NIFTI_INTENT_POINTSET" DataType="NIFTI_TYPE_FLOAT32" ArrayIndexingOrder="RowMajorOrder" Dimensionality="2" Dim0="2000000000" Dim1="2000000000" Encoding="ASCII" Endian="LittleEndian" ExternalFileName="" ExternalFileOffset="">
+<Data>0 0 0</Data>
+</DataArray>
+<DataArray Intent="NIFTI_INTENT_TRIANGLE" DataType="NIFTI_TYPE_INT32" ArrayIndexingOrder="RowMajorOrder" Dimensionality="2" Dim0="1" Dim1="3" Encoding="ASCII" Endian="LittleEndian" ExternalFileName="" ExternalFileOffset="">
+<Data>0 0 0</Data>
+</DataArray>
+</GIFTI>`
+
+	_, err := MeshFromBytes([]byte(gii), "gii")
+	if err == nil {
+		t.Errorf("got no error for GIFTI data array with wrong dimensions, wanted one")
+	}
+}
+
+func TestMeshFromBytesGiftiCompressedDataTooLarge(t *testing.T) {
+	// Compressed data that expands to far more than the declared dimensions must be rejected without decompressing all of it.
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(make([]byte, 64<<20))
+	zw.Close()
+	gii := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<GIFTI Version="1.0" NumberOfDataArrays="2">
+<DataArray Intent="NIFTI_INTENT_POINTSET" DataType="NIFTI_TYPE_FLOAT32" ArrayIndexingOrder="RowMajorOrder" Dimensionality="2" Dim0="1" Dim1="3" Encoding="GZipBase64Binary" Endian="LittleEndian" ExternalFileName="" ExternalFileOffset="">
+<Data>%s</Data>
+</DataArray>
+<DataArray Intent="NIFTI_INTENT_TRIANGLE" DataType="NIFTI_TYPE_INT32" ArrayIndexingOrder="RowMajorOrder" Dimensionality="2" Dim0="1" Dim1="3" Encoding="ASCII" Endian="LittleEndian" ExternalFileName="" ExternalFileOffset="">
+<Data>0 0 0</Data>
+</DataArray>
+</GIFTI>`, base64.StdEncoding.EncodeToString(compressed.Bytes()))
+
+	_, err := MeshFromBytes([]byte(gii), "gii")
+	if err == nil {
+		t.Errorf("got no error for GIFTI data array that expands beyond its dimensions, wanted one")
+	}
+}
+
+func ExampleImportMesh() {
+	var myCube Mesh = GenerateCube()
+	meshFile := filepath.Join(os.TempDir(), "neurogo_example_cube.ply")
+	defer os.Remove(meshFile)
+
+	ExportMesh(myCube, meshFile, "auto", true, nil)
+	mesh, _ := ImportMesh(meshFile, "auto")
+	fmt.Printf("Mesh has %d vertices and %d faces.\n", NumVertices(mesh), NumFaces(mesh))
+	// Output: Mesh has 8 vertices and 12 faces.
+}
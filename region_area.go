@@ -0,0 +1,79 @@
+package neuro
+
+import (
+	"fmt"
+)
+
+// VertexAreas computes the area associated with each vertex of a mesh, by distributing the area of each face equally among its 3 vertices.
+//
+// This is the definition used by FreeSurfer for the recon-all output files `<subject>/surf/?h.area`, and the per-vertex areas sum up to the total area of the mesh.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - []float32 : the area of each vertex, in the squared unit of the vertex coordinates (mm^2 for FreeSurfer surfaces). 0 for vertices that are not part of any face.
+//   - error : an error if the mesh has invalid face indices
+func VertexAreas(mesh Mesh) ([]float32, error) {
+	if err := validateFaceIndices(mesh); err != nil {
+		return nil, fmt.Errorf("VertexAreas: %s", err)
+	}
+	areas := make([]float64, NumVertices(mesh))
+	for f := 0; f < NumFaces(mesh); f++ {
+		third := faceArea64(mesh, f) / 3
+		for j := 0; j < 3; j++ {
+			areas[mesh.Faces[f*3+j]] += third
+		}
+	}
+	result := make([]float32, len(areas))
+	for i, a := range areas {
+		result[i] = float32(a)
+	}
+	return result, nil
+}
+
+// AnnotRegionAreas computes the surface area of each region of an annotation, like the SurfArea column of the recon-all output files `<subject>/stats/?h.aparc.stats`.
+//
+// The area of each face is distributed equally among its vertices (see VertexAreas), and the vertex areas are summed per region.
+// This allows recomputing region areas for modified surfaces, e.g., after smoothing or decimation, as long as the vertices of
+// the annotation are preserved. Note that recon-all computes the stats on the white surface.
+//
+// Parameters:
+//   - annot : the annotation, see ReadFsAnnot
+//   - mesh : the surface the annotation belongs to, typically '<subject>/surf/lh.white'
+//
+// Returns:
+//   - []TableColumn : the columns 'StructName' (string), 'NumVert' (int32) and 'SurfArea' (float64, in mm^2 for FreeSurfer surfaces), with one row per region of the colortable, in colortable order. Vertices whose code is not in the colortable are not counted. See WriteParquet for saving the table.
+//   - error : an error if the mesh has invalid face indices, or the annotation does not match the mesh
+func AnnotRegionAreas(annot FsAnnot, mesh Mesh) ([]TableColumn, error) {
+	areas, err := VertexAreas(mesh)
+	if err != nil {
+		return nil, fmt.Errorf("AnnotRegionAreas: %s", err)
+	}
+	// Unlike for labels, the mesh is required here, so an empty mesh is not accepted.
+	if len(annot.Code) != len(annot.VertexIndex) {
+		return nil, fmt.Errorf("AnnotRegionAreas: annotation has %d vertex indices but %d codes", len(annot.VertexIndex), len(annot.Code))
+	}
+	for _, v := range annot.VertexIndex {
+		if err := checkVertexIndex(mesh, v); err != nil {
+			return nil, fmt.Errorf("AnnotRegionAreas: annotation is invalid for this mesh: %s", err)
+		}
+	}
+
+	ct := annot.Colortable
+	region := make(map[int32]int, len(ct.Code))
+	for i := len(ct.Code) - 1; i >= 0; i-- {
+		region[ct.Code[i]] = i // if a code occurs several times, the first region gets the vertices
+	}
+	numVert := make([]int32, len(ct.Code))
+	surfArea := make([]float64, len(ct.Code))
+	for i, code := range annot.Code {
+		if r, ok := region[code]; ok {
+			numVert[r]++
+			surfArea[r] += float64(areas[annot.VertexIndex[i]])
+		}
+	}
+	names := make([]string, len(ct.Name))
+	copy(names, ct.Name)
+	return []TableColumn{{Name: "StructName", Data: names}, {Name: "NumVert", Data: numVert}, {Name: "SurfArea", Data: surfArea}}, nil
+}
@@ -0,0 +1,65 @@
+package neuro
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVertexAreas(t *testing.T) {
+	mesh := Mesh{Vertices: []float32{0, 0, 0, 3, 0, 0, 0, 2, 0, 3, 2, 0, 9, 9, 9}, Faces: []int32{0, 1, 2, 1, 3, 2}}
+	areas, err := VertexAreas(mesh)
+	if err != nil {
+		t.Fatalf("VertexAreas failed: %v", err)
+	}
+	if diff := cmp.Diff([]float32{1, 2, 2, 1, 0}, areas); diff != "" {
+		t.Error(diff)
+	}
+
+	brain, _ := ReadFsSurface("testdata/lh.white")
+	areas, _ = VertexAreas(brain)
+	var total float64
+	for _, a := range areas {
+		total += float64(a)
+	}
+	if want := Mesh64Area(ToMesh64(brain)); math.Abs(total-want) > 1e-3*want {
+		t.Errorf("got sum of vertex areas %f, want total area %f", total, want)
+	}
+
+	if _, err := VertexAreas(Mesh{Vertices: []float32{0, 0, 0}, Faces: []int32{0, 1, 2}}); err == nil {
+		t.Errorf("got no error for invalid face indices, wanted one")
+	}
+}
+
+func TestAnnotRegionAreas(t *testing.T) {
+	annot, err := ReadFsAnnotFromReader(bytes.NewReader(testAnnotBytes(2)))
+	if err != nil {
+		t.Fatalf("ReadFsAnnotFromReader failed: %v", err)
+	}
+	// Vertices 0 and 2 are in region 'bankssts', vertex 1 in 'cuneus', vertex 3 in no region.
+	mesh := Mesh{Vertices: []float32{0, 0, 0, 3, 0, 0, 0, 2, 0, 3, 2, 0}, Faces: []int32{0, 1, 2, 1, 3, 2}}
+	table, err := AnnotRegionAreas(annot, mesh)
+	if err != nil {
+		t.Fatalf("AnnotRegionAreas failed: %v", err)
+	}
+	want := []TableColumn{
+		{Name: "StructName", Data: []string{"bankssts", "cuneus"}},
+		{Name: "NumVert", Data: []int32{2, 1}},
+		{Name: "SurfArea", Data: []float64{3, 2}},
+	}
+	if diff := cmp.Diff(want, table); diff != "" {
+		t.Error(diff)
+	}
+	if _, err := ToParquetFormat(table); err != nil {
+		t.Errorf("could not convert region area table to Parquet: %v", err)
+	}
+
+	if _, err := AnnotRegionAreas(annot, Mesh{Vertices: mesh.Vertices[:9], Faces: []int32{0, 1, 2}}); err == nil {
+		t.Errorf("got no error for mesh with too few vertices, wanted one")
+	}
+	if _, err := AnnotRegionAreas(annot, Mesh{}); err == nil {
+		t.Errorf("got no error for empty mesh, wanted one")
+	}
+}
@@ -0,0 +1,425 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// remesher holds a triangle mesh in a form that supports local modifications, see IsotropicRemesh.
+//
+// Faces and vertices are never removed during remeshing, they are only marked as dead. The face lists of the vertices
+// are updated lazily: they may contain faces that no longer contain the vertex, and facesOf filters them out.
+type remesher struct {
+	verts     [][3]float64
+	vertAlive []bool
+	faces     [][3]int32
+	faceAlive []bool
+	vertFaces [][]int32
+	boundary  []bool // whether a vertex lies on an edge that does not belong to exactly 2 faces
+}
+
+// newRemesher creates a remesher for a mesh with valid face indices.
+func newRemesher(mesh Mesh) *remesher {
+	numVertices := NumVertices(mesh)
+	r := &remesher{verts: make([][3]float64, numVertices), vertAlive: make([]bool, numVertices), vertFaces: make([][]int32, numVertices)}
+	for v := range r.verts {
+		r.verts[v] = meshVertex64(mesh, int32(v))
+		r.vertAlive[v] = true
+	}
+	for f := 0; f < NumFaces(mesh); f++ {
+		r.addFace([3]int32{mesh.Faces[f*3], mesh.Faces[f*3+1], mesh.Faces[f*3+2]})
+	}
+	r.boundary = make([]bool, numVertices)
+	edges, faceCounts := meshEdges(mesh)
+	for i, e := range edges {
+		if faceCounts[i] != 2 {
+			r.boundary[e[0]], r.boundary[e[1]] = true, true
+		}
+	}
+	return r
+}
+
+// addFace adds a face and registers it with its vertices.
+func (r *remesher) addFace(face [3]int32) int32 {
+	f := int32(len(r.faces))
+	r.faces = append(r.faces, face)
+	r.faceAlive = append(r.faceAlive, true)
+	for _, v := range face {
+		r.vertFaces[v] = append(r.vertFaces[v], f)
+	}
+	return f
+}
+
+// addVertex adds a vertex at position p and returns its index.
+func (r *remesher) addVertex(p [3]float64) int32 {
+	r.verts = append(r.verts, p)
+	r.vertAlive = append(r.vertAlive, true)
+	r.vertFaces = append(r.vertFaces, nil)
+	r.boundary = append(r.boundary, false)
+	return int32(len(r.verts) - 1)
+}
+
+// faceIndexOf returns the position of vertex v in a face, or -1 if the face does not contain it.
+func faceIndexOf(face [3]int32, v int32) int {
+	for j := 0; j < 3; j++ {
+		if face[j] == v {
+			return j
+		}
+	}
+	return -1
+}
+
+// facesOf returns the live faces containing vertex v, removing stale entries from its face list.
+func (r *remesher) facesOf(v int32) []int32 {
+	faces := r.vertFaces[v][:0]
+	for _, f := range r.vertFaces[v] {
+		if r.faceAlive[f] && faceIndexOf(r.faces[f], v) >= 0 && (len(faces) == 0 || !containsIndex(faces, f)) {
+			faces = append(faces, f)
+		}
+	}
+	r.vertFaces[v] = faces
+	return faces
+}
+
+// containsIndex returns whether a list of face or vertex indices contains index i.
+func containsIndex(indices []int32, i int32) bool {
+	for _, j := range indices {
+		if j == i {
+			return true
+		}
+	}
+	return false
+}
+
+// edgeFaces returns the live faces containing both vertices a and b.
+func (r *remesher) edgeFaces(a int32, b int32) []int32 {
+	var faces []int32
+	for _, f := range r.facesOf(a) {
+		if faceIndexOf(r.faces[f], b) >= 0 {
+			faces = append(faces, f)
+		}
+	}
+	return faces
+}
+
+// neighbors returns the vertices connected to vertex v by an edge.
+func (r *remesher) neighbors(v int32) []int32 {
+	var neighbors []int32
+	for _, f := range r.facesOf(v) {
+		for _, n := range r.faces[f] {
+			if n != v && !containsIndex(neighbors, n) {
+				neighbors = append(neighbors, n)
+			}
+		}
+	}
+	return neighbors
+}
+
+// isBoundary returns whether vertex v lies on a boundary or a non-manifold edge.
+// Such vertices are not moved, collapsed or flipped, which preserves the boundary of the mesh.
+func (r *remesher) isBoundary(v int32) bool {
+	return r.boundary[v]
+}
+
+// edges returns the unique edges of the live faces.
+func (r *remesher) edges() [][2]int32 {
+	seen := make(map[[2]int32]bool, len(r.faces)*3/2)
+	edges := make([][2]int32, 0, len(r.faces)*3/2)
+	for f, face := range r.faces {
+		if !r.faceAlive[f] {
+			continue
+		}
+		for j := 0; j < 3; j++ {
+			e := meshEdgeKey(face[j], face[(j+1)%3])
+			if !seen[e] {
+				seen[e] = true
+				edges = append(edges, e)
+			}
+		}
+	}
+	return edges
+}
+
+// dist returns the distance between vertices a and b.
+func (r *remesher) dist(a int32, b int32) float64 {
+	return math.Sqrt(distanceSquared64(r.verts[a], r.verts[b]))
+}
+
+// splitEdge splits the edge between vertices a and b at its midpoint, and splits the faces containing it in two.
+func (r *remesher) splitEdge(a int32, b int32) int32 {
+	pa, pb := r.verts[a], r.verts[b]
+	m := r.addVertex([3]float64{(pa[0] + pb[0]) / 2, (pa[1] + pb[1]) / 2, (pa[2] + pb[2]) / 2})
+	edgeFaces := r.edgeFaces(a, b)
+	r.boundary[m] = len(edgeFaces) != 2
+	for _, f := range edgeFaces {
+		// Face (a, b, c) becomes (a, m, c) and (m, b, c), keeping the orientation.
+		newFace := r.faces[f]
+		newFace[faceIndexOf(newFace, a)] = m
+		r.faces[f][faceIndexOf(r.faces[f], b)] = m
+		r.vertFaces[m] = append(r.vertFaces[m], f)
+		r.addFace(newFace)
+	}
+	return m
+}
+
+// splitLongEdges splits all edges longer than maxLength, until none is left.
+//
+// The edges are split in passes, longest first. Revisiting the new edges of a split immediately instead would split the same fan of
+// triangles over and over, as the edges from the new vertices to the opposite vertex of a long edge do not get shorter.
+func (r *remesher) splitLongEdges(maxLength float64) {
+	for {
+		var long [][2]int32
+		var lengths []float64
+		for _, e := range r.edges() {
+			if d := r.dist(e[0], e[1]); d > maxLength {
+				long = append(long, e)
+				lengths = append(lengths, d)
+			}
+		}
+		if len(long) == 0 {
+			return
+		}
+		order := make([]int, len(long))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool { return lengths[order[i]] > lengths[order[j]] })
+		for _, i := range order {
+			// The edge no longer exists if one of its faces was split before.
+			if len(r.edgeFaces(long[i][0], long[i][1])) > 0 {
+				r.splitEdge(long[i][0], long[i][1])
+			}
+		}
+	}
+}
+
+// tryCollapseEdge collapses the edge between vertices a and b into its midpoint, if this keeps the mesh manifold,
+// creates no edges longer than maxLength, and flips no faces. Returns whether the edge was collapsed.
+func (r *remesher) tryCollapseEdge(a int32, b int32, maxLength float64) bool {
+	edgeFaces := r.edgeFaces(a, b)
+	if len(edgeFaces) != 2 || r.isBoundary(a) || r.isBoundary(b) {
+		return false
+	}
+	// The link condition: the only common neighbors of a and b are the opposite vertices of the 2 faces of the edge.
+	neighborsA, neighborsB := r.neighbors(a), r.neighbors(b)
+	numCommon := 0
+	for _, n := range neighborsA {
+		if containsIndex(neighborsB, n) {
+			numCommon++
+			if len(r.neighbors(n)) <= 3 {
+				return false
+			}
+		}
+	}
+	if numCommon != 2 || len(neighborsA)+len(neighborsB)-4 < 3 {
+		return false
+	}
+
+	pa, pb := r.verts[a], r.verts[b]
+	p := [3]float64{(pa[0] + pb[0]) / 2, (pa[1] + pb[1]) / 2, (pa[2] + pb[2]) / 2}
+	for _, n := range append(neighborsA, neighborsB...) {
+		if n != a && n != b && math.Sqrt(distanceSquared64(p, r.verts[n])) > maxLength {
+			return false
+		}
+	}
+	faces := append(append([]int32{}, r.facesOf(a)...), r.facesOf(b)...)
+	for _, f := range faces {
+		if containsIndex(edgeFaces, f) {
+			continue
+		}
+		var before, after [3][3]float64
+		for j, v := range r.faces[f] {
+			before[j] = r.verts[v]
+			after[j] = r.verts[v]
+			if v == a || v == b {
+				after[j] = p
+			}
+		}
+		if dot64(faceCross(before[0], before[1], before[2]), faceCross(after[0], after[1], after[2])) <= 0 {
+			return false
+		}
+	}
+
+	r.verts[a] = p
+	for _, f := range edgeFaces {
+		r.faceAlive[f] = false
+	}
+	for _, f := range r.facesOf(b) {
+		r.faces[f][faceIndexOf(r.faces[f], b)] = a
+		r.vertFaces[a] = append(r.vertFaces[a], f)
+	}
+	r.vertAlive[b] = false
+	r.vertFaces[b] = nil
+	return true
+}
+
+// collapseShortEdges collapses edges shorter than minLength, see tryCollapseEdge.
+func (r *remesher) collapseShortEdges(minLength float64, maxLength float64) {
+	queue := r.edges()
+	for len(queue) > 0 {
+		e := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		a, b := e[0], e[1]
+		if !r.vertAlive[a] || !r.vertAlive[b] || r.dist(a, b) >= minLength || len(r.edgeFaces(a, b)) == 0 {
+			continue
+		}
+		if r.tryCollapseEdge(a, b, maxLength) {
+			for _, n := range r.neighbors(a) {
+				queue = append(queue, [2]int32{a, n})
+			}
+		}
+	}
+}
+
+// flipEdges flips edges if this brings the valences of the 4 involved vertices closer to the ideal valence, which is 6 for inner vertices and 4 for boundary vertices.
+func (r *remesher) flipEdges() {
+	valenceDeviation := func(v int32, change int) int {
+		target := 6
+		if r.isBoundary(v) {
+			target = 4
+		}
+		d := len(r.neighbors(v)) + change - target
+		return d * d
+	}
+	for _, e := range r.edges() {
+		a, b := e[0], e[1]
+		edgeFaces := r.edgeFaces(a, b)
+		if len(edgeFaces) != 2 || r.isBoundary(a) || r.isBoundary(b) {
+			continue
+		}
+		// Orient the edge so that face f1 is (a, b, c) and face f2 is (b, a, d), in cyclic order.
+		f1, f2 := edgeFaces[0], edgeFaces[1]
+		if r.faces[f1][(faceIndexOf(r.faces[f1], a)+1)%3] != b {
+			f1, f2 = f2, f1
+		}
+		c := r.faces[f1][(faceIndexOf(r.faces[f1], b)+1)%3]
+		d := r.faces[f2][(faceIndexOf(r.faces[f2], a)+1)%3]
+		if c == d || len(r.edgeFaces(c, d)) > 0 || len(r.neighbors(a)) <= 3 || len(r.neighbors(b)) <= 3 {
+			continue
+		}
+		before := valenceDeviation(a, 0) + valenceDeviation(b, 0) + valenceDeviation(c, 0) + valenceDeviation(d, 0)
+		after := valenceDeviation(a, -1) + valenceDeviation(b, -1) + valenceDeviation(c, 1) + valenceDeviation(d, 1)
+		if after >= before {
+			continue
+		}
+		// The new faces (a, d, c) and (d, b, c) must face the same way as the old ones, otherwise the flip folds the surface.
+		normal := faceCross(r.verts[a], r.verts[b], r.verts[c])
+		n2 := faceCross(r.verts[b], r.verts[a], r.verts[d])
+		normal = [3]float64{normal[0] + n2[0], normal[1] + n2[1], normal[2] + n2[2]}
+		if dot64(normal, faceCross(r.verts[a], r.verts[d], r.verts[c])) <= 0 || dot64(normal, faceCross(r.verts[d], r.verts[b], r.verts[c])) <= 0 {
+			continue
+		}
+		r.faces[f1] = [3]int32{a, d, c}
+		r.faces[f2] = [3]int32{d, b, c}
+		r.vertFaces[c] = append(r.vertFaces[c], f2)
+		r.vertFaces[d] = append(r.vertFaces[d], f1)
+	}
+}
+
+// relaxVertices moves each inner vertex towards the centroid of its neighbors, within the tangent plane of the surface at the vertex.
+func (r *remesher) relaxVertices() {
+	normals := make([][3]float64, len(r.verts))
+	for f, face := range r.faces {
+		if !r.faceAlive[f] {
+			continue
+		}
+		n := faceCross(r.verts[face[0]], r.verts[face[1]], r.verts[face[2]])
+		for _, v := range face {
+			normals[v] = [3]float64{normals[v][0] + n[0], normals[v][1] + n[1], normals[v][2] + n[2]}
+		}
+	}
+	newVerts := make([][3]float64, len(r.verts))
+	copy(newVerts, r.verts)
+	for v := range r.verts {
+		length := math.Sqrt(dot64(normals[v], normals[v]))
+		if !r.vertAlive[v] || length == 0 || r.isBoundary(int32(v)) {
+			continue
+		}
+		neighbors := r.neighbors(int32(v))
+		var centroid [3]float64
+		for _, n := range neighbors {
+			for k := 0; k < 3; k++ {
+				centroid[k] += r.verts[n][k] / float64(len(neighbors))
+			}
+		}
+		n := [3]float64{normals[v][0] / length, normals[v][1] / length, normals[v][2] / length}
+		move := [3]float64{centroid[0] - r.verts[v][0], centroid[1] - r.verts[v][1], centroid[2] - r.verts[v][2]}
+		normalPart := dot64(move, n)
+		for k := 0; k < 3; k++ {
+			newVerts[v][k] += move[k] - normalPart*n[k]
+		}
+	}
+	r.verts = newVerts
+}
+
+// toMesh converts the live faces to a Mesh, dropping dead vertices and vertices that are not part of any face.
+func (r *remesher) toMesh() Mesh {
+	newIndex := make([]int32, len(r.verts))
+	for v := range newIndex {
+		newIndex[v] = -1
+	}
+	var mesh Mesh
+	for f, face := range r.faces {
+		if !r.faceAlive[f] {
+			continue
+		}
+		for _, v := range face {
+			if newIndex[v] < 0 {
+				newIndex[v] = int32(len(mesh.Vertices) / 3)
+				mesh.Vertices = append(mesh.Vertices, float32(r.verts[v][0]), float32(r.verts[v][1]), float32(r.verts[v][2]))
+			}
+			mesh.Faces = append(mesh.Faces, newIndex[v])
+		}
+	}
+	return mesh
+}
+
+// IsotropicRemesh computes a new triangulation of a surface with approximately uniform triangle sizes and shapes.
+//
+// FreeSurfer surfaces contain many small and thin triangles, which reduces the accuracy and speed of smoothing, geodesic distance computation
+// and decimation. This function implements the isotropic remeshing algorithm by Botsch and Kobbelt (2004). In each iteration, it splits edges
+// longer than 4/3 of the target edge length, collapses edges shorter than 4/5 of it, flips edges to equalize the vertex valences, and moves
+// the vertices to the centroid of their neighbors within the tangent plane. Finally, the vertices are projected back onto the input surface,
+// so the shape of the surface is kept. Vertices on the boundary of the mesh are not moved, so holes and the medial wall cut of a cortex
+// patch keep their outline.
+//
+// The vertices of the result do not correspond to the input vertices. Use NearestVertexMap or NearestSurfacePoints to transfer per-vertex data.
+//
+// Parameters:
+//   - mesh : the input mesh
+//   - targetEdgeLength : the desired edge length, in the unit of the vertex coordinates. Use the 'avgEdgeLength' from MeshStats to keep the resolution of the mesh.
+//   - iterations : the number of iterations, typically 5 to 10
+//
+// Returns:
+//   - Mesh : the remeshed surface. Vertices of the input mesh that are not part of any face are dropped.
+//   - error : an error if the mesh is invalid or has no faces, or the target edge length or number of iterations are not positive
+func IsotropicRemesh(mesh Mesh, targetEdgeLength float32, iterations int) (Mesh, error) {
+	if err := validateFaceIndices(mesh); err != nil {
+		return Mesh{}, fmt.Errorf("IsotropicRemesh: invalid mesh: %s", err)
+	}
+	if NumFaces(mesh) == 0 {
+		return Mesh{}, fmt.Errorf("IsotropicRemesh: mesh has no faces")
+	}
+	if !(targetEdgeLength > 0) || iterations < 1 {
+		return Mesh{}, fmt.Errorf("IsotropicRemesh: target edge length (%f) and number of iterations (%d) must be positive", targetEdgeLength, iterations)
+	}
+
+	minLength, maxLength := 0.8*float64(targetEdgeLength), 4.0/3.0*float64(targetEdgeLength)
+	r := newRemesher(mesh)
+	for i := 0; i < iterations; i++ {
+		r.splitLongEdges(maxLength)
+		r.collapseShortEdges(minLength, maxLength)
+		r.flipEdges()
+		r.relaxVertices()
+
+		remeshed := r.toMesh()
+		projected, _, err := NearestSurfacePoints(remeshed, mesh)
+		if err != nil {
+			return Mesh{}, fmt.Errorf("IsotropicRemesh: could not project vertices onto input surface: %s", err)
+		}
+		r = newRemesher(Mesh{Vertices: projected, Faces: remeshed.Faces})
+		logDebug("IsotropicRemesh: iteration %d: %d vertices, %d faces.", i+1, NumVertices(remeshed), NumFaces(remeshed))
+	}
+	return r.toMesh(), nil
+}
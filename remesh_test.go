@@ -0,0 +1,97 @@
+package neuro
+
+import (
+	"math"
+	"testing"
+)
+
+// edgeLengthStats computes the mean and the coefficient of variation of the edge lengths of a mesh.
+func edgeLengthStats(mesh Mesh) (float64, float64) {
+	edges, _ := meshEdges(mesh)
+	var sum, sumSquares float64
+	for _, e := range edges {
+		l := edgeLength(mesh, e[0], e[1])
+		sum += l
+		sumSquares += l * l
+	}
+	mean := sum / float64(len(edges))
+	return mean, math.Sqrt(sumSquares/float64(len(edges))-mean*mean) / mean
+}
+
+func TestIsotropicRemeshSphere(t *testing.T) {
+	// The UV sphere has tiny triangles at the poles and large ones at the equator.
+	sphere, _, err := FromTriangleSoup(ToTriangleSoup(GenerateSphere(10, 32, 16)), 1e-5)
+	if err != nil {
+		t.Fatalf("FromTriangleSoup failed: %v", err)
+	}
+	remeshed, err := IsotropicRemesh(sphere, 1.5, 5)
+	if err != nil {
+		t.Fatalf("IsotropicRemesh failed: %v", err)
+	}
+
+	topo, err := ComputeMeshTopology(remeshed)
+	if err != nil || !topo.IsClosed || !topo.IsManifold || topo.Genus != 0 {
+		t.Errorf("remeshed sphere is not a closed manifold of genus 0: %+v, error %v", topo, err)
+	}
+	_, variationBefore := edgeLengthStats(sphere)
+	mean, variation := edgeLengthStats(remeshed)
+	if math.Abs(mean-1.5) > 0.2 || variation > variationBefore/2 {
+		t.Errorf("got mean edge length %f with coefficient of variation %f (%f before remeshing), want close to 1.5 and more uniform", mean, variation, variationBefore)
+	}
+	// The vertices are projected onto the input surface, which lies inside the sphere and at most a few tenths of a mm below it.
+	for v := 0; v < NumVertices(remeshed); v++ {
+		p := meshVertex64(remeshed, int32(v))
+		if r := math.Sqrt(dot64(p, p)); r > 10.001 || r < 9.7 {
+			t.Fatalf("vertex %d has distance %f from the center, want close to 10", v, r)
+		}
+	}
+}
+
+func TestIsotropicRemeshKeepsBoundary(t *testing.T) {
+	// A 5x5 mm patch of the z=0 plane, refined to a target edge length of 0.5 mm.
+	grid := generateGrid(6)
+	remeshed, err := IsotropicRemesh(grid, 0.5, 3)
+	if err != nil {
+		t.Fatalf("IsotropicRemesh failed: %v", err)
+	}
+	if NumVertices(remeshed) <= NumVertices(grid) {
+		t.Errorf("got %d vertices after refinement, want more than %d", NumVertices(remeshed), NumVertices(grid))
+	}
+	topo, _ := ComputeMeshTopology(remeshed)
+	if topo.NumComponents != 1 || topo.NumBoundaryEdges == 0 || topo.EulerCharacteristic != 1 {
+		t.Errorf("remeshed grid is not a single disk: %+v", topo)
+	}
+	boundary := map[int32]bool{}
+	edges, faceCounts := meshEdges(remeshed)
+	for i, e := range edges {
+		if faceCounts[i] == 1 {
+			boundary[e[0]], boundary[e[1]] = true, true
+		}
+	}
+	for v := 0; v < NumVertices(remeshed); v++ {
+		x, y, z := remeshed.Vertices[v*3], remeshed.Vertices[v*3+1], remeshed.Vertices[v*3+2]
+		if z != 0 || x < 0 || x > 5 || y < 0 || y > 5 {
+			t.Fatalf("vertex %d at (%f, %f, %f) left the grid", v, x, y, z)
+		}
+		onOutline := x == 0 || x == 5 || y == 0 || y == 5
+		if boundary[int32(v)] && !onOutline {
+			t.Errorf("boundary vertex %d at (%f, %f) is not on the outline of the grid", v, x, y)
+		}
+	}
+}
+
+func TestIsotropicRemeshInvalid(t *testing.T) {
+	cube := GenerateCube()
+	if _, err := IsotropicRemesh(cube, 0, 5); err == nil {
+		t.Errorf("got no error for target edge length 0, wanted one")
+	}
+	if _, err := IsotropicRemesh(cube, 1, 0); err == nil {
+		t.Errorf("got no error for 0 iterations, wanted one")
+	}
+	if _, err := IsotropicRemesh(Mesh{Vertices: cube.Vertices}, 1, 5); err == nil {
+		t.Errorf("got no error for mesh without faces, wanted one")
+	}
+	if _, err := IsotropicRemesh(Mesh{Vertices: cube.Vertices, Faces: []int32{0, 1, 8}}, 1, 5); err == nil {
+		t.Errorf("got no error for invalid face indices, wanted one")
+	}
+}
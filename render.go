@@ -0,0 +1,457 @@
+package neuro
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// RenderCamera models the camera used by RenderMeshToImage.
+type RenderCamera struct {
+	Position    [3]float32 // position of the camera (the eye)
+	Target      [3]float32 // the point the camera looks at
+	Up          [3]float32 // the direction that points up in the image. Must not be parallel to the viewing direction.
+	Perspective bool       // whether to use a perspective projection. If false, an orthographic projection is used.
+	FieldOfView float32    // the field of view in degrees along the shorter side of the image, only used for perspective projections. If 0, 30 degrees are used.
+}
+
+// RenderOptions holds the settings for RenderMeshToImage.
+type RenderOptions struct {
+	Width      int          // width of the image in pixels
+	Height     int          // height of the image in pixels
+	Camera     RenderCamera // the camera
+	Shading    string       // the shading mode, one of 'flat' (one color per face) or 'gouraud' (colors interpolated over faces, smooth appearance). If empty, 'flat' is used.
+	Colors     []uint8      // optional per-vertex colors, as a flat array of RGB values [r1, g1, b1, r2, g2, b2, ...], e.g., from OverlayColors. If nil, the mesh is rendered in gray.
+	Background color.RGBA   // the background color
+}
+
+// renderViewAxes holds the direction pointing up on screen and the direction pointing towards the viewer of a view, in RAS coordinates.
+type renderViewAxes struct {
+	up     [3]float32
+	toward [3]float32
+}
+
+// renderViews holds the axes of the standard views of a left hemisphere. For a right hemisphere, the lateral and medial views are swapped.
+var renderViews = map[string]renderViewAxes{
+	"lateral":   {up: [3]float32{0, 0, 1}, toward: [3]float32{-1, 0, 0}},
+	"medial":    {up: [3]float32{0, 0, 1}, toward: [3]float32{1, 0, 0}},
+	"dorsal":    {up: [3]float32{0, 1, 0}, toward: [3]float32{0, 0, 1}},
+	"ventral":   {up: [3]float32{0, 1, 0}, toward: [3]float32{0, 0, -1}},
+	"anterior":  {up: [3]float32{0, 0, 1}, toward: [3]float32{0, 1, 0}},
+	"posterior": {up: [3]float32{0, 0, 1}, toward: [3]float32{0, -1, 0}},
+}
+
+// renderDefaultColor is the color of the mesh if no per-vertex colors are given.
+var renderDefaultColor = [3]uint8{200, 200, 200}
+
+// renderDefaultFieldOfView is the field of view in degrees along the shorter side of the image used for perspective cameras if none is given.
+const renderDefaultFieldOfView = 30
+
+// ViewCamera returns a camera that shows a brain surface mesh from one of the standard anatomical views.
+//
+// The camera looks at the center of the bounding box of the mesh, from a distance at which the whole mesh is visible.
+// The coordinates of the mesh are interpreted as RAS coordinates, like in FreeSurfer surfaces. Whether the mesh
+// is a left or right hemisphere is determined from the sign of the mean x coordinate, which affects the lateral and medial views.
+//
+// Parameters:
+//   - mesh: the mesh
+//   - view: the view, one of 'lateral', 'medial', 'dorsal', 'ventral', 'anterior', 'posterior'
+//   - perspective: whether the camera should use a perspective projection instead of an orthographic one
+//
+// Returns:
+//   - RenderCamera: the camera, see RenderMeshToImage
+//   - error: an error if one occurred, e.g., the view is unknown or the mesh has no vertices
+func ViewCamera(mesh Mesh, view string, perspective bool) (RenderCamera, error) {
+	var camera RenderCamera
+	axes, ok := renderViews[view]
+	if !ok {
+		return camera, fmt.Errorf("ViewCamera: invalid view '%s', use one of 'lateral', 'medial', 'dorsal', 'ventral', 'anterior', 'posterior'", view)
+	}
+	if NumVertices(mesh) == 0 {
+		return camera, fmt.Errorf("ViewCamera: mesh has no vertices")
+	}
+
+	minCoord := [3]float32{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}
+	maxCoord := [3]float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	var sumX float64
+	for i := 0; i < len(mesh.Vertices); i += 3 {
+		for j := 0; j < 3; j++ {
+			if mesh.Vertices[i+j] < minCoord[j] {
+				minCoord[j] = mesh.Vertices[i+j]
+			}
+			if mesh.Vertices[i+j] > maxCoord[j] {
+				maxCoord[j] = mesh.Vertices[i+j]
+			}
+		}
+		sumX += float64(mesh.Vertices[i])
+	}
+
+	if sumX > 0 && (view == "lateral" || view == "medial") { // right hemisphere
+		axes.toward = [3]float32{-axes.toward[0], -axes.toward[1], -axes.toward[2]}
+	}
+
+	var radius float32
+	for j := 0; j < 3; j++ {
+		camera.Target[j] = (minCoord[j] + maxCoord[j]) / 2
+		radius += (maxCoord[j] - minCoord[j]) * (maxCoord[j] - minCoord[j]) / 4
+	}
+	radius = float32(math.Sqrt(float64(radius)))
+	if radius == 0 {
+		radius = 1
+	}
+
+	// Far enough away so that the bounding sphere of the mesh fits into the field of view. The field of view applies to the
+	// shorter side of the image, i.e., it is the minimum of the horizontal and vertical field of view, so the mesh fits for all image sizes.
+	distance := 2 * radius
+	if perspective {
+		camera.FieldOfView = renderDefaultFieldOfView
+		distance = 1.1 * radius / float32(math.Sin(float64(camera.FieldOfView)/2*math.Pi/180))
+	}
+	for j := 0; j < 3; j++ {
+		camera.Position[j] = camera.Target[j] + distance*axes.toward[j]
+	}
+	camera.Up = axes.up
+	camera.Perspective = perspective
+	return camera, nil
+}
+
+// RenderMeshView renders a brain surface mesh from one of the standard anatomical views into an image.
+//
+// The mesh is rendered with an orthographic camera and flat shading, with the light coming from the viewer, and scaled to fill the image.
+// See ViewCamera for details on the views, and RenderMeshToImage for more rendering options.
+//
+// Parameters:
+//   - mesh: the mesh to render
+//   - colors: optional per-vertex colors, as a flat array of RGB values [r1, g1, b1, r2, g2, b2, ...], e.g., from OverlayColors. Pass nil to render the mesh in gray.
+//   - view: the view, one of 'lateral', 'medial', 'dorsal', 'ventral', 'anterior', 'posterior'
+//   - width: the width of the image in pixels
+//   - height: the height of the image in pixels
+//
+// Returns:
+//   - *image.RGBA: the rendered image, with a white background
+//   - error: an error if one occurred, e.g., the view is unknown or the number of colors does not match the mesh
+func RenderMeshView(mesh Mesh, colors []uint8, view string, width int, height int) (*image.RGBA, error) {
+	camera, err := ViewCamera(mesh, view, false)
+	if err != nil {
+		return nil, fmt.Errorf("RenderMeshView: %s", err)
+	}
+	opts := RenderOptions{Width: width, Height: height, Camera: camera, Shading: "flat", Colors: colors, Background: color.RGBA{255, 255, 255, 255}}
+	img, err := RenderMeshToImage(mesh, opts)
+	if err != nil {
+		return nil, fmt.Errorf("RenderMeshView: %s", err)
+	}
+	return img, nil
+}
+
+// RenderMeshToImage renders a mesh into an image, using a software rasterizer with a z-buffer.
+//
+// This works without OpenGL or a display, e.g., on servers and headless cluster nodes. The light comes from the camera.
+// For orthographic cameras, the mesh is scaled to fill the image, so only the viewing direction of the camera matters.
+// For perspective cameras, the position and field of view of the camera determine which part of the mesh is visible.
+//
+// Parameters:
+//   - mesh: the mesh to render
+//   - opts: the rendering options, including the image size and the camera. Use ViewCamera to get a camera for the standard anatomical views.
+//
+// Returns:
+//   - *image.RGBA: the rendered image
+//   - error: an error if one occurred, e.g., the options are invalid or the number of colors does not match the mesh
+func RenderMeshToImage(mesh Mesh, opts RenderOptions) (*image.RGBA, error) {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return nil, fmt.Errorf("RenderMeshToImage: invalid image size %dx%d", opts.Width, opts.Height)
+	}
+	if opts.Colors != nil && len(opts.Colors) != len(mesh.Vertices) {
+		return nil, fmt.Errorf("RenderMeshToImage: got %d color values, but need 3 per vertex for %d vertices", len(opts.Colors), NumVertices(mesh))
+	}
+	shading := opts.Shading
+	if shading == "" {
+		shading = "flat"
+	}
+	if shading != "flat" && shading != "gouraud" {
+		return nil, fmt.Errorf("RenderMeshToImage: invalid shading '%s', use one of 'flat', 'gouraud'", opts.Shading)
+	}
+	if err := validateFaceIndices(mesh); err != nil {
+		return nil, fmt.Errorf("RenderMeshToImage: invalid mesh: %s", err)
+	}
+
+	// The camera coordinate system: right and up span the image plane, forward points into the scene.
+	camera := opts.Camera
+	forward := normalize3(sub3(camera.Target, camera.Position))
+	right := normalize3(cross3(forward, camera.Up))
+	if forward == [3]float32{} || right == [3]float32{} {
+		return nil, fmt.Errorf("RenderMeshToImage: invalid camera, position and target must differ and the up direction must not be parallel to the viewing direction")
+	}
+	up := cross3(right, forward)
+	toward := [3]float32{-forward[0], -forward[1], -forward[2]}
+
+	width, height := opts.Width, opts.Height
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, opts.Background)
+		}
+	}
+	if NumFaces(mesh) == 0 {
+		return img, nil
+	}
+
+	// Transform the vertices into screen coordinates (x, y, depth), with larger depth values closer to the camera.
+	// For perspective cameras, the depth is the inverse distance from the camera, which (unlike the distance itself) can be
+	// interpolated linearly in screen space.
+	numVertices := NumVertices(mesh)
+	screen := make([][3]float32, numVertices)
+	visible := make([]bool, numVertices)
+	for i := 0; i < numVertices; i++ {
+		p := sub3([3]float32{mesh.Vertices[i*3], mesh.Vertices[i*3+1], mesh.Vertices[i*3+2]}, camera.Position)
+		screen[i] = [3]float32{dot3(p, right), dot3(p, up), -dot3(p, forward)}
+		visible[i] = true
+	}
+	if camera.Perspective {
+		fov := camera.FieldOfView
+		if fov <= 0 {
+			fov = renderDefaultFieldOfView
+		}
+		shortSide := width
+		if height < shortSide {
+			shortSide = height
+		}
+		focal := float32(shortSide) / 2 / float32(math.Tan(float64(fov)/2*math.Pi/180))
+		for i := range screen {
+			dist := -screen[i][2]
+			if dist <= 0 {
+				visible[i] = false // behind the camera
+				continue
+			}
+			screen[i][0] = float32(width)/2 + screen[i][0]/dist*focal
+			screen[i][1] = float32(height)/2 - screen[i][1]/dist*focal
+			screen[i][2] = 1 / dist
+		}
+	} else {
+		fitOrthographic(screen, width, height)
+	}
+
+	// Per-vertex colors, and for Gouraud shading, the per-vertex light intensity.
+	vertexColors := make([][3]float32, numVertices)
+	for i := range vertexColors {
+		if opts.Colors != nil {
+			vertexColors[i] = [3]float32{float32(opts.Colors[i*3]), float32(opts.Colors[i*3+1]), float32(opts.Colors[i*3+2])}
+		} else {
+			vertexColors[i] = [3]float32{float32(renderDefaultColor[0]), float32(renderDefaultColor[1]), float32(renderDefaultColor[2])}
+		}
+	}
+	if shading == "gouraud" {
+		normals := VertexNormals(mesh)
+		for i := range vertexColors {
+			n := [3]float32{normals[i*3], normals[i*3+1], normals[i*3+2]}
+			intensity := lightIntensity(n, toward)
+			for c := 0; c < 3; c++ {
+				vertexColors[i][c] *= intensity
+			}
+		}
+	}
+
+	zbuffer := make([]float32, width*height)
+	for i := range zbuffer {
+		zbuffer[i] = -float32(math.MaxFloat32)
+	}
+
+	for f := 0; f < NumFaces(mesh); f++ {
+		idx := [3]int32{mesh.Faces[f*3], mesh.Faces[f*3+1], mesh.Faces[f*3+2]}
+		if !visible[idx[0]] || !visible[idx[1]] || !visible[idx[2]] {
+			continue
+		}
+
+		if shading == "flat" {
+			intensity := lightIntensity(faceNormal(mesh, f), toward)
+			var faceColor color.RGBA
+			faceColor.A = 255
+			c0, c1, c2 := vertexColors[idx[0]], vertexColors[idx[1]], vertexColors[idx[2]]
+			faceColor.R = clampUint8((c0[0] + c1[0] + c2[0]) / 3 * intensity)
+			faceColor.G = clampUint8((c0[1] + c1[1] + c2[1]) / 3 * intensity)
+			faceColor.B = clampUint8((c0[2] + c1[2] + c2[2]) / 3 * intensity)
+			rasterizeTriangle(screen[idx[0]], screen[idx[1]], screen[idx[2]], width, height, func(x int, y int, w [3]float32) {
+				depth := w[0]*screen[idx[0]][2] + w[1]*screen[idx[1]][2] + w[2]*screen[idx[2]][2]
+				if depth > zbuffer[y*width+x] {
+					zbuffer[y*width+x] = depth
+					img.SetRGBA(x, y, faceColor)
+				}
+			})
+		} else {
+			c0, c1, c2 := vertexColors[idx[0]], vertexColors[idx[1]], vertexColors[idx[2]]
+			rasterizeTriangle(screen[idx[0]], screen[idx[1]], screen[idx[2]], width, height, func(x int, y int, w [3]float32) {
+				depth := w[0]*screen[idx[0]][2] + w[1]*screen[idx[1]][2] + w[2]*screen[idx[2]][2]
+				if depth > zbuffer[y*width+x] {
+					zbuffer[y*width+x] = depth
+					if camera.Perspective {
+						// Perspective-correct interpolation: weight the screen space barycentric coordinates by the inverse distances.
+						for j := 0; j < 3; j++ {
+							w[j] *= screen[idx[j]][2] / depth
+						}
+					}
+					img.SetRGBA(x, y, color.RGBA{
+						clampUint8(w[0]*c0[0] + w[1]*c1[0] + w[2]*c2[0]),
+						clampUint8(w[0]*c0[1] + w[1]*c1[1] + w[2]*c2[1]),
+						clampUint8(w[0]*c0[2] + w[1]*c1[2] + w[2]*c2[2]),
+						255})
+				}
+			})
+		}
+	}
+	return img, nil
+}
+
+// VertexNormals computes the unit normals of all vertices of a mesh.
+//
+// The normal of a vertex is the average of the normals of the faces it belongs to, weighted by the face areas.
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - []float32 : the normals, as a flat array [nx1, ny1, nz1, nx2, ...]. All zeros for vertices that are not part of any non-degenerate face.
+func VertexNormals(mesh Mesh) []float32 {
+	normals := make([]float32, len(mesh.Vertices))
+	for f := 0; f < NumFaces(mesh); f++ {
+		v0 := mesh.Faces[f*3] * 3
+		v1 := mesh.Faces[f*3+1] * 3
+		v2 := mesh.Faces[f*3+2] * 3
+		e1 := [3]float32{mesh.Vertices[v1] - mesh.Vertices[v0], mesh.Vertices[v1+1] - mesh.Vertices[v0+1], mesh.Vertices[v1+2] - mesh.Vertices[v0+2]}
+		e2 := [3]float32{mesh.Vertices[v2] - mesh.Vertices[v0], mesh.Vertices[v2+1] - mesh.Vertices[v0+1], mesh.Vertices[v2+2] - mesh.Vertices[v0+2]}
+		n := cross3(e1, e2) // length is twice the face area, which gives the weighting
+		for _, v := range []int32{v0, v1, v2} {
+			normals[v] += n[0]
+			normals[v+1] += n[1]
+			normals[v+2] += n[2]
+		}
+	}
+	for i := 0; i < len(normals); i += 3 {
+		n := normalize3([3]float32{normals[i], normals[i+1], normals[i+2]})
+		normals[i], normals[i+1], normals[i+2] = n[0], n[1], n[2]
+	}
+	return normals
+}
+
+// lightIntensity computes the brightness of a surface with normal n, lit from direction light. The absolute value of the angle is used, so that meshes with inconsistent face orientation are shaded correctly.
+func lightIntensity(n [3]float32, light [3]float32) float32 {
+	return 0.25 + 0.75*float32(math.Abs(float64(dot3(n, light))))
+}
+
+// clampUint8 converts a color channel value to uint8, clamping it to the range [0, 255].
+func clampUint8(v float32) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+// fitOrthographic scales and translates the x and y coordinates of orthographically projected points so that they fill the image, keeping the aspect ratio and leaving a small margin.
+func fitOrthographic(screen [][3]float32, width int, height int) {
+	const margin = 0.05
+	minX, minY := float32(math.MaxFloat32), float32(math.MaxFloat32)
+	maxX, maxY := -float32(math.MaxFloat32), -float32(math.MaxFloat32)
+	for _, p := range screen {
+		minX, maxX = min2f(minX, p[0]), max2f(maxX, p[0])
+		minY, maxY = min2f(minY, p[1]), max2f(maxY, p[1])
+	}
+
+	extentX, extentY := maxX-minX, maxY-minY
+	scale := float32(math.Inf(1))
+	if extentX > 0 {
+		scale = float32(width) * (1 - 2*margin) / extentX
+	}
+	if extentY > 0 {
+		scale = min2f(scale, float32(height)*(1-2*margin)/extentY)
+	}
+	if math.IsInf(float64(scale), 1) {
+		scale = 1
+	}
+	centerX, centerY := (minX+maxX)/2, (minY+maxY)/2
+	for i := range screen {
+		screen[i][0] = float32(width)/2 + (screen[i][0]-centerX)*scale
+		screen[i][1] = float32(height)/2 - (screen[i][1]-centerY)*scale
+	}
+}
+
+// rasterizeTriangle calls plot for every pixel whose center is covered by the triangle (a, b, c), given in screen coordinates.
+// The barycentric coordinates of the pixel center with respect to a, b and c are passed to plot, for interpolating values over the triangle.
+func rasterizeTriangle(a [3]float32, b [3]float32, c [3]float32, width int, height int, plot func(x int, y int, w [3]float32)) {
+	area := edgeFunction(a, b, c)
+	if area == 0 {
+		return
+	}
+
+	x0 := int(math.Floor(float64(min2f(a[0], min2f(b[0], c[0])))))
+	x1 := int(math.Ceil(float64(max2f(a[0], max2f(b[0], c[0])))))
+	y0 := int(math.Floor(float64(min2f(a[1], min2f(b[1], c[1])))))
+	y1 := int(math.Ceil(float64(max2f(a[1], max2f(b[1], c[1])))))
+	if x0 < 0 {
+		x0 = 0
+	}
+	if y0 < 0 {
+		y0 = 0
+	}
+	if x1 > width-1 {
+		x1 = width - 1
+	}
+	if y1 > height-1 {
+		y1 = height - 1
+	}
+
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			p := [3]float32{float32(x) + 0.5, float32(y) + 0.5, 0}
+			w := [3]float32{edgeFunction(b, c, p) / area, edgeFunction(c, a, p) / area, edgeFunction(a, b, p) / area}
+			if w[0] < 0 || w[1] < 0 || w[2] < 0 {
+				continue
+			}
+			plot(x, y, w)
+		}
+	}
+}
+
+// edgeFunction returns twice the signed area of the triangle (a, b, p), using only the x and y coordinates.
+func edgeFunction(a [3]float32, b [3]float32, p [3]float32) float32 {
+	return (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+}
+
+// dot3 returns the dot product of two 3D vectors.
+func dot3(a [3]float32, b [3]float32) float32 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+// sub3 returns the difference a - b of two 3D vectors.
+func sub3(a [3]float32, b [3]float32) [3]float32 {
+	return [3]float32{a[0] - b[0], a[1] - b[1], a[2] - b[2]}
+}
+
+// cross3 returns the cross product of two 3D vectors.
+func cross3(a [3]float32, b [3]float32) [3]float32 {
+	return [3]float32{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+// normalize3 returns the unit vector in the direction of a, or the zero vector if a has length zero.
+func normalize3(a [3]float32) [3]float32 {
+	length := float32(math.Sqrt(float64(dot3(a, a))))
+	if length == 0 {
+		return [3]float32{}
+	}
+	return [3]float32{a[0] / length, a[1] / length, a[2] / length}
+}
+
+func min2f(a float32, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max2f(a float32, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
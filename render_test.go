@@ -0,0 +1,167 @@
+package neuro
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestRenderMeshViewCube(t *testing.T) {
+	img, err := RenderMeshView(GenerateCube(), nil, "dorsal", 64, 48)
+	if err != nil {
+		t.Fatalf("RenderMeshView failed: %s", err)
+	}
+	if img.Bounds().Dx() != 64 || img.Bounds().Dy() != 48 {
+		t.Errorf("got image size %v, wanted 64x48", img.Bounds())
+	}
+
+	// The cube fills the center of the image, the corners show the white background.
+	center := img.RGBAAt(32, 24)
+	if center.R == 255 && center.G == 255 && center.B == 255 {
+		t.Errorf("expected mesh color at image center, got background")
+	}
+	corner := img.RGBAAt(0, 0)
+	if corner.R != 255 || corner.G != 255 || corner.B != 255 {
+		t.Errorf("expected white background at image corner, got %v", corner)
+	}
+}
+
+func TestRenderMeshViewColors(t *testing.T) {
+	cube := GenerateCube()
+	colors := make([]uint8, len(cube.Vertices))
+	for i := 0; i < len(colors); i += 3 {
+		colors[i] = 255 // red
+	}
+
+	img, err := RenderMeshView(cube, colors, "anterior", 32, 32)
+	if err != nil {
+		t.Fatalf("RenderMeshView failed: %s", err)
+	}
+	center := img.RGBAAt(16, 16)
+	if center.R == 0 || center.G != 0 || center.B != 0 {
+		t.Errorf("expected red at image center, got %v", center)
+	}
+}
+
+func TestRenderMeshViewInvalid(t *testing.T) {
+	cube := GenerateCube()
+	if _, err := RenderMeshView(cube, nil, "sideways", 32, 32); err == nil {
+		t.Errorf("expected error for invalid view")
+	}
+	if _, err := RenderMeshView(cube, []uint8{1, 2, 3}, "lateral", 32, 32); err == nil {
+		t.Errorf("expected error for wrong number of colors")
+	}
+	if _, err := RenderMeshView(cube, nil, "lateral", 0, 32); err == nil {
+		t.Errorf("expected error for invalid image size")
+	}
+}
+
+func TestRenderMeshToImageGouraudPerspective(t *testing.T) {
+	cube := GenerateCube()
+	camera, err := ViewCamera(cube, "anterior", true)
+	if err != nil {
+		t.Fatalf("ViewCamera failed: %s", err)
+	}
+
+	background := color.RGBA{0, 0, 0, 255}
+	img, err := RenderMeshToImage(cube, RenderOptions{Width: 40, Height: 30, Camera: camera, Shading: "gouraud", Background: background})
+	if err != nil {
+		t.Fatalf("RenderMeshToImage failed: %s", err)
+	}
+	if img.RGBAAt(20, 15) == background {
+		t.Errorf("expected mesh color at image center, got background")
+	}
+	if img.RGBAAt(0, 0) != background {
+		t.Errorf("expected background at image corner, got %v", img.RGBAAt(0, 0))
+	}
+}
+
+func TestRenderMeshToImageZBuffer(t *testing.T) {
+	// Two parallel triangles covering the image center, the red one closer to the camera. The far one is drawn last.
+	mesh := Mesh{
+		Vertices: []float32{-1, -1, 1, 1, -1, 1, 0, 1, 1, -1, -1, 0, 1, -1, 0, 0, 1, 0},
+		Faces:    []int32{0, 1, 2, 3, 4, 5},
+	}
+	colors := []uint8{255, 0, 0, 255, 0, 0, 255, 0, 0, 0, 0, 255, 0, 0, 255, 0, 0, 255}
+	camera := RenderCamera{Position: [3]float32{0, 0, 10}, Target: [3]float32{0, 0, 0}, Up: [3]float32{0, 1, 0}}
+
+	for _, perspective := range []bool{false, true} {
+		camera.Perspective = perspective
+		img, err := RenderMeshToImage(mesh, RenderOptions{Width: 20, Height: 20, Camera: camera, Colors: colors})
+		if err != nil {
+			t.Fatalf("RenderMeshToImage failed: %s", err)
+		}
+		center := img.RGBAAt(10, 10)
+		if center.R == 0 || center.B != 0 {
+			t.Errorf("perspective=%t: expected the closer red triangle at image center, got %v", perspective, center)
+		}
+	}
+}
+
+func TestRenderMeshToImageInvalid(t *testing.T) {
+	cube := GenerateCube()
+	camera, _ := ViewCamera(cube, "lateral", false)
+	if _, err := RenderMeshToImage(cube, RenderOptions{Width: 10, Height: 10, Camera: camera, Shading: "phong"}); err == nil {
+		t.Errorf("expected error for invalid shading")
+	}
+	camera.Up = [3]float32{1, 0, 0} // parallel to the viewing direction
+	if _, err := RenderMeshToImage(cube, RenderOptions{Width: 10, Height: 10, Camera: camera}); err == nil {
+		t.Errorf("expected error for invalid camera")
+	}
+}
+
+func TestVertexNormals(t *testing.T) {
+	// A single triangle in the xy plane, counter-clockwise when seen from +z.
+	mesh := Mesh{Vertices: []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}, Faces: []int32{0, 1, 2}}
+	normals := VertexNormals(mesh)
+	for i := 0; i < 3; i++ {
+		if normals[i*3] != 0 || normals[i*3+1] != 0 || normals[i*3+2] != 1 {
+			t.Errorf("got normal %v for vertex %d, wanted [0 0 1]", normals[i*3:i*3+3], i)
+		}
+	}
+}
+
+func TestRenderMeshToImagePerspectiveDepth(t *testing.T) {
+	// Two intersecting quads: the red one is tilted (z = -x/2), so it is in front of the blue one (z = 0) left of x = 0 and behind it right of x = 0.
+	// With a perspective camera, interpolating the distance linearly in screen space would put the crossing 25 pixels left of the image center.
+	mesh := Mesh{
+		Vertices: []float32{-4, -1, 2, 4, -1, -2, 4, 1, -2, -4, 1, 2, -4, -1, 0, 4, -1, 0, 4, 1, 0, -4, 1, 0},
+		Faces:    []int32{0, 1, 2, 0, 2, 3, 4, 5, 6, 4, 6, 7},
+	}
+	colors := []uint8{255, 0, 0, 255, 0, 0, 255, 0, 0, 255, 0, 0, 0, 0, 255, 0, 0, 255, 0, 0, 255, 0, 0, 255}
+	camera := RenderCamera{Position: [3]float32{0, 0, 6}, Target: [3]float32{0, 0, 0}, Up: [3]float32{0, 1, 0}, Perspective: true, FieldOfView: 90}
+
+	for _, shading := range []string{"flat", "gouraud"} {
+		img, err := RenderMeshToImage(mesh, RenderOptions{Width: 400, Height: 200, Camera: camera, Shading: shading, Colors: colors})
+		if err != nil {
+			t.Fatalf("RenderMeshToImage failed: %s", err)
+		}
+
+		// The quads intersect at x = 0, which is projected to the image center column 200.
+		left, right := img.RGBAAt(190, 100), img.RGBAAt(210, 100)
+		if left.R == 0 || left.B != 0 {
+			t.Errorf("shading %s: expected red left of the intersection, got %v", shading, left)
+		}
+		if right.B == 0 || right.R != 0 {
+			t.Errorf("shading %s: expected blue right of the intersection, got %v", shading, right)
+		}
+	}
+}
+
+func TestViewCameraPerspectivePortrait(t *testing.T) {
+	// In a portrait image, the horizontal field of view is the smaller one, and the mesh must still fit.
+	cube := GenerateCube()
+	camera, err := ViewCamera(cube, "anterior", true)
+	if err != nil {
+		t.Fatalf("ViewCamera failed: %s", err)
+	}
+	background := color.RGBA{0, 0, 0, 255}
+	img, err := RenderMeshToImage(cube, RenderOptions{Width: 20, Height: 80, Camera: camera, Background: background})
+	if err != nil {
+		t.Fatalf("RenderMeshToImage failed: %s", err)
+	}
+	for y := 0; y < 80; y++ {
+		if img.RGBAAt(0, y) != background || img.RGBAAt(19, y) != background {
+			t.Fatalf("mesh touches the left or right image border in row %d, it is clipped", y)
+		}
+	}
+}
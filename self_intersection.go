@@ -0,0 +1,104 @@
+package neuro
+
+import (
+	"fmt"
+	"sort"
+)
+
+// segmentIntersectsTriangle checks whether the segment from p to q intersects the triangle (a, b, c), using the Möller-Trumbore algorithm.
+// Segments that lie in the plane of the triangle are not reported.
+func segmentIntersectsTriangle(p [3]float64, q [3]float64, a [3]float64, b [3]float64, c [3]float64) bool {
+	const eps = 1e-12
+	dir := sub64(q, p)
+	e1, e2 := sub64(b, a), sub64(c, a)
+	h := cross64(dir, e2)
+	det := dot64(e1, h)
+	if det > -eps && det < eps {
+		return false // the segment is parallel to the triangle
+	}
+	f := 1 / det
+	s := sub64(p, a)
+	u := f * dot64(s, h)
+	if u < 0 || u > 1 {
+		return false
+	}
+	qv := cross64(s, e1)
+	v := f * dot64(dir, qv)
+	if v < 0 || u+v > 1 {
+		return false
+	}
+	t := f * dot64(e2, qv)
+	return t >= 0 && t <= 1
+}
+
+// trianglesIntersect checks whether two faces of a mesh intersect.
+//
+// If two triangles that are not coplanar intersect, each end point of the intersection segment lies on an edge of one of the triangles,
+// so it is sufficient to test the 6 edges against the other triangle. Overlaps of coplanar triangles are not detected.
+func trianglesIntersect(mesh Mesh, f1 int32, f2 int32) bool {
+	var t1, t2 [3][3]float64
+	for j := 0; j < 3; j++ {
+		v1, v2 := mesh.Faces[f1*3+int32(j)]*3, mesh.Faces[f2*3+int32(j)]*3
+		for k := 0; k < 3; k++ {
+			t1[j][k] = float64(mesh.Vertices[v1+int32(k)])
+			t2[j][k] = float64(mesh.Vertices[v2+int32(k)])
+		}
+	}
+	for j := 0; j < 3; j++ {
+		if segmentIntersectsTriangle(t1[j], t1[(j+1)%3], t2[0], t2[1], t2[2]) || segmentIntersectsTriangle(t2[j], t2[(j+1)%3], t1[0], t1[1], t1[2]) {
+			return true
+		}
+	}
+	return false
+}
+
+// facesShareVertex checks whether two faces of a mesh have a vertex in common.
+func facesShareVertex(mesh Mesh, f1 int32, f2 int32) bool {
+	for j := int32(0); j < 3; j++ {
+		for k := int32(0); k < 3; k++ {
+			if mesh.Faces[f1*3+j] == mesh.Faces[f2*3+k] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FindSelfIntersections finds the pairs of faces of a mesh that intersect each other.
+//
+// Pial surfaces sometimes self-intersect after aggressive smoothing or decimation, which makes operations that rely on a
+// well-defined inside and outside, like volume computation or voxelization, fail silently. A bounding volume hierarchy
+// is used to find candidate face pairs, so this is fast enough for full brain meshes.
+//
+// Faces that share a vertex or an edge are neighbors in the mesh and are not tested. Overlaps of coplanar faces are not reported.
+// Faces that merely touch are reported, so meshes with duplicated vertices, e.g., from STL files, should be welded first (see FromTriangleSoup).
+//
+// Parameters:
+//   - mesh : the mesh
+//
+// Returns:
+//   - [][2]int32 : the intersecting face pairs, with the smaller face index first, sorted by face indices. Empty if the mesh does not self-intersect.
+//   - error : an error if the mesh has invalid face indices
+func FindSelfIntersections(mesh Mesh) ([][2]int32, error) {
+	if err := validateFaceIndices(mesh); err != nil {
+		return nil, fmt.Errorf("FindSelfIntersections: %s", err)
+	}
+	bvh := newMeshBVH(mesh)
+	pairs := [][2]int32{}
+	for f := int32(0); f < int32(NumFaces(mesh)); f++ {
+		lo, hi := faceBounds(mesh, f)
+		bvh.overlapping(lo, hi, func(other int32) {
+			if other <= f || facesShareVertex(mesh, f, other) {
+				return
+			}
+			if trianglesIntersect(mesh, f, other) {
+				pairs = append(pairs, [2]int32{f, other})
+			}
+		})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0] || (pairs[i][0] == pairs[j][0] && pairs[i][1] < pairs[j][1])
+	})
+	logInfo("FindSelfIntersections: found %d intersecting face pairs in mesh with %d faces.", len(pairs), NumFaces(mesh))
+	return pairs, nil
+}
@@ -0,0 +1,83 @@
+package neuro
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindSelfIntersectionsCrossingTriangles(t *testing.T) {
+	// Triangle 0 lies in the z=0 plane, triangle 1 pierces it vertically, triangle 2 is far away.
+	mesh := Mesh{
+		Vertices: []float32{
+			0, 0, 0, 2, 0, 0, 0, 2, 0,
+			0.5, 0.5, -1, 0.5, 0.5, 1, 1.5, 0.5, 0,
+			10, 10, 10, 11, 10, 10, 10, 11, 10,
+		},
+		Faces: []int32{0, 1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	pairs, err := FindSelfIntersections(mesh)
+	if err != nil {
+		t.Fatalf("FindSelfIntersections failed: %v", err)
+	}
+	if diff := cmp.Diff([][2]int32{{0, 1}}, pairs); diff != "" {
+		t.Error(diff)
+	}
+
+	// Moving the second triangle above the first one removes the intersection.
+	for i := 9; i < 18; i += 3 {
+		mesh.Vertices[i+2] += 5
+	}
+	pairs, _ = FindSelfIntersections(mesh)
+	if len(pairs) != 0 {
+		t.Errorf("got intersections %v for separated triangles, wanted none", pairs)
+	}
+}
+
+func TestFindSelfIntersectionsClosedMeshes(t *testing.T) {
+	// GenerateSphere duplicates the vertices at the poles and the seam, so weld it to get a closed mesh.
+	sphere, _, err := FromTriangleSoup(ToTriangleSoup(GenerateSphere(1, 16, 16)), 1e-5)
+	if err != nil {
+		t.Fatalf("FromTriangleSoup failed: %v", err)
+	}
+	for name, mesh := range map[string]Mesh{"cube": GenerateCube(), "sphere": sphere} {
+		pairs, err := FindSelfIntersections(mesh)
+		if err != nil {
+			t.Fatalf("FindSelfIntersections failed for %s: %v", name, err)
+		}
+		if len(pairs) != 0 {
+			t.Errorf("got intersections %v for %s, wanted none", pairs, name)
+		}
+	}
+
+	// Pushing a corner of the cube through the opposite side makes it self-intersect.
+	cube := GenerateCube()
+	copy(cube.Vertices[0:3], []float32{-2, 0.2, 0.3})
+	pairs, _ := FindSelfIntersections(cube)
+	if len(pairs) == 0 {
+		t.Errorf("got no intersections for cube with a corner pushed through, wanted some")
+	}
+}
+
+func TestFindSelfIntersectionsBrainMesh(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping self-intersection test on full brain mesh in short mode")
+	}
+	mesh, err := ReadFsSurface("testdata/lh.white")
+	if err != nil {
+		t.Fatalf("ReadFsSurface failed: %v", err)
+	}
+	pairs, err := FindSelfIntersections(mesh)
+	if err != nil {
+		t.Fatalf("FindSelfIntersections failed: %v", err)
+	}
+	if len(pairs) != 0 {
+		t.Errorf("got %d intersecting face pairs for recon-all white surface, wanted none, e.g., %v", len(pairs), pairs[0])
+	}
+}
+
+func TestFindSelfIntersectionsInvalidMesh(t *testing.T) {
+	if _, err := FindSelfIntersections(Mesh{Vertices: []float32{0, 0, 0}, Faces: []int32{0, 1, 2}}); err == nil {
+		t.Errorf("got no error for invalid face indices, wanted one")
+	}
+}
@@ -0,0 +1,114 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+)
+
+// CartesianToSpherical converts the vertex coordinates of a sphere surface, like the recon-all output files '<subject>/surf/lh.sphere'
+// and '<subject>/surf/lh.sphere.reg', to spherical coordinates.
+//
+// The angles follow the physics convention: theta is the polar angle, measured from the positive z axis, and phi is the azimuth,
+// measured in the xy plane from the positive x axis towards the positive y axis. The sphere is assumed to be centered at the origin,
+// which is the case for FreeSurfer sphere surfaces.
+//
+// Parameters:
+//   - mesh : the sphere mesh
+//
+// Returns:
+//   - []float32 : the polar angle theta of each vertex, in radians in range [0, pi]. 0 for a vertex at the origin.
+//   - []float32 : the azimuth phi of each vertex, in radians in range [-pi, pi]
+//   - []float32 : the distance of each vertex from the origin, about 100 for FreeSurfer sphere surfaces
+func CartesianToSpherical(mesh Mesh) ([]float32, []float32, []float32) {
+	nv := NumVertices(mesh)
+	theta := make([]float32, nv)
+	phi := make([]float32, nv)
+	radius := make([]float32, nv)
+	for i := 0; i < nv; i++ {
+		x, y, z := float64(mesh.Vertices[i*3]), float64(mesh.Vertices[i*3+1]), float64(mesh.Vertices[i*3+2])
+		r := math.Sqrt(x*x + y*y + z*z)
+		if r > 0 {
+			theta[i] = float32(math.Acos(math.Max(-1, math.Min(1, z/r))))
+		}
+		phi[i] = float32(math.Atan2(y, x))
+		radius[i] = float32(r)
+	}
+	return theta, phi, radius
+}
+
+// SphericalToCartesian converts spherical coordinates on a sphere centered at the origin to Cartesian coordinates, see CartesianToSpherical for the conventions.
+//
+// Parameters:
+//   - theta : the polar angle of each point, in radians
+//   - phi : the azimuth of each point, in radians
+//   - radius : the radius of the sphere, e.g., 100 for FreeSurfer sphere surfaces
+//
+// Returns:
+//   - []float32 : the coordinates, as a flat array [x1, y1, z1, x2, ...] like Mesh.Vertices
+//   - error : an error if theta and phi have different lengths
+func SphericalToCartesian(theta []float32, phi []float32, radius float32) ([]float32, error) {
+	if len(theta) != len(phi) {
+		return nil, fmt.Errorf("SphericalToCartesian: got %d theta values but %d phi values, lengths must match", len(theta), len(phi))
+	}
+	coords := make([]float32, len(theta)*3)
+	for i := range theta {
+		sinTheta, cosTheta := math.Sincos(float64(theta[i]))
+		sinPhi, cosPhi := math.Sincos(float64(phi[i]))
+		coords[i*3] = radius * float32(sinTheta*cosPhi)
+		coords[i*3+1] = radius * float32(sinTheta*sinPhi)
+		coords[i*3+2] = radius * float32(cosTheta)
+	}
+	return coords, nil
+}
+
+// GreatCircleDistance computes the distance between two points on a sphere along the great circle through them, i.e., the shortest path on the sphere.
+//
+// The Vincenty formula is used, which is accurate for all distances, including very small ones and nearly antipodal points.
+//
+// Parameters:
+//   - theta1 : the polar angle of the first point, in radians, see CartesianToSpherical
+//   - phi1 : the azimuth of the first point, in radians
+//   - theta2 : the polar angle of the second point, in radians
+//   - phi2 : the azimuth of the second point, in radians
+//   - radius : the radius of the sphere
+//
+// Returns:
+//   - float64 : the distance, in the unit of the radius
+func GreatCircleDistance(theta1 float64, phi1 float64, theta2 float64, phi2 float64, radius float64) float64 {
+	// The formula uses latitudes, which are pi/2 - theta.
+	sinLat1, cosLat1 := math.Cos(theta1), math.Sin(theta1)
+	sinLat2, cosLat2 := math.Cos(theta2), math.Sin(theta2)
+	sinDPhi, cosDPhi := math.Sincos(phi2 - phi1)
+	a := cosLat2 * sinDPhi
+	b := cosLat1*sinLat2 - sinLat1*cosLat2*cosDPhi
+	return radius * math.Atan2(math.Sqrt(a*a+b*b), sinLat1*sinLat2+cosLat1*cosLat2*cosDPhi)
+}
+
+// VertexGreatCircleDistance computes the great circle distance between two vertices of a sphere surface, like '<subject>/surf/lh.sphere'.
+//
+// The vertices of FreeSurfer sphere surfaces are not exactly at the same distance from the origin, so the mean distance of the two vertices is used as the radius.
+//
+// Parameters:
+//   - mesh : the sphere mesh, centered at the origin
+//   - a : the index of the first vertex
+//   - b : the index of the second vertex
+//
+// Returns:
+//   - float32 : the distance, in the unit of the vertex coordinates
+//   - error : an error if a vertex index is out of range
+func VertexGreatCircleDistance(mesh Mesh, a int32, b int32) (float32, error) {
+	if err := checkVertexIndex(mesh, a); err != nil {
+		return 0, fmt.Errorf("VertexGreatCircleDistance: %s", err)
+	}
+	if err := checkVertexIndex(mesh, b); err != nil {
+		return 0, fmt.Errorf("VertexGreatCircleDistance: %s", err)
+	}
+	var p, q [3]float64
+	for k := 0; k < 3; k++ {
+		p[k], q[k] = float64(mesh.Vertices[a*3+int32(k)]), float64(mesh.Vertices[b*3+int32(k)])
+	}
+	cx, cy, cz := p[1]*q[2]-p[2]*q[1], p[2]*q[0]-p[0]*q[2], p[0]*q[1]-p[1]*q[0]
+	angle := math.Atan2(math.Sqrt(cx*cx+cy*cy+cz*cz), p[0]*q[0]+p[1]*q[1]+p[2]*q[2])
+	radius := (math.Sqrt(p[0]*p[0]+p[1]*p[1]+p[2]*p[2]) + math.Sqrt(q[0]*q[0]+q[1]*q[1]+q[2]*q[2])) / 2
+	return float32(angle * radius), nil
+}
@@ -0,0 +1,86 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestSphericalCoordinatesRoundTrip(t *testing.T) {
+	sphere := GenerateSphere(100, 12, 8)
+	theta, phi, radius := CartesianToSpherical(sphere)
+	for i, r := range radius {
+		if math.Abs(float64(r)-100) > 1e-3 {
+			t.Fatalf("got radius %f for vertex %d, want 100", r, i)
+		}
+		if theta[i] < 0 || theta[i] > math.Pi || phi[i] < -math.Pi || phi[i] > math.Pi {
+			t.Fatalf("got angles (%f, %f) out of range for vertex %d", theta[i], phi[i], i)
+		}
+	}
+
+	coords, err := SphericalToCartesian(theta, phi, 100)
+	if err != nil {
+		t.Fatalf("SphericalToCartesian failed: %v", err)
+	}
+	for i := range coords {
+		if math.Abs(float64(coords[i]-sphere.Vertices[i])) > 1e-3 {
+			t.Fatalf("got coordinate %f at index %d, want %f", coords[i], i, sphere.Vertices[i])
+		}
+	}
+
+	if _, err := SphericalToCartesian(theta, phi[1:], 100); err == nil {
+		t.Errorf("got no error for different numbers of theta and phi values, wanted one")
+	}
+}
+
+func TestCartesianToSphericalAxes(t *testing.T) {
+	mesh := Mesh{Vertices: []float32{0, 0, 2, 0, 3, 0, -1, 0, 0, 0, 0, 0}}
+	theta, phi, radius := CartesianToSpherical(mesh)
+	want := [][3]float64{{0, 0, 2}, {math.Pi / 2, math.Pi / 2, 3}, {math.Pi / 2, math.Pi, 1}, {0, 0, 0}}
+	for i, w := range want {
+		if math.Abs(float64(theta[i])-w[0]) > 1e-6 || math.Abs(float64(phi[i])-w[1]) > 1e-6 || math.Abs(float64(radius[i])-w[2]) > 1e-6 {
+			t.Errorf("got (theta, phi, r) = (%f, %f, %f) for vertex %d, want %v", theta[i], phi[i], radius[i], i, w)
+		}
+	}
+}
+
+func TestGreatCircleDistance(t *testing.T) {
+	tests := []struct {
+		theta1, phi1, theta2, phi2, want float64
+	}{
+		{0, 0, math.Pi, 0, math.Pi},                             // pole to pole
+		{math.Pi / 2, 0, math.Pi / 2, math.Pi / 2, math.Pi / 2}, // along the equator
+		{0, 0, math.Pi / 2, 1.234, math.Pi / 2},                 // the azimuth of the pole does not matter
+		{1, 2, 1, 2, 0},
+		{math.Pi / 2, -math.Pi, math.Pi / 2, math.Pi, 0}, // phi -pi and pi are the same
+	}
+	for _, tc := range tests {
+		if got := GreatCircleDistance(tc.theta1, tc.phi1, tc.theta2, tc.phi2, 1); math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("got distance %f between (%f, %f) and (%f, %f), want %f", got, tc.theta1, tc.phi1, tc.theta2, tc.phi2, tc.want)
+		}
+	}
+}
+
+func TestVertexGreatCircleDistance(t *testing.T) {
+	mesh := Mesh{Vertices: []float32{100, 0, 0, 0, 100, 0, 0, 0, -100}}
+	d, err := VertexGreatCircleDistance(mesh, 0, 1)
+	if err != nil {
+		t.Fatalf("VertexGreatCircleDistance failed: %v", err)
+	}
+	if math.Abs(float64(d)-50*math.Pi) > 1e-3 {
+		t.Errorf("got distance %f, want %f", d, 50*math.Pi)
+	}
+	theta, phi, _ := CartesianToSpherical(mesh)
+	if want := GreatCircleDistance(float64(theta[1]), float64(phi[1]), float64(theta[2]), float64(phi[2]), 100); math.Abs(want-50*math.Pi) > 1e-3 {
+		t.Errorf("got GreatCircleDistance %f, want %f", want, 50*math.Pi)
+	}
+	if _, err := VertexGreatCircleDistance(mesh, 0, 3); err == nil {
+		t.Errorf("got no error for out of range vertex index, wanted one")
+	}
+}
+
+func ExampleGreatCircleDistance() {
+	// The distance between the north pole and a point on the equator of a FreeSurfer sphere with radius 100 mm.
+	fmt.Printf("%.2f mm\n", GreatCircleDistance(0, 0, math.Pi/2, 0, 100))
+	// Output: 157.08 mm
+}
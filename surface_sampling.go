@@ -0,0 +1,65 @@
+package neuro
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// SampleSurfacePoints draws points uniformly at random from the surface of a mesh, i.e., the number of points in a region is proportional to its area.
+//
+// Each point is sampled by choosing a face with probability proportional to its area, and then a uniformly distributed
+// position within the face using barycentric coordinates. This is the basis for point cloud based distance metrics
+// between meshes, like the Chamfer or Hausdorff distance, and for Monte Carlo estimates of surface integrals.
+//
+// Parameters:
+//   - m : the mesh
+//   - n : the number of points to sample
+//   - seed : the seed of the random number generator. The same seed gives the same points for the same mesh.
+//
+// Returns:
+//   - []float32 : the coordinates of the points, as a flat array [x1, y1, z1, x2, ...] like Mesh.Vertices
+//   - []int32 : the index of the face each point lies on
+//   - error : an error if n is negative, the mesh has invalid face indices, or its area is 0
+func SampleSurfacePoints(m Mesh, n int, seed int64) ([]float32, []int32, error) {
+	if n < 0 {
+		return nil, nil, fmt.Errorf("SampleSurfacePoints: number of points must not be negative, got %d", n)
+	}
+	if err := validateFaceIndices(m); err != nil {
+		return nil, nil, fmt.Errorf("SampleSurfacePoints: %s", err)
+	}
+
+	// The cumulative face areas, for drawing faces by area with a binary search.
+	numFaces := NumFaces(m)
+	cumArea := make([]float64, numFaces)
+	var total float64
+	for f := 0; f < numFaces; f++ {
+		total += faceArea64(m, f)
+		cumArea[f] = total
+	}
+	if total == 0 {
+		return nil, nil, fmt.Errorf("SampleSurfacePoints: mesh has no faces with non-zero area")
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	points := make([]float32, n*3)
+	faces := make([]int32, n)
+	for i := 0; i < n; i++ {
+		f := sort.SearchFloat64s(cumArea, rng.Float64()*total)
+		if f == numFaces {
+			f = numFaces - 1 // only possible through rounding
+		}
+		// Reflecting points outside the triangle back into it keeps the distribution uniform.
+		u, v := rng.Float64(), rng.Float64()
+		if u+v > 1 {
+			u, v = 1-u, 1-v
+		}
+		a, b, c := m.Faces[f*3]*3, m.Faces[f*3+1]*3, m.Faces[f*3+2]*3
+		for k := int32(0); k < 3; k++ {
+			pa, pb, pc := float64(m.Vertices[a+k]), float64(m.Vertices[b+k]), float64(m.Vertices[c+k])
+			points[i*3+int(k)] = float32(pa + u*(pb-pa) + v*(pc-pa))
+		}
+		faces[i] = int32(f)
+	}
+	return points, faces, nil
+}
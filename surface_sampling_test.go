@@ -0,0 +1,73 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSampleSurfacePointsByArea(t *testing.T) {
+	// Two faces in the z=0 plane: face 0 has area 0.5, face 1 has area 1.5.
+	mesh := Mesh{Vertices: []float32{0, 0, 0, 1, 0, 0, 0, 1, 0, 4, 0, 0, 4, 1, 0}, Faces: []int32{0, 1, 2, 1, 3, 4}}
+	n := 20000
+	points, faces, err := SampleSurfacePoints(mesh, n, 42)
+	if err != nil {
+		t.Fatalf("SampleSurfacePoints failed: %v", err)
+	}
+	if len(points) != n*3 || len(faces) != n {
+		t.Fatalf("got %d coordinates and %d faces, want %d and %d", len(points), len(faces), n*3, n)
+	}
+	numOnFace0 := 0
+	for i, f := range faces {
+		x, y, z := points[i*3], points[i*3+1], points[i*3+2]
+		if z != 0 || x < 0 || x > 4 || y < 0 || y > 1 {
+			t.Fatalf("got point (%f, %f, %f) outside of the mesh", x, y, z)
+		}
+		if f == 0 {
+			numOnFace0++
+			if x+y > 1+1e-6 {
+				t.Fatalf("got point (%f, %f) outside of face 0", x, y)
+			}
+		}
+	}
+	if frac := float64(numOnFace0) / float64(n); math.Abs(frac-0.25) > 0.02 {
+		t.Errorf("got fraction %f of points on face 0, want 0.25 (its fraction of the total area)", frac)
+	}
+}
+
+func TestSampleSurfacePointsSeed(t *testing.T) {
+	mesh := GenerateSphere(1, 8, 8)
+	p1, f1, _ := SampleSurfacePoints(mesh, 100, 7)
+	p2, f2, _ := SampleSurfacePoints(mesh, 100, 7)
+	if diff := cmp.Diff(p1, p2); diff != "" {
+		t.Errorf("got different points for the same seed: %s", diff)
+	}
+	if diff := cmp.Diff(f1, f2); diff != "" {
+		t.Errorf("got different faces for the same seed: %s", diff)
+	}
+	p3, _, _ := SampleSurfacePoints(mesh, 100, 8)
+	if cmp.Equal(p1, p3) {
+		t.Errorf("got the same points for different seeds")
+	}
+}
+
+func TestSampleSurfacePointsErrors(t *testing.T) {
+	if _, _, err := SampleSurfacePoints(GenerateCube(), -1, 0); err == nil {
+		t.Errorf("got no error for negative number of points, wanted one")
+	}
+	if _, _, err := SampleSurfacePoints(Mesh{Vertices: []float32{0, 0, 0}}, 10, 0); err == nil {
+		t.Errorf("got no error for mesh without faces, wanted one")
+	}
+	if _, _, err := SampleSurfacePoints(Mesh{Vertices: []float32{0, 0, 0}, Faces: []int32{0, 1, 2}}, 10, 0); err == nil {
+		t.Errorf("got no error for invalid face indices, wanted one")
+	}
+}
+
+func ExampleSampleSurfacePoints() {
+	mesh, _ := ReadFsSurface("testdata/lh.white")
+	points, faces, _ := SampleSurfacePoints(mesh, 10000, 1)
+	fmt.Printf("Sampled %d points on %d faces.\n", len(points)/3, len(faces))
+	// Output: Sampled 10000 points on 10000 faces.
+}
@@ -0,0 +1,50 @@
+"""Writes the Parquet reference file used by TestToParquetFormatPyarrowReference in write_parquet_test.go.
+
+The file contains the same table as table_neurogo.parquet, the golden output of neuro.ToParquetFormat, written by pyarrow with the
+options that match the neurogo writer: required columns, PLAIN encoding without dictionaries, no compression and version 1 data pages.
+
+Usage:
+    python3 pyarrow_reference.py               # writes table_pyarrow.parquet next to this script
+    python3 pyarrow_reference.py FILE [FILE..] # reads Parquet files with pyarrow and compares them to the table, e.g. table_neurogo.parquet
+"""
+import os
+import sys
+
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+
+def reference_table():
+    schema = pa.schema([
+        pa.field("region", pa.string(), nullable=False),
+        pa.field("area", pa.float32(), nullable=False),
+        pa.field("thickness", pa.float64(), nullable=False),
+        pa.field("numVertices", pa.int32(), nullable=False),
+        pa.field("cluster", pa.int64(), nullable=False),
+        pa.field("significant", pa.bool_(), nullable=False),
+    ])
+    return pa.table({
+        "region": ["bankssts", "cuneus", "insula"],
+        "area": [1.5, 2.5, 3.5],
+        "thickness": [2.25, 2.5, 2.75],
+        "numVertices": [10, 20, 30],
+        "cluster": [1, 2, 1 << 40],
+        "significant": [True, False, True],
+    }, schema=schema)
+
+
+def main():
+    if len(sys.argv) == 1:
+        path = os.path.join(os.path.dirname(os.path.abspath(__file__)), "table_pyarrow.parquet")
+        pq.write_table(reference_table(), path, compression="NONE", use_dictionary=False, write_statistics=False, data_page_version="1.0")
+        print("Wrote %s with pyarrow %s." % (path, pa.__version__))
+        return
+    for path in sys.argv[1:]:
+        table = pq.read_table(path)
+        print("%s: %d rows, schema %s" % (path, table.num_rows, table.schema))
+        if not table.equals(reference_table()):
+            sys.exit("%s: table differs from the reference table" % path)
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,136 @@
+package tract
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReadTck reads a file in MRtrix tracks format, typically with extension '.tck'.
+//
+// The points in MRtrix track files are stored in scanner RAS coordinates in mm, so no conversion is needed.
+// Track files have no per-point scalars, MRtrix stores those in separate '.tsf' files.
+//
+// Parameters:
+//   - path : the path to the file
+//
+// Returns:
+//   - Streamlines : the streamlines
+//   - map[string]string : the key-value pairs of the text header, e.g., 'count' or 'step_size'. The values of keys that occur several times, like 'command_history', are joined with newlines.
+//   - error : an error if one occurred
+func ReadTck(path string) (Streamlines, map[string]string, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return Streamlines{}, nil, fmt.Errorf("ReadTck: could not read file '%s': %s", path, err)
+	}
+	s, hdr, err := readTckFromBytes(bs)
+	if err != nil {
+		return s, hdr, fmt.Errorf("ReadTck: failed to parse MRtrix tracks file '%s': %s", path, err)
+	}
+	return s, hdr, nil
+}
+
+// ReadTckFromReader reads streamlines in MRtrix tracks format from a reader, see ReadTck.
+//
+// Parameters:
+//   - r : the reader providing the file contents
+//
+// Returns:
+//   - Streamlines : the streamlines
+//   - map[string]string : the key-value pairs of the text header
+//   - error : an error if one occurred
+func ReadTckFromReader(r io.Reader) (Streamlines, map[string]string, error) {
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return Streamlines{}, nil, fmt.Errorf("ReadTckFromReader: could not read data: %s", err)
+	}
+	s, hdr, err := readTckFromBytes(bs)
+	if err != nil {
+		return s, hdr, fmt.Errorf("ReadTckFromReader: %s", err)
+	}
+	return s, hdr, nil
+}
+
+// readTckHeader parses the text header of an MRtrix tracks file, which ends with a line 'END'.
+func readTckHeader(bs []byte) (map[string]string, error) {
+	end := bytes.Index(bs, []byte("\nEND\n"))
+	if !bytes.HasPrefix(bs, []byte("mrtrix tracks\n")) || end < 0 {
+		return nil, fmt.Errorf("this is not an MRtrix tracks file, it must start with the line 'mrtrix tracks' and contain a line 'END'")
+	}
+	hdr := map[string]string{}
+	for _, line := range strings.Split(string(bs[len("mrtrix tracks\n"):end]), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if prev, ok := hdr[key]; ok {
+			value = prev + "\n" + value
+		}
+		hdr[key] = value
+	}
+	return hdr, nil
+}
+
+// readTckFromBytes parses the contents of an MRtrix tracks file.
+//
+// The data consists of point triplets, a triplet of NaN values ends a streamline and a triplet of Inf values ends the data.
+func readTckFromBytes(bs []byte) (Streamlines, map[string]string, error) {
+	var s Streamlines
+	hdr, err := readTckHeader(bs)
+	if err != nil {
+		return s, hdr, err
+	}
+
+	fields := strings.Fields(hdr["file"])
+	if len(fields) != 2 || fields[0] != "." {
+		return s, hdr, fmt.Errorf("invalid 'file' entry '%s' in header, only data in the same file ('. <offset>') is supported", hdr["file"])
+	}
+	offset, err := strconv.Atoi(fields[1])
+	if err != nil || offset < 0 || offset > len(bs) {
+		return s, hdr, fmt.Errorf("invalid data offset '%s' in header for file with %d bytes", fields[1], len(bs))
+	}
+
+	var endian binary.ByteOrder
+	var valueSize int
+	switch hdr["datatype"] {
+	case "Float32LE":
+		endian, valueSize = binary.LittleEndian, 4
+	case "Float32BE":
+		endian, valueSize = binary.BigEndian, 4
+	case "Float64LE":
+		endian, valueSize = binary.LittleEndian, 8
+	case "Float64BE":
+		endian, valueSize = binary.BigEndian, 8
+	default:
+		return s, hdr, fmt.Errorf("unsupported datatype '%s' in header, must be one of Float32LE, Float32BE, Float64LE, Float64BE", hdr["datatype"])
+	}
+
+	data := bs[offset:]
+	value := func(i int) float64 {
+		if valueSize == 4 {
+			return float64(math.Float32frombits(endian.Uint32(data[i*4:])))
+		}
+		return math.Float64frombits(endian.Uint64(data[i*8:]))
+	}
+	var points []float32
+	for i := 0; (i+3)*valueSize <= len(data); i += 3 {
+		x, y, z := value(i), value(i+1), value(i+2)
+		if math.IsInf(x, 0) {
+			return s, hdr, nil
+		}
+		if math.IsNaN(x) {
+			s.Points = append(s.Points, points)
+			points = nil
+			continue
+		}
+		points = append(points, float32(x), float32(y), float32(z))
+	}
+	// The end marker is missing, e.g., because the file is still being written by MRtrix. Only complete streamlines are returned.
+	return s, hdr, nil
+}
@@ -0,0 +1,74 @@
+package tract
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testTckBytes creates the contents of an MRtrix tracks file with 2 streamlines, with float32 values in the given byte order.
+// If withEndMarker is false, the final Inf triplet is missing and the second streamline is not terminated, like in a file that is still being written.
+func testTckBytes(endian binary.ByteOrder, withEndMarker bool) []byte {
+	datatype := "Float32LE"
+	if endian == binary.BigEndian {
+		datatype = "Float32BE"
+	}
+	const offset = 200
+	header := fmt.Sprintf("mrtrix tracks\ndatatype: %s\ncount: 2\ncommand_history: tckgen a b\ncommand_history: tckedit b c\nfile: . %d\nEND\n", datatype, offset)
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	buf.Write(make([]byte, offset-len(header)))
+	nan, inf := float32(math.NaN()), float32(math.Inf(1))
+	binary.Write(&buf, endian, []float32{1, 2, 3, 4, 5, 6, nan, nan, nan, -1, -2, -3})
+	if withEndMarker {
+		binary.Write(&buf, endian, []float32{nan, nan, nan, inf, inf, inf})
+	}
+	return buf.Bytes()
+}
+
+func TestReadTckFromReader(t *testing.T) {
+	for _, endian := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		s, hdr, err := ReadTckFromReader(bytes.NewReader(testTckBytes(endian, true)))
+		if err != nil {
+			t.Fatalf("ReadTckFromReader failed for %s file: %v", endian, err)
+		}
+		if diff := cmp.Diff(Streamlines{Points: [][]float32{{1, 2, 3, 4, 5, 6}, {-1, -2, -3}}}, s); diff != "" {
+			t.Errorf("%s file mismatch (-want +got):\n%s", endian, diff)
+		}
+		if hdr["count"] != "2" || hdr["command_history"] != "tckgen a b\ntckedit b c" {
+			t.Errorf("got unexpected header %v", hdr)
+		}
+	}
+
+	// Without the end marker, only the terminated streamlines are returned.
+	s, _, err := ReadTckFromReader(bytes.NewReader(testTckBytes(binary.LittleEndian, false)))
+	if err != nil {
+		t.Fatalf("ReadTckFromReader failed for file without end marker: %v", err)
+	}
+	if NumStreamlines(s) != 1 {
+		t.Errorf("got %d streamlines for file without end marker, want 1", NumStreamlines(s))
+	}
+}
+
+func TestReadTckInvalid(t *testing.T) {
+	bs := testTckBytes(binary.LittleEndian, true)
+	tests := map[string][]byte{
+		"missing magic line":  bs[6:],
+		"unsupported type":    []byte(strings.Replace(string(bs), "Float32LE", "Int32LE", 1)),
+		"invalid file offset": []byte(strings.Replace(string(bs), "file: . 200", "file: . 9999", 1)),
+		"external data file":  []byte(strings.Replace(string(bs), "file: . 200", "file: x 200", 1)),
+	}
+	for name, data := range tests {
+		if _, _, err := ReadTckFromReader(bytes.NewReader(data)); err == nil {
+			t.Errorf("got no error for %s, wanted one", name)
+		}
+	}
+	if _, _, err := ReadTck("testdata/does_not_exist.tck"); err == nil {
+		t.Errorf("got no error for missing file, wanted one")
+	}
+}
@@ -0,0 +1,258 @@
+package tract
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// trkHeaderSize is the size of the header of a TrackVis file in bytes.
+const trkHeaderSize = 1000
+
+// trkHeaderRaw is the binary layout of the header of a TrackVis file, see http://trackvis.org/docs/?subsect=fileformat.
+type trkHeaderRaw struct {
+	IDString                [6]byte
+	Dim                     [3]int16
+	VoxelSize               [3]float32
+	Origin                  [3]float32
+	NumScalars              int16
+	ScalarName              [10][20]byte
+	NumProperties           int16
+	PropertyName            [10][20]byte
+	VoxToRas                [4][4]float32
+	Reserved                [444]byte
+	VoxelOrder              [4]byte
+	Pad2                    [4]byte
+	ImageOrientationPatient [6]float32
+	Pad1                    [2]byte
+	InvertX                 uint8
+	InvertY                 uint8
+	InvertZ                 uint8
+	SwapXY                  uint8
+	SwapYZ                  uint8
+	SwapZX                  uint8
+	NumCount                int32
+	Version                 int32
+	HdrSize                 int32
+}
+
+// TrkHeader holds the header information of a TrackVis file that describes the image the streamlines were computed from.
+type TrkHeader struct {
+	Dim        [3]int16    // The dimensions of the image volume, in voxels.
+	VoxelSize  [3]float32  // The voxel size of the image volume, in mm.
+	VoxToRas   [16]float32 // The 4x4 matrix in row-major order that transforms voxel indices to RAS coordinates. All zeros if not set, e.g., in version 1 files.
+	VoxelOrder string      // The orientation of the voxel axes, e.g., 'LAS'. Empty if not set.
+	NumCount   int32       // The number of streamlines as declared in the header, 0 if not stored.
+	Version    int32       // The file format version, 1 or 2.
+}
+
+// ReadTrk reads a file in TrackVis format, typically with extension '.trk'.
+//
+// TrackVis stores the points in 'voxmm' space, i.e., voxel coordinates scaled by the voxel size with the origin at the corner of the first voxel.
+// If the header contains a vox_to_ras matrix (file format version 2), the points are converted to RAS coordinates, taking the voxel order into
+// account like nibabel does. Otherwise, e.g., for version 1 files, they are returned in voxmm space.
+//
+// Parameters:
+//   - path : the path to the file
+//
+// Returns:
+//   - Streamlines : the streamlines, with per-point scalars and per-streamline properties if the file contains them
+//   - TrkHeader : the header information
+//   - error : an error if one occurred
+func ReadTrk(path string) (Streamlines, TrkHeader, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return Streamlines{}, TrkHeader{}, fmt.Errorf("ReadTrk: could not read file '%s': %s", path, err)
+	}
+	s, hdr, err := readTrkFromBytes(bs)
+	if err != nil {
+		return s, hdr, fmt.Errorf("ReadTrk: failed to parse TrackVis file '%s': %s", path, err)
+	}
+	return s, hdr, nil
+}
+
+// ReadTrkFromReader reads streamlines in TrackVis format from a reader, see ReadTrk.
+//
+// Parameters:
+//   - r : the reader providing the file contents
+//
+// Returns:
+//   - Streamlines : the streamlines
+//   - TrkHeader : the header information
+//   - error : an error if one occurred
+func ReadTrkFromReader(r io.Reader) (Streamlines, TrkHeader, error) {
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return Streamlines{}, TrkHeader{}, fmt.Errorf("ReadTrkFromReader: could not read data: %s", err)
+	}
+	s, hdr, err := readTrkFromBytes(bs)
+	if err != nil {
+		return s, hdr, fmt.Errorf("ReadTrkFromReader: %s", err)
+	}
+	return s, hdr, nil
+}
+
+// trkName converts a NUL-padded name from a TrackVis header to a string.
+func trkName(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// readTrkFromBytes parses the contents of a TrackVis file.
+func readTrkFromBytes(bs []byte) (Streamlines, TrkHeader, error) {
+	var s Streamlines
+	var hdr TrkHeader
+	if len(bs) < trkHeaderSize {
+		return s, hdr, fmt.Errorf("data too short for TrackVis header, got %d bytes, need %d", len(bs), trkHeaderSize)
+	}
+	if string(bs[:5]) != "TRACK" {
+		return s, hdr, fmt.Errorf("this is not a TrackVis file, it does not start with 'TRACK'")
+	}
+
+	// TrackVis writes little endian files, the header size allows detecting byte-swapped files.
+	var endian binary.ByteOrder = binary.LittleEndian
+	if binary.LittleEndian.Uint32(bs[996:1000]) != trkHeaderSize {
+		if binary.BigEndian.Uint32(bs[996:1000]) != trkHeaderSize {
+			return s, hdr, fmt.Errorf("invalid header size in TrackVis header, expected %d in either byte order", trkHeaderSize)
+		}
+		endian = binary.BigEndian
+	}
+	r := bytes.NewReader(bs)
+	var raw trkHeaderRaw
+	if err := binary.Read(r, endian, &raw); err != nil {
+		return s, hdr, fmt.Errorf("could not read TrackVis header: %s", err)
+	}
+	if raw.NumScalars < 0 || raw.NumScalars > 10 || raw.NumProperties < 0 || raw.NumProperties > 10 {
+		return s, hdr, fmt.Errorf("invalid number of scalars (%d) or properties (%d) in TrackVis header, must be in range 0 to 10", raw.NumScalars, raw.NumProperties)
+	}
+
+	hdr = TrkHeader{Dim: raw.Dim, VoxelSize: raw.VoxelSize, VoxelOrder: trkName(raw.VoxelOrder[:3]), NumCount: raw.NumCount, Version: raw.Version}
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			hdr.VoxToRas[row*4+col] = raw.VoxToRas[row][col]
+		}
+	}
+	for i := 0; i < int(raw.NumScalars); i++ {
+		s.ScalarNames = append(s.ScalarNames, trkName(raw.ScalarName[i][:]))
+	}
+	for i := 0; i < int(raw.NumProperties); i++ {
+		s.PropertyNames = append(s.PropertyNames, trkName(raw.PropertyName[i][:]))
+	}
+
+	toRas, err := trkVoxmmToRas(hdr)
+	if err != nil {
+		return s, hdr, err
+	}
+
+	numScalars, numProperties := int(raw.NumScalars), int(raw.NumProperties)
+	for r.Len() > 0 && (hdr.NumCount == 0 || int32(len(s.Points)) < hdr.NumCount) {
+		var numPoints int32
+		if err := binary.Read(r, endian, &numPoints); err != nil {
+			return s, hdr, fmt.Errorf("could not read number of points of streamline %d: %s", len(s.Points), err)
+		}
+		numValues := int64(numPoints)*int64(3+numScalars) + int64(numProperties)
+		if numPoints < 0 || numValues*4 > int64(r.Len()) {
+			return s, hdr, fmt.Errorf("streamline %d declares %d points, which requires %d bytes of data, but only %d bytes are left", len(s.Points), numPoints, numValues*4, r.Len())
+		}
+		values := make([]float32, numValues)
+		if err := binary.Read(r, endian, values); err != nil {
+			return s, hdr, fmt.Errorf("could not read streamline %d: %s", len(s.Points), err)
+		}
+		points := make([]float32, numPoints*3)
+		var scalars []float32
+		if numScalars > 0 {
+			scalars = make([]float32, int(numPoints)*numScalars)
+		}
+		for p := 0; p < int(numPoints); p++ {
+			v := values[p*(3+numScalars):]
+			copy(points[p*3:p*3+3], toRas(v[0], v[1], v[2]))
+			copy(scalars[p*numScalars:(p+1)*numScalars], v[3:3+numScalars])
+		}
+		s.Points = append(s.Points, points)
+		if numScalars > 0 {
+			s.Scalars = append(s.Scalars, scalars)
+		}
+		if numProperties > 0 {
+			s.Properties = append(s.Properties, values[int(numPoints)*(3+numScalars):])
+		}
+	}
+	if hdr.NumCount != 0 && int32(len(s.Points)) != hdr.NumCount {
+		return s, hdr, fmt.Errorf("TrackVis header declares %d streamlines, but the file contains %d", hdr.NumCount, len(s.Points))
+	}
+	return s, hdr, nil
+}
+
+// trkAxisCode returns the orientation letter of a voxel axis, given the column of the vox_to_ras matrix for that axis.
+func trkAxisCode(col [3]float64) byte {
+	axis := 0
+	for k := 1; k < 3; k++ {
+		if math.Abs(col[k]) > math.Abs(col[axis]) {
+			axis = k
+		}
+	}
+	codes := [3][2]byte{{'R', 'L'}, {'A', 'P'}, {'S', 'I'}}
+	if col[axis] < 0 {
+		return codes[axis][1]
+	}
+	return codes[axis][0]
+}
+
+// trkOppositeCode returns the orientation letter of the opposite direction, e.g., 'L' for 'R'.
+func trkOppositeCode(c byte) byte {
+	opposite := map[byte]byte{'R': 'L', 'L': 'R', 'A': 'P', 'P': 'A', 'S': 'I', 'I': 'S'}
+	return opposite[c]
+}
+
+// trkVoxmmToRas returns a function that converts a point from TrackVis voxmm space to RAS space, see ReadTrk.
+//
+// If the voxel order in the header differs from the orientation of the vox_to_ras matrix, the voxel axes are flipped first,
+// like nibabel does it. If the voxel order is not set, the TrackVis default 'LPS' is assumed.
+func trkVoxmmToRas(hdr TrkHeader) (func(x, y, z float32) []float32, error) {
+	if hdr.VoxToRas[15] == 0 {
+		return func(x, y, z float32) []float32 { return []float32{x, y, z} }, nil
+	}
+	for k := 0; k < 3; k++ {
+		if hdr.VoxelSize[k] <= 0 {
+			return nil, fmt.Errorf("invalid voxel size %v in TrackVis header, must be positive", hdr.VoxelSize)
+		}
+	}
+	voxelOrder := strings.ToUpper(hdr.VoxelOrder)
+	if voxelOrder == "" {
+		voxelOrder = "LPS"
+	}
+	if len(voxelOrder) != 3 {
+		return nil, fmt.Errorf("invalid voxel order '%s' in TrackVis header", hdr.VoxelOrder)
+	}
+	var flip [3]bool
+	for j := 0; j < 3; j++ {
+		code := trkAxisCode([3]float64{float64(hdr.VoxToRas[j]), float64(hdr.VoxToRas[4+j]), float64(hdr.VoxToRas[8+j])})
+		switch voxelOrder[j] {
+		case code:
+		case trkOppositeCode(code):
+			flip[j] = true
+		default:
+			return nil, fmt.Errorf("voxel order '%s' in TrackVis header permutes the axes of the vox_to_ras matrix, which is not supported", hdr.VoxelOrder)
+		}
+	}
+	m := hdr.VoxToRas
+	return func(x, y, z float32) []float32 {
+		var vox [3]float64
+		for k, c := range [3]float32{x, y, z} {
+			vox[k] = float64(c)/float64(hdr.VoxelSize[k]) - 0.5 // TrackVis puts the origin at the corner of the first voxel, not its center.
+			if flip[k] {
+				vox[k] = float64(hdr.Dim[k]-1) - vox[k]
+			}
+		}
+		ras := make([]float32, 3)
+		for row := 0; row < 3; row++ {
+			ras[row] = float32(float64(m[row*4])*vox[0] + float64(m[row*4+1])*vox[1] + float64(m[row*4+2])*vox[2] + float64(m[row*4+3]))
+		}
+		return ras
+	}, nil
+}
@@ -0,0 +1,125 @@
+package tract
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// testTrkBytes creates the contents of a TrackVis file with 2 streamlines, 1 scalar per point and 1 property per streamline.
+//
+// The image has 10x10x10 voxels of size 2 mm, with voxel order 'LAS' matching the vox_to_ras matrix.
+func testTrkBytes(endian binary.ByteOrder, voxelOrder string) []byte {
+	var hdr trkHeaderRaw
+	copy(hdr.IDString[:], "TRACK")
+	hdr.Dim = [3]int16{10, 10, 10}
+	hdr.VoxelSize = [3]float32{2, 2, 2}
+	hdr.NumScalars = 1
+	copy(hdr.ScalarName[0][:], "FA")
+	hdr.NumProperties = 1
+	copy(hdr.PropertyName[0][:], "length")
+	hdr.VoxToRas = [4][4]float32{{-2, 0, 0, 90}, {0, 2, 0, -126}, {0, 0, 2, -72}, {0, 0, 0, 1}}
+	copy(hdr.VoxelOrder[:], voxelOrder)
+	hdr.NumCount = 2
+	hdr.Version = 2
+	hdr.HdrSize = trkHeaderSize
+
+	var buf bytes.Buffer
+	binary.Write(&buf, endian, hdr)
+	binary.Write(&buf, endian, int32(2))
+	binary.Write(&buf, endian, []float32{3, 5, 7, 0.5, 5, 5, 7, 0.6, 42})
+	binary.Write(&buf, endian, int32(1))
+	binary.Write(&buf, endian, []float32{1, 1, 1, 0.7, 1})
+	return buf.Bytes()
+}
+
+func TestReadTrkFromReader(t *testing.T) {
+	for _, endian := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		s, hdr, err := ReadTrkFromReader(bytes.NewReader(testTrkBytes(endian, "LAS")))
+		if err != nil {
+			t.Fatalf("ReadTrkFromReader failed for %s file: %v", endian, err)
+		}
+		// The voxmm point (3, 5, 7) is the center of voxel (1, 2, 3).
+		want := Streamlines{
+			Points:        [][]float32{{88, -122, -66, 86, -122, -66}, {90, -126, -72}},
+			ScalarNames:   []string{"FA"},
+			Scalars:       [][]float32{{0.5, 0.6}, {0.7}},
+			PropertyNames: []string{"length"},
+			Properties:    [][]float32{{42}, {1}},
+		}
+		if diff := cmp.Diff(want, s); diff != "" {
+			t.Errorf("%s file mismatch (-want +got):\n%s", endian, diff)
+		}
+		if hdr.VoxelOrder != "LAS" || hdr.Dim != [3]int16{10, 10, 10} || hdr.VoxToRas[3] != 90 || hdr.Version != 2 {
+			t.Errorf("got unexpected header %+v", hdr)
+		}
+		if NumStreamlines(s) != 2 || NumPoints(s) != 3 {
+			t.Errorf("got %d streamlines with %d points, want 2 and 3", NumStreamlines(s), NumPoints(s))
+		}
+	}
+}
+
+func TestReadTrkVoxelOrder(t *testing.T) {
+	// With voxel order 'RAS', the x axis of the voxel data is flipped relative to the vox_to_ras matrix.
+	s, _, err := ReadTrkFromReader(bytes.NewReader(testTrkBytes(binary.LittleEndian, "RAS")))
+	if err != nil {
+		t.Fatalf("ReadTrkFromReader failed: %v", err)
+	}
+	if diff := cmp.Diff([]float32{74, -122, -66}, s.Points[0][:3]); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, _, err := ReadTrkFromReader(bytes.NewReader(testTrkBytes(binary.LittleEndian, "ALS"))); err == nil {
+		t.Errorf("got no error for voxel order with permuted axes, wanted one")
+	}
+}
+
+func TestReadTrkWithoutVoxToRas(t *testing.T) {
+	bs := testTrkBytes(binary.LittleEndian, "LAS")
+	copy(bs[440:504], make([]byte, 64)) // clear vox_to_ras
+	s, _, err := ReadTrkFromReader(bytes.NewReader(bs))
+	if err != nil {
+		t.Fatalf("ReadTrkFromReader failed: %v", err)
+	}
+	if diff := cmp.Diff([]float32{3, 5, 7, 5, 5, 7}, s.Points[0]); diff != "" {
+		t.Errorf("got points not in voxmm space: %s", diff)
+	}
+}
+
+func TestReadTrkInvalid(t *testing.T) {
+	bs := testTrkBytes(binary.LittleEndian, "LAS")
+	if _, _, err := ReadTrkFromReader(bytes.NewReader(bs[:len(bs)-4])); err == nil {
+		t.Errorf("got no error for truncated streamline data, wanted one")
+	}
+	if _, _, err := ReadTrkFromReader(bytes.NewReader(bs[:500])); err == nil {
+		t.Errorf("got no error for truncated header, wanted one")
+	}
+	if _, _, err := ReadTrkFromReader(bytes.NewReader(append([]byte("TRICK"), bs[5:]...))); err == nil {
+		t.Errorf("got no error for invalid magic string, wanted one")
+	}
+}
+
+func TestReadStreamlines(t *testing.T) {
+	dir := t.TempDir()
+	trkFile := filepath.Join(dir, "tracts.trk")
+	os.WriteFile(trkFile, testTrkBytes(binary.LittleEndian, "LAS"), 0644)
+	s, err := ReadStreamlines(trkFile)
+	if err != nil || NumStreamlines(s) != 2 {
+		t.Errorf("got %d streamlines and error %v for TrackVis file, want 2 and no error", NumStreamlines(s), err)
+	}
+
+	tckFile := filepath.Join(dir, "tracts.tck")
+	os.WriteFile(tckFile, testTckBytes(binary.LittleEndian, true), 0644)
+	s, err = ReadStreamlines(tckFile)
+	if err != nil || NumStreamlines(s) != 2 {
+		t.Errorf("got %d streamlines and error %v for MRtrix file, want 2 and no error", NumStreamlines(s), err)
+	}
+
+	if _, err := ReadStreamlines(filepath.Join(dir, "tracts.vtk")); err == nil {
+		t.Errorf("got no error for unknown file extension, wanted one")
+	}
+}
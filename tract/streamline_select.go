@@ -0,0 +1,211 @@
+package tract
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dfsp-spirit/neuro"
+)
+
+// SelectStreamlines returns the subset of streamlines for which keep is true, with their scalars and properties.
+//
+// Parameters:
+//   - s : the streamlines
+//   - keep : for each streamline, whether it should be kept. Must have length NumStreamlines(s).
+//
+// Returns:
+//   - Streamlines : the selected streamlines, in their original order. The point data is shared with s, not copied.
+//   - error : an error if the length of keep does not match the number of streamlines
+func SelectStreamlines(s Streamlines, keep []bool) (Streamlines, error) {
+	if len(keep) != NumStreamlines(s) {
+		return Streamlines{}, fmt.Errorf("SelectStreamlines: got %d selection values for %d streamlines, must be equal", len(keep), NumStreamlines(s))
+	}
+	selected := Streamlines{ScalarNames: s.ScalarNames, PropertyNames: s.PropertyNames}
+	for i, k := range keep {
+		if !k {
+			continue
+		}
+		selected.Points = append(selected.Points, s.Points[i])
+		if len(s.Scalars) > 0 {
+			selected.Scalars = append(selected.Scalars, s.Scalars[i])
+		}
+		if len(s.Properties) > 0 {
+			selected.Properties = append(selected.Properties, s.Properties[i])
+		}
+	}
+	return selected, nil
+}
+
+// FilterByLength returns the streamlines with a length in the given range, see StreamlineLength.
+//
+// Removing very short streamlines is a common cleanup step before computing connectivity, as they are often tracking artifacts.
+//
+// Parameters:
+//   - s : the streamlines
+//   - minLength : the minimal length in mm, inclusive
+//   - maxLength : the maximal length in mm, inclusive. Pass float32(math.Inf(1)) for no upper limit.
+//
+// Returns:
+//   - Streamlines : the streamlines with lengths in range [minLength, maxLength]
+func FilterByLength(s Streamlines, minLength float32, maxLength float32) Streamlines {
+	keep := make([]bool, NumStreamlines(s))
+	for i, l := range StreamlineLengths(s) {
+		keep[i] = l >= minLength && l <= maxLength
+	}
+	selected, _ := SelectStreamlines(s, keep)
+	return selected
+}
+
+// streamlineTestPoints returns the indices of the points of a streamline that are tested against an ROI: the first and last point if endpointsOnly is set, all points otherwise.
+func streamlineTestPoints(points []float32, endpointsOnly bool) []int {
+	numPoints := len(points) / 3
+	if numPoints == 0 {
+		return nil
+	}
+	if endpointsOnly {
+		if numPoints == 1 {
+			return []int{0}
+		}
+		return []int{0, numPoints - 1}
+	}
+	indices := make([]int, numPoints)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
+// SelectBySurfaceROI returns the streamlines that pass close to a region of interest on a brain surface, e.g., a label or an atlas region.
+//
+// A streamline is selected if at least one of its tested points lies within maxDist of a vertex of the ROI. Streamlines end in the white matter
+// close to the cortex, so use the white surface and endpointsOnly to select the streamlines that connect to the region.
+//
+// Parameters:
+//   - s : the streamlines, in the RAS space of the mesh
+//   - mesh : the surface mesh, e.g., the white surface from ReadFsSurface
+//   - roi : for each vertex of the mesh, whether it belongs to the ROI, e.g., from neuro.LabelToMask
+//   - maxDist : the maximal distance of a point from an ROI vertex, in mm
+//   - endpointsOnly : whether to test only the first and last point of each streamline instead of all points
+//
+// Returns:
+//   - Streamlines : the selected streamlines
+//   - error : an error if the length of roi does not match the number of vertices of the mesh, or the ROI is empty
+func SelectBySurfaceROI(s Streamlines, mesh neuro.Mesh, roi []bool, maxDist float32, endpointsOnly bool) (Streamlines, error) {
+	if len(roi) != neuro.NumVertices(mesh) {
+		return Streamlines{}, fmt.Errorf("SelectBySurfaceROI: got ROI with %d values for mesh with %d vertices, must be equal", len(roi), neuro.NumVertices(mesh))
+	}
+	// Each ROI vertex becomes a degenerate face, so the nearest vertex search only considers ROI vertices.
+	target := neuro.Mesh{Vertices: mesh.Vertices}
+	for v, inRoi := range roi {
+		if inRoi {
+			target.Faces = append(target.Faces, int32(v), int32(v), int32(v))
+		}
+	}
+	if len(target.Faces) == 0 {
+		return Streamlines{}, fmt.Errorf("SelectBySurfaceROI: the ROI contains no vertices")
+	}
+
+	var source neuro.Mesh
+	for _, points := range s.Points {
+		for _, p := range streamlineTestPoints(points, endpointsOnly) {
+			source.Vertices = append(source.Vertices, points[p*3:p*3+3]...)
+		}
+	}
+	nearest, err := neuro.NearestVertexMap(source, target)
+	if err != nil {
+		return Streamlines{}, fmt.Errorf("SelectBySurfaceROI: %s", err)
+	}
+
+	maxDistSquared := float64(maxDist) * float64(maxDist)
+	keep := make([]bool, NumStreamlines(s))
+	next := 0
+	for i, points := range s.Points {
+		for range streamlineTestPoints(points, endpointsOnly) {
+			var distSquared float64
+			for k := 0; k < 3; k++ {
+				d := float64(source.Vertices[next*3+k] - mesh.Vertices[nearest[next]*3+int32(k)])
+				distSquared += d * d
+			}
+			keep[i] = keep[i] || distSquared <= maxDistSquared
+			next++
+		}
+	}
+	return SelectStreamlines(s, keep)
+}
+
+// invertAffine computes the inverse of a 4x4 affine transformation matrix in row-major order, whose last row is [0, 0, 0, 1].
+func invertAffine(m [16]float32) ([16]float64, error) {
+	var inv [16]float64
+	a := func(row, col int) float64 { return float64(m[row*4+col]) }
+	det := a(0, 0)*(a(1, 1)*a(2, 2)-a(1, 2)*a(2, 1)) - a(0, 1)*(a(1, 0)*a(2, 2)-a(1, 2)*a(2, 0)) + a(0, 2)*(a(1, 0)*a(2, 1)-a(1, 1)*a(2, 0))
+	if det == 0 {
+		return inv, fmt.Errorf("matrix is singular")
+	}
+	// The inverse of the 3x3 part is its adjugate divided by the determinant.
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			r1, r2 := (col+1)%3, (col+2)%3
+			c1, c2 := (row+1)%3, (row+2)%3
+			inv[row*4+col] = (a(r1, c1)*a(r2, c2) - a(r1, c2)*a(r2, c1)) / det
+		}
+	}
+	for row := 0; row < 3; row++ {
+		inv[row*4+3] = -(inv[row*4]*a(0, 3) + inv[row*4+1]*a(1, 3) + inv[row*4+2]*a(2, 3))
+	}
+	inv[15] = 1
+	return inv, nil
+}
+
+// SelectByVolumeMask returns the streamlines that pass through a volume mask, e.g., a binarized segmentation of a region.
+//
+// Each tested point is mapped to the voxel containing it with the inverse of the vox2ras matrix of the mask, see neuro.MghVox2Ras.
+// A streamline is selected if at least one of its tested points lies in a voxel with a non-zero value.
+//
+// Parameters:
+//   - s : the streamlines, in the RAS space of the mask volume
+//   - mask : the mask volume with a single frame, e.g., from neuro.ReadFsMgh. Voxels with non-zero values belong to the mask.
+//   - endpointsOnly : whether to test only the first and last point of each streamline instead of all points
+//
+// Returns:
+//   - Streamlines : the selected streamlines
+//   - error : an error if the mask has several frames, an unsupported data type, data that does not match its dimensions, or no valid RAS information in its header
+func SelectByVolumeMask(s Streamlines, mask neuro.Mgh, endpointsOnly bool) (Streamlines, error) {
+	if mask.Header.Dim4Length != 1 {
+		return Streamlines{}, fmt.Errorf("SelectByVolumeMask: invalid mask: volume has %d frames, but a mask must have a single frame", mask.Header.Dim4Length)
+	}
+	vox2ras, err := neuro.MghVox2Ras(mask.Header)
+	if err != nil {
+		return Streamlines{}, fmt.Errorf("SelectByVolumeMask: %s", err)
+	}
+	ras2vox, err := invertAffine(vox2ras)
+	if err != nil {
+		return Streamlines{}, fmt.Errorf("SelectByVolumeMask: cannot invert vox2ras matrix of mask: %s", err)
+	}
+
+	dims := [3]int{int(mask.Header.Dim1Length), int(mask.Header.Dim2Length), int(mask.Header.Dim3Length)}
+	keep := make([]bool, NumStreamlines(s))
+	for i, points := range s.Points {
+		for _, p := range streamlineTestPoints(points, endpointsOnly) {
+			var vox [3]int
+			inside := true
+			for row := 0; row < 3; row++ {
+				c := ras2vox[row*4]*float64(points[p*3]) + ras2vox[row*4+1]*float64(points[p*3+1]) + ras2vox[row*4+2]*float64(points[p*3+2]) + ras2vox[row*4+3]
+				// Voxel indices refer to voxel centers, so rounding yields the voxel that contains the point.
+				vox[row] = int(math.Round(c))
+				inside = inside && vox[row] >= 0 && vox[row] < dims[row]
+			}
+			if !inside {
+				continue
+			}
+			value, err := neuro.MghVoxelValue(mask, vox[0], vox[1], vox[2], 0)
+			if err != nil {
+				return Streamlines{}, fmt.Errorf("SelectByVolumeMask: invalid mask: %s", err)
+			}
+			if value != 0 {
+				keep[i] = true
+				break
+			}
+		}
+	}
+	return SelectStreamlines(s, keep)
+}
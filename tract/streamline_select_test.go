@@ -0,0 +1,148 @@
+package tract
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dfsp-spirit/neuro"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSelectStreamlines(t *testing.T) {
+	s := Streamlines{
+		Points:        [][]float32{{1, 1, 1}, {2, 2, 2}, {3, 3, 3}},
+		ScalarNames:   []string{"FA"},
+		Scalars:       [][]float32{{0.1}, {0.2}, {0.3}},
+		PropertyNames: []string{"id"},
+		Properties:    [][]float32{{1}, {2}, {3}},
+	}
+	selected, err := SelectStreamlines(s, []bool{true, false, true})
+	if err != nil {
+		t.Fatalf("SelectStreamlines failed: %v", err)
+	}
+	want := Streamlines{
+		Points:        [][]float32{{1, 1, 1}, {3, 3, 3}},
+		ScalarNames:   []string{"FA"},
+		Scalars:       [][]float32{{0.1}, {0.3}},
+		PropertyNames: []string{"id"},
+		Properties:    [][]float32{{1}, {3}},
+	}
+	if diff := cmp.Diff(want, selected); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := SelectStreamlines(s, []bool{true}); err == nil {
+		t.Errorf("got no error for selection of wrong length, wanted one")
+	}
+}
+
+func TestFilterByLength(t *testing.T) {
+	s := Streamlines{Points: [][]float32{{0, 0, 0, 5, 0, 0}, {0, 0, 0, 20, 0, 0}, {0, 0, 0, 200, 0, 0}}}
+	filtered := FilterByLength(s, 10, 100)
+	if diff := cmp.Diff([][]float32{{0, 0, 0, 20, 0, 0}}, filtered.Points); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if NumStreamlines(FilterByLength(s, 10, float32(math.Inf(1)))) != 2 {
+		t.Errorf("expected 2 streamlines without upper length limit")
+	}
+}
+
+func TestSelectBySurfaceROI(t *testing.T) {
+	mesh := neuro.Mesh{Vertices: []float32{0, 0, 0, 10, 0, 0, 0, 10, 0}, Faces: []int32{0, 1, 2}}
+	roi := []bool{false, true, false}
+	s := Streamlines{Points: [][]float32{
+		{10.5, 0, 0, 20, 0, 0},            // endpoint close to the ROI vertex
+		{0, 0, 0.5, 0, -20, 0},            // endpoint close to a vertex outside of the ROI
+		{-5, 0, 0, 10, 0, 0.5, 0, -20, 0}, // passes the ROI vertex
+	}}
+
+	selected, err := SelectBySurfaceROI(s, mesh, roi, 1, true)
+	if err != nil {
+		t.Fatalf("SelectBySurfaceROI failed: %v", err)
+	}
+	if diff := cmp.Diff(s.Points[:1], selected.Points); diff != "" {
+		t.Errorf("mismatch for endpoints only (-want +got):\n%s", diff)
+	}
+
+	selected, err = SelectBySurfaceROI(s, mesh, roi, 1, false)
+	if err != nil {
+		t.Fatalf("SelectBySurfaceROI failed: %v", err)
+	}
+	if diff := cmp.Diff([][]float32{s.Points[0], s.Points[2]}, selected.Points); diff != "" {
+		t.Errorf("mismatch for all points (-want +got):\n%s", diff)
+	}
+
+	if _, err := SelectBySurfaceROI(s, mesh, []bool{false, false, false}, 1, true); err == nil {
+		t.Errorf("got no error for empty ROI, wanted one")
+	}
+	if _, err := SelectBySurfaceROI(s, mesh, []bool{true}, 1, true); err == nil {
+		t.Errorf("got no error for ROI of wrong length, wanted one")
+	}
+}
+
+func TestSelectByVolumeMask(t *testing.T) {
+	// A 4x4x4 volume with 1 mm voxels, where voxel (2, 2, 2) is at RAS (0, 0, 0) and only voxel (3, 2, 2) is in the mask.
+	hdr := neuro.MghHeader{Dim1Length: 4, Dim2Length: 4, Dim3Length: 4, Dim4Length: 1, MghDataType: neuro.MRI_UCHAR,
+		RasGoodFlag: 1, XSize: 1, YSize: 1, ZSize: 1, Mdc: [9]float32{1, 0, 0, 0, 1, 0, 0, 0, 1}}
+	data := make([]uint8, 64)
+	data[3+2*4+2*16] = 1
+	mask := neuro.Mgh{Header: hdr, Data: neuro.MghData{DataMriUchar: data, MghDataType: neuro.MRI_UCHAR}}
+
+	s := Streamlines{Points: [][]float32{
+		{0.9, 0.1, 0, 5, 5, 5},         // starts in the mask voxel
+		{-5, 0, 0, 1, 0, 0, -1, -8, 0}, // passes the mask voxel
+		{-1, -1, -1, 0, 0, 0},          // misses the mask
+	}}
+
+	selected, err := SelectByVolumeMask(s, mask, true)
+	if err != nil {
+		t.Fatalf("SelectByVolumeMask failed: %v", err)
+	}
+	if diff := cmp.Diff(s.Points[:1], selected.Points); diff != "" {
+		t.Errorf("mismatch for endpoints only (-want +got):\n%s", diff)
+	}
+
+	selected, err = SelectByVolumeMask(s, mask, false)
+	if err != nil {
+		t.Fatalf("SelectByVolumeMask failed: %v", err)
+	}
+	if diff := cmp.Diff(s.Points[:2], selected.Points); diff != "" {
+		t.Errorf("mismatch for all points (-want +got):\n%s", diff)
+	}
+
+	mask.Data.DataMriUchar = data[:32]
+	if _, err := SelectByVolumeMask(s, mask, false); err == nil {
+		t.Errorf("got no error for mask with too little data, wanted one")
+	}
+
+	mask.Header.RasGoodFlag = 0
+	if _, err := SelectByVolumeMask(s, mask, false); err == nil {
+		t.Errorf("got no error for mask without RAS information, wanted one")
+	}
+}
+
+func TestInvertAffine(t *testing.T) {
+	m := [16]float32{0, -2, 0, 10, 0, 0, 3, -5, 1, 0, 0, 7, 0, 0, 0, 1}
+	inv, err := invertAffine(m)
+	if err != nil {
+		t.Fatalf("invertAffine failed: %v", err)
+	}
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			var sum float64
+			for k := 0; k < 4; k++ {
+				sum += float64(m[row*4+k]) * inv[k*4+col]
+			}
+			want := 0.0
+			if row == col {
+				want = 1
+			}
+			if math.Abs(sum-want) > 1e-9 {
+				t.Errorf("product of matrix and inverse at (%d, %d) is %f, want %f", row, col, sum, want)
+			}
+		}
+	}
+	if _, err := invertAffine([16]float32{}); err == nil {
+		t.Errorf("got no error for singular matrix, wanted one")
+	}
+}
@@ -0,0 +1,84 @@
+package tract
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// StreamlineLength computes the length of a single streamline, i.e., the sum of the distances between its consecutive points.
+//
+// Parameters:
+//   - points : the points of the streamline, as a flat array [x1, y1, z1, x2, ...], e.g., one entry of Streamlines.Points
+//
+// Returns:
+//   - float32 : the length, in the unit of the coordinates (mm for streamlines read with this package). 0 for streamlines with less than 2 points.
+func StreamlineLength(points []float32) float32 {
+	var length float64
+	for i := 3; i+2 < len(points); i += 3 {
+		dx := float64(points[i] - points[i-3])
+		dy := float64(points[i+1] - points[i-2])
+		dz := float64(points[i+2] - points[i-1])
+		length += math.Sqrt(dx*dx + dy*dy + dz*dz)
+	}
+	return float32(length)
+}
+
+// StreamlineLengths computes the length of each streamline, see StreamlineLength.
+//
+// Parameters:
+//   - s : the streamlines
+//
+// Returns:
+//   - []float32 : the length of each streamline
+func StreamlineLengths(s Streamlines) []float32 {
+	lengths := make([]float32, len(s.Points))
+	for i, points := range s.Points {
+		lengths[i] = StreamlineLength(points)
+	}
+	return lengths
+}
+
+// StreamlineStats computes descriptive statistics of a set of streamlines, including the distribution of the streamline lengths.
+//
+// Parameters:
+//   - s : the streamlines
+//
+// Returns:
+//   - map[string]float32 : the statistics, with keys 'numStreamlines', 'numPoints', 'minLength', 'maxLength', 'meanLength', 'medianLength' and 'stdLength'. The lengths are in mm.
+//   - error : an error if there are no streamlines
+func StreamlineStats(s Streamlines) (map[string]float32, error) {
+	n := NumStreamlines(s)
+	if n == 0 {
+		return nil, fmt.Errorf("StreamlineStats: there are no streamlines.")
+	}
+
+	lengths := StreamlineLengths(s)
+	sorted := make([]float64, n)
+	var sum float64
+	for i, l := range lengths {
+		sorted[i] = float64(l)
+		sum += float64(l)
+	}
+	sort.Float64s(sorted)
+	mean := sum / float64(n)
+
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	var sumSquares float64
+	for _, l := range sorted {
+		sumSquares += (l - mean) * (l - mean)
+	}
+
+	stats := map[string]float32{"numStreamlines": float32(n),
+		"numPoints":    float32(NumPoints(s)),
+		"minLength":    float32(sorted[0]),
+		"maxLength":    float32(sorted[n-1]),
+		"meanLength":   float32(mean),
+		"medianLength": float32(median),
+		"stdLength":    float32(math.Sqrt(sumSquares / float64(n))),
+	}
+	return stats, nil
+}
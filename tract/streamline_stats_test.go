@@ -0,0 +1,43 @@
+package tract
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestStreamlineLengths(t *testing.T) {
+	s := Streamlines{Points: [][]float32{{0, 0, 0, 3, 0, 0, 3, 4, 0}, {1, 2, 3}, {}}}
+	lengths := StreamlineLengths(s)
+	if len(lengths) != 3 || lengths[0] != 7 || lengths[1] != 0 || lengths[2] != 0 {
+		t.Errorf("got lengths %v, want [7 0 0]", lengths)
+	}
+}
+
+func TestStreamlineStats(t *testing.T) {
+	s := Streamlines{Points: [][]float32{{0, 0, 0, 3, 0, 0}, {0, 0, 0, 3, 0, 0, 3, 4, 0}, {0, 0, 0, 0, 4, 0}}}
+	stats, err := StreamlineStats(s)
+	if err != nil {
+		t.Fatalf("StreamlineStats failed: %v", err)
+	}
+	want := map[string]float32{"numStreamlines": 3, "numPoints": 7, "minLength": 3, "maxLength": 7, "medianLength": 4}
+	for key, value := range want {
+		if stats[key] != value {
+			t.Errorf("got %s %f, want %f", key, stats[key], value)
+		}
+	}
+	if math.Abs(float64(stats["meanLength"])-14.0/3.0) > 1e-5 || math.Abs(float64(stats["stdLength"])-math.Sqrt(78.0/27.0)) > 1e-5 {
+		t.Errorf("got mean length %f and std %f, want %f and %f", stats["meanLength"], stats["stdLength"], 14.0/3.0, math.Sqrt(78.0/27.0))
+	}
+
+	if _, err := StreamlineStats(Streamlines{}); err == nil {
+		t.Errorf("got no error for empty streamlines, wanted one")
+	}
+}
+
+func ExampleStreamlineStats() {
+	s := Streamlines{Points: [][]float32{{0, 0, 0, 10, 0, 0}, {0, 0, 0, 0, 20, 0, 0, 20, 10}, {5, 5, 5, 5, 5, 50}, {0, 0, 0, 0, 0, 60}}}
+	stats, _ := StreamlineStats(s)
+	fmt.Printf("%.0f streamlines, length range %.1f to %.1f mm, median %.1f mm\n", stats["numStreamlines"], stats["minLength"], stats["maxLength"], stats["medianLength"])
+	// Output: 4 streamlines, length range 10.0 to 60.0 mm, median 37.5 mm
+}
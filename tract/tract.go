@@ -0,0 +1,71 @@
+// Provides functions for reading tractography streamlines, as produced by diffusion MRI pipelines.
+//
+// The tract package reads the TrackVis (.trk) and MRtrix (.tck) file formats into a common Streamlines type.
+// The coordinates are in RAS space in mm, the space of the structural data read by the neuro package, so
+// streamlines can be combined with brain surfaces and volumes of the same subject.
+package tract
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Streamlines models a set of streamlines (also called tracts or fibers), i.e., sequences of points along white matter fiber bundles.
+//
+// The slices Points, Scalars and Properties are parallel, i.e., entry i of each slice belongs to streamline i.
+type Streamlines struct {
+	Points        [][]float32 // The points of each streamline, as a flat array [x1, y1, z1, x2, ...] in RAS coordinates in mm.
+	ScalarNames   []string    // The names of the per-point scalar values, e.g., 'FA'. Empty if there are none.
+	Scalars       [][]float32 // The per-point scalar values of each streamline, len(ScalarNames) values per point, in order [point1_scalar1, point1_scalar2, ..., point2_scalar1, ...]. Empty if there are no scalars.
+	PropertyNames []string    // The names of the per-streamline properties, e.g., 'length'. Empty if there are none.
+	Properties    [][]float32 // The len(PropertyNames) property values of each streamline. Empty if there are no properties.
+}
+
+// NumStreamlines returns the number of streamlines.
+//
+// Parameters:
+//   - s : the streamlines
+//
+// Returns:
+//   - int : the number of streamlines
+func NumStreamlines(s Streamlines) int {
+	return len(s.Points)
+}
+
+// NumPoints returns the total number of points of all streamlines.
+//
+// Parameters:
+//   - s : the streamlines
+//
+// Returns:
+//   - int : the number of points
+func NumPoints(s Streamlines) int {
+	n := 0
+	for _, points := range s.Points {
+		n += len(points) / 3
+	}
+	return n
+}
+
+// ReadStreamlines reads a streamline file, determining the format from the file extension.
+//
+// Use ReadTrk or ReadTck directly to access the file header.
+//
+// Parameters:
+//   - path : the path to the file, with extension '.trk' (TrackVis) or '.tck' (MRtrix)
+//
+// Returns:
+//   - Streamlines : the streamlines
+//   - error : an error if the file extension is unknown, or the file could not be read
+func ReadStreamlines(path string) (Streamlines, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".trk":
+		s, _, err := ReadTrk(path)
+		return s, err
+	case ".tck":
+		s, _, err := ReadTck(path)
+		return s, err
+	}
+	return Streamlines{}, fmt.Errorf("ReadStreamlines: cannot determine streamline format of file '%s' from its extension, must be '.trk' or '.tck'", path)
+}
@@ -0,0 +1,113 @@
+package neuro
+
+import (
+	"fmt"
+	"math"
+)
+
+// TriangleSoupStats describes the result of welding a triangle soup into an indexed mesh, see FromTriangleSoup.
+type TriangleSoupStats struct {
+	NumSoupVertices  int     // number of vertices in the soup, i.e., 3 per triangle
+	NumVertices      int     // number of vertices of the welded mesh
+	NumRemovedFaces  int     // number of triangles removed because two or more of their corners were welded into the same vertex
+	CompressionRatio float64 // NumSoupVertices / NumVertices. About 6 for closed triangle meshes, where each vertex is shared by 6 triangles on average.
+}
+
+// weldCell returns the cell of the spatial hash grid used for welding that contains a point.
+func weldCell(x float32, y float32, z float32, cellSize float32) [3]int64 {
+	return [3]int64{int64(math.Floor(float64(x / cellSize))), int64(math.Floor(float64(y / cellSize))), int64(math.Floor(float64(z / cellSize)))}
+}
+
+// FromTriangleSoup creates an indexed mesh from a triangle soup, i.e., a list of triangles that each store the coordinates of their
+// 3 corners, like the triangles in STL files or the output of marching cubes. Corners at identical or nearly identical positions
+// are welded into a single vertex, so that the mesh is connected and its topology can be analyzed.
+//
+// Parameters:
+//   - soup: the triangles, as a flat array of 9 coordinates per triangle: [x1, y1, z1, x2, y2, z2, x3, y3, z3, ...]
+//   - tolerance: the maximal distance between corners that get welded. Use 0 to weld corners with identical coordinates only.
+//     With a tolerance, each corner is welded to the first vertex created within the tolerance, so choose it well below the edge length.
+//
+// Returns:
+//   - Mesh: the indexed mesh. Vertices are ordered by their first occurrence in the soup.
+//   - TriangleSoupStats: the number of vertices before and after welding, and the number of triangles removed because they collapsed
+//   - error: an error if the soup length is not a multiple of 9 or the tolerance is invalid
+func FromTriangleSoup(soup []float32, tolerance float32) (Mesh, TriangleSoupStats, error) {
+	stats := TriangleSoupStats{NumSoupVertices: len(soup) / 3}
+	if len(soup)%9 != 0 {
+		return Mesh{}, stats, fmt.Errorf("FromTriangleSoup: got %d coordinates, but need 9 per triangle", len(soup))
+	}
+	if tolerance < 0 || math.IsNaN(float64(tolerance)) || math.IsInf(float64(tolerance), 0) {
+		return Mesh{}, stats, fmt.Errorf("FromTriangleSoup: invalid tolerance %f", tolerance)
+	}
+
+	var mesh Mesh
+	exactIndex := make(map[[3]float32]int32)
+	grid := make(map[[3]int64][]int32) // only used if tolerance > 0
+	toleranceSquared := tolerance * tolerance
+
+	// weld returns the index of the vertex for a corner, creating a new vertex if there is none within the tolerance.
+	weld := func(x float32, y float32, z float32) int32 {
+		key := [3]float32{x, y, z}
+		if idx, ok := exactIndex[key]; ok {
+			return idx
+		}
+		var cell [3]int64
+		if tolerance > 0 {
+			cell = weldCell(x, y, z, tolerance)
+			for dx := int64(-1); dx <= 1; dx++ {
+				for dy := int64(-1); dy <= 1; dy++ {
+					for dz := int64(-1); dz <= 1; dz++ {
+						for _, idx := range grid[[3]int64{cell[0] + dx, cell[1] + dy, cell[2] + dz}] {
+							v := mesh.Vertices[idx*3 : idx*3+3]
+							d := [3]float32{v[0] - x, v[1] - y, v[2] - z}
+							if dot3(d, d) <= toleranceSquared {
+								return idx
+							}
+						}
+					}
+				}
+			}
+		}
+		idx := int32(len(mesh.Vertices) / 3)
+		mesh.Vertices = append(mesh.Vertices, x, y, z)
+		exactIndex[key] = idx
+		if tolerance > 0 {
+			grid[cell] = append(grid[cell], idx)
+		}
+		return idx
+	}
+
+	mesh.Faces = make([]int32, 0, len(soup)/3)
+	for i := 0; i < len(soup); i += 9 {
+		a := weld(soup[i], soup[i+1], soup[i+2])
+		b := weld(soup[i+3], soup[i+4], soup[i+5])
+		c := weld(soup[i+6], soup[i+7], soup[i+8])
+		if a == b || b == c || a == c {
+			stats.NumRemovedFaces++
+			continue
+		}
+		mesh.Faces = append(mesh.Faces, a, b, c)
+	}
+
+	stats.NumVertices = NumVertices(mesh)
+	if stats.NumVertices > 0 {
+		stats.CompressionRatio = float64(stats.NumSoupVertices) / float64(stats.NumVertices)
+	}
+	logDebug("FromTriangleSoup: welded %d corners into %d vertices (ratio %.2f), removed %d collapsed triangles.", stats.NumSoupVertices, stats.NumVertices, stats.CompressionRatio, stats.NumRemovedFaces)
+	return mesh, stats, nil
+}
+
+// ToTriangleSoup converts an indexed mesh into a triangle soup, see FromTriangleSoup.
+//
+// Parameters:
+//   - mesh: the mesh
+//
+// Returns:
+//   - []float32: the triangles, as a flat array of 9 coordinates per triangle
+func ToTriangleSoup(mesh Mesh) []float32 {
+	soup := make([]float32, 0, len(mesh.Faces)*3)
+	for _, v := range mesh.Faces {
+		soup = append(soup, mesh.Vertices[v*3:v*3+3]...)
+	}
+	return soup
+}
@@ -0,0 +1,69 @@
+package neuro
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromTriangleSoupRoundTrip(t *testing.T) {
+	cube := GenerateCube()
+	mesh, stats, err := FromTriangleSoup(ToTriangleSoup(cube), 0)
+	if err != nil {
+		t.Fatalf("FromTriangleSoup failed: %v", err)
+	}
+	want := TriangleSoupStats{NumSoupVertices: 36, NumVertices: 8, NumRemovedFaces: 0, CompressionRatio: 4.5}
+	if diff := cmp.Diff(want, stats); diff != "" {
+		t.Error(diff)
+	}
+	// The vertex order may differ, but the triangles must be the same.
+	if diff := cmp.Diff(ToTriangleSoup(cube), ToTriangleSoup(mesh)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestFromTriangleSoupTolerance(t *testing.T) {
+	// Two triangles sharing an edge, with the shared corners slightly perturbed in the second triangle.
+	soup := []float32{
+		0, 0, 0, 1, 0, 0, 0, 1, 0,
+		1.001, 0, 0, 1, 1, 0, 0, 1.0005, 0,
+	}
+	_, stats, _ := FromTriangleSoup(soup, 0)
+	if stats.NumVertices != 6 {
+		t.Errorf("got %d vertices without tolerance, want 6", stats.NumVertices)
+	}
+
+	mesh, stats, err := FromTriangleSoup(soup, 0.01)
+	if err != nil {
+		t.Fatalf("FromTriangleSoup failed: %v", err)
+	}
+	if stats.NumVertices != 4 {
+		t.Errorf("got %d vertices with tolerance, want 4", stats.NumVertices)
+	}
+	if diff := cmp.Diff([]int32{0, 1, 2, 1, 3, 2}, mesh.Faces); diff != "" {
+		t.Error(diff)
+	}
+
+	// With a large tolerance, the second triangle collapses.
+	_, stats, _ = FromTriangleSoup(soup, 1.5)
+	if stats.NumRemovedFaces != 2 {
+		t.Errorf("got %d removed faces with large tolerance, want 2", stats.NumRemovedFaces)
+	}
+}
+
+func TestFromTriangleSoupInvalid(t *testing.T) {
+	if _, _, err := FromTriangleSoup(make([]float32, 8), 0); err == nil {
+		t.Errorf("got no error for soup length not a multiple of 9, wanted one")
+	}
+	if _, _, err := FromTriangleSoup(make([]float32, 9), -1); err == nil {
+		t.Errorf("got no error for negative tolerance, wanted one")
+	}
+}
+
+func ExampleFromTriangleSoup() {
+	mesh, _ := ReadFsSurface("testdata/lh.white")
+	welded, stats, _ := FromTriangleSoup(ToTriangleSoup(mesh), 0)
+	fmt.Printf("Welded %d corners into %d vertices, compression ratio %.2f.\n", stats.NumSoupVertices, NumVertices(welded), stats.CompressionRatio)
+	// Output: Welded 895452 corners into 149244 vertices, compression ratio 6.00.
+}
@@ -3,7 +3,6 @@ package neuro
 import (
 	"bufio"
 	"encoding/binary"
-	"fmt"
 	"math"
 	"os"
 )
@@ -84,11 +83,9 @@ func WriteFsCurv(filename string, data[]float32) error {
 
 	curvHdr := getCurvHeaderStruct(data)
 
-	if Verbosity >= 1 {
-		fmt.Printf("WriteFsCurv: curvHdr.NumVertices: %d\n", curvHdr.NumVertices)
-		fmt.Printf("WriteFsCurv: curvHdr.NumFaces: %d\n", curvHdr.NumFaces)
-		fmt.Printf("WriteFsCurv: curvHdr.NumValuesPerVertex: %d\n", curvHdr.NumValuesPerVertex)
-	}
+	logInfo("WriteFsCurv: curvHdr.NumVertices: %d", curvHdr.NumVertices)
+	logInfo("WriteFsCurv: curvHdr.NumFaces: %d", curvHdr.NumFaces)
+	logInfo("WriteFsCurv: curvHdr.NumValuesPerVertex: %d", curvHdr.NumValuesPerVertex)
 
 	err = binary.Write(file, binary.BigEndian, &curvHdr)
     if err != nil {
@@ -97,9 +94,7 @@ func WriteFsCurv(filename string, data[]float32) error {
 
 	writer := bufio.NewWriter(file)
 
-	if Verbosity >= 1 {
-		fmt.Printf("WriteFsCurv: Writing %d per-vertex descriptor values to file '%s'.\n", len(data), filename)
-	}
+	logInfo("WriteFsCurv: Writing %d per-vertex descriptor values to file '%s'.", len(data), filename)
 
 	numBytesWrittenTotal := 0
 	for _, x := range data {
@@ -110,9 +105,7 @@ func WriteFsCurv(filename string, data[]float32) error {
 		numBytesWrittenTotal += numBytesWritten
 	}
 
-	if Verbosity >= 1 {
-		fmt.Printf("WriteFsCurv: Wrote %d bytes to file '%s'.\n", numBytesWrittenTotal, filename)
-	}
+	logInfo("WriteFsCurv: Wrote %d bytes to file '%s'.", numBytesWrittenTotal, filename)
 
 	writer.Flush()
 
@@ -0,0 +1,139 @@
+package neuro
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// WriteFsSurface writes a Mesh to a file in FreeSurfer surface format.
+//
+// The file is written in big endian byte order, like FreeSurfer does it, and can be read by FreeSurfer tools and by ReadFsSurface.
+//
+// Parameters:
+//   - filepath: the path of the output file. Path to it must exist.
+//   - mesh: the mesh to write
+//
+// Returns:
+//   - error: an error if one occurred, e.g., the mesh is invalid or the file could not be written. Or nil otherwise.
+func WriteFsSurface(filepath string, mesh Mesh) error {
+	if err := WriteFsSurfaceWithHeader(filepath, mesh, FsSurfaceHeader{}); err != nil {
+		return fmt.Errorf("WriteFsSurface: %s", err)
+	}
+	return nil
+}
+
+// WriteFsSurfaceWithHeader writes a Mesh to a file in FreeSurfer surface format, with the given metadata.
+//
+// This preserves the provenance of a surface that was read with ReadFsSurfaceWithHeader and then modified. Append a command line to
+// header.CmdLines to document the modification.
+//
+// Parameters:
+//   - filepath: the path of the output file. Path to it must exist.
+//   - mesh: the mesh to write
+//   - header: the metadata. If header.CreatedLine is empty, a line like 'created by neurogo on <date>' is written.
+//
+// Returns:
+//   - error: an error if one occurred, e.g., the mesh is invalid, a header line contains a newline, or the file could not be written. Or nil otherwise.
+func WriteFsSurfaceWithHeader(filepath string, mesh Mesh, header FsSurfaceHeader) error {
+
+	if len(mesh.Vertices)%3 != 0 || len(mesh.Faces)%3 != 0 {
+		return fmt.Errorf("WriteFsSurfaceWithHeader: invalid mesh, lengths of Vertices (%d) and Faces (%d) must be multiples of 3", len(mesh.Vertices), len(mesh.Faces))
+	}
+	if strings.Contains(header.CreatedLine, "\n") || strings.Contains(header.CommentLine, "\n") {
+		return fmt.Errorf("WriteFsSurfaceWithHeader: the created and comment lines must not contain newlines")
+	}
+	if header.VolumeGeometry != "" && strings.Count(header.VolumeGeometry, "\n") != 8 {
+		return fmt.Errorf("WriteFsSurfaceWithHeader: the volume geometry must consist of 8 newline-terminated lines")
+	}
+	if header.CreatedLine == "" {
+		header.CreatedLine = fmt.Sprintf("created by neurogo on %s", time.Now().Format(time.ANSIC))
+	}
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("WriteFsSurfaceWithHeader: could not create surface file '%s': %s", filepath, err)
+	}
+
+	writer := bufio.NewWriter(file)
+	if err := writeFsSurface(writer, mesh, binary.BigEndian, header); err != nil {
+		file.Close()
+		return fmt.Errorf("WriteFsSurfaceWithHeader: could not write surface file '%s': %s", filepath, err)
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("WriteFsSurfaceWithHeader: could not write surface file '%s': %s", filepath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("WriteFsSurfaceWithHeader: could not close surface file '%s': %s", filepath, err)
+	}
+
+	logInfo("WriteFsSurfaceWithHeader: Wrote mesh with %d vertices and %d faces to file '%s'.", NumVertices(mesh), NumFaces(mesh), filepath)
+	return nil
+}
+
+// writeFsSurface writes a Mesh in FreeSurfer surface format to a writer, using the given byte order.
+//
+// Parameters:
+//   - w: the writer
+//   - mesh: the mesh to write
+//   - endian: the byte order. FreeSurfer uses binary.BigEndian, other byte orders are only useful for testing.
+//   - header: the metadata, see FsSurfaceHeader
+//
+// Returns:
+//   - error: an error if one occurred, or nil otherwise
+func writeFsSurface(w io.Writer, mesh Mesh, endian binary.ByteOrder, header FsSurfaceHeader) error {
+
+	magic := fsSurfaceTriangleMagic
+	if endian == binary.LittleEndian {
+		magic = [3]uint8{magic[2], magic[1], magic[0]}
+	}
+	if err := binary.Write(w, endian, magic); err != nil {
+		return err
+	}
+
+	// FreeSurfer terminates the created line with two newlines, the second one ends the (empty) comment line.
+	if _, err := io.WriteString(w, header.CreatedLine+"\n"+header.CommentLine+"\n"); err != nil {
+		return err
+	}
+
+	counts := []int32{int32(NumVertices(mesh)), int32(NumFaces(mesh))}
+	if err := binary.Write(w, endian, counts); err != nil {
+		return err
+	}
+	if err := binary.Write(w, endian, mesh.Vertices); err != nil {
+		return err
+	}
+	if err := binary.Write(w, endian, mesh.Faces); err != nil {
+		return err
+	}
+
+	if header.VolumeGeometry != "" {
+		var useRealRas int32
+		if header.UseRealRas {
+			useRealRas = 1
+		}
+		if err := binary.Write(w, endian, []int32{fsSurfaceTagOldUseRealRas, useRealRas, fsSurfaceTagOldSurfGeom}); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, header.VolumeGeometry); err != nil {
+			return err
+		}
+	}
+	for _, cmdline := range header.CmdLines {
+		if err := binary.Write(w, endian, fsSurfaceTagCmdline); err != nil {
+			return err
+		}
+		if err := binary.Write(w, endian, int64(len(cmdline)+1)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, cmdline+"\x00"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package neuro
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteRereadSurface(t *testing.T) {
+	var surfFile string = "testdata/lh.white"
+
+	surf, err := ReadFsSurface(surfFile)
+	if err != nil {
+		t.Fatalf("ReadFsSurface failed: %v", err)
+	}
+
+	// get a temp file.
+	file, err := os.CreateTemp("", "")
+	if err != nil {
+		t.Errorf("CreateTemp failed: %v", err)
+	}
+	defer os.Remove(file.Name()) // clean up
+	surf_file_name := file.Name()
+	file.Close()
+
+	err = WriteFsSurface(surf_file_name, surf)
+	if err != nil {
+		t.Errorf("WriteFsSurface failed: %v", err)
+	}
+	surf_reread, err := ReadFsSurface(surf_file_name)
+	if err != nil {
+		t.Errorf("ReadFsSurface failed on written file: %v", err)
+	}
+
+	if diff := cmp.Diff(surf, surf_reread); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestWriteFsSurfaceInvalidMesh(t *testing.T) {
+	mesh := Mesh{Vertices: []float32{1.0, 2.0}}
+
+	err := WriteFsSurface(os.DevNull, mesh)
+	if err == nil {
+		t.Errorf("got no error when writing invalid mesh, wanted one")
+	}
+}
+
+func TestWriteFsSurfaceWriteError(t *testing.T) {
+	// Writes to /dev/full fail with 'no space left on device' once the buffered data is flushed.
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available on this system")
+	}
+	if err := WriteFsSurface("/dev/full", GenerateCube()); err == nil {
+		t.Errorf("got no error when writing to a full device, wanted one")
+	}
+}
+
+func TestWriteFsSurfaceWithHeaderRoundTrip(t *testing.T) {
+	var surfFile string = "testdata/lh.white"
+
+	surf, header, err := ReadFsSurfaceWithHeader(surfFile)
+	if err != nil {
+		t.Fatalf("ReadFsSurfaceWithHeader failed: %v", err)
+	}
+	if diff := cmp.Diff("created by timschaefer on Tue Mar 20 17:56:08 2018", header.CreatedLine); diff != "" {
+		t.Error(diff)
+	}
+	if !strings.HasPrefix(header.VolumeGeometry, "valid = 1  # volume info valid\nfilename = ../mri/filled-pretess255.mgz\n") || header.UseRealRas {
+		t.Errorf("got unexpected volume geometry %q, useRealRAS %t", header.VolumeGeometry, header.UseRealRas)
+	}
+	if len(header.CmdLines) == 0 || !strings.HasPrefix(header.CmdLines[0], "mris_remove_intersection ") {
+		t.Errorf("got unexpected command lines %q", header.CmdLines)
+	}
+
+	// Writing the surface with the header must reproduce the original file exactly.
+	outFile := filepath.Join(t.TempDir(), "lh.white")
+	if err := WriteFsSurfaceWithHeader(outFile, surf, header); err != nil {
+		t.Fatalf("WriteFsSurfaceWithHeader failed: %v", err)
+	}
+	original, _ := os.ReadFile(surfFile)
+	written, _ := os.ReadFile(outFile)
+	if !bytes.Equal(original, written) {
+		t.Errorf("written file with %d bytes differs from original file with %d bytes", len(written), len(original))
+	}
+}
+
+func TestWriteFsSurfaceWithHeader(t *testing.T) {
+	cube := GenerateCube()
+	header := FsSurfaceHeader{CreatedLine: "created by test", CommentLine: "a comment", CmdLines: []string{"neurogo convert a b", "neurogo decimate b c"}}
+	outFile := filepath.Join(t.TempDir(), "cube.white")
+	if err := WriteFsSurfaceWithHeader(outFile, cube, header); err != nil {
+		t.Fatalf("WriteFsSurfaceWithHeader failed: %v", err)
+	}
+	surf, reread, err := ReadFsSurfaceWithHeader(outFile)
+	if err != nil {
+		t.Fatalf("ReadFsSurfaceWithHeader failed: %v", err)
+	}
+	if diff := cmp.Diff(header, reread); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(cube, surf); diff != "" {
+		t.Error(diff)
+	}
+
+	if err := WriteFsSurfaceWithHeader(outFile, cube, FsSurfaceHeader{CommentLine: "two\nlines"}); err == nil {
+		t.Errorf("got no error for comment line with newline, wanted one")
+	}
+	if err := WriteFsSurfaceWithHeader(outFile, cube, FsSurfaceHeader{VolumeGeometry: "valid = 1\n"}); err == nil {
+		t.Errorf("got no error for incomplete volume geometry, wanted one")
+	}
+}
@@ -0,0 +1,116 @@
+package neuro
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// ToGiftiFormat converts a mesh to GIFTI surface format.
+//
+// GIFTI is an XML-based format for surface data, supported by most neuroimaging software packages (FreeSurfer, FSL, AFNI, SPM, Connectome Workbench, nibabel, ...).
+// The mesh is stored as two data arrays, one with intent NIFTI_INTENT_POINTSET for the vertex coordinates and one with intent NIFTI_INTENT_TRIANGLE for the faces.
+//
+// Parameters:
+//   - mesh     : the mesh to convert
+//   - encoding : the encoding of the data arrays, one of 'ASCII', 'Base64Binary' or 'GZipBase64Binary'. The latter produces the smallest files.
+//
+// Returns:
+//   - []byte : the mesh representation in GIFTI format
+//   - error  : the error if one occured, or nil otherwise
+func ToGiftiFormat(mesh Mesh, encoding string) ([]byte, error) {
+
+	if !(encoding == "ASCII" || encoding == "Base64Binary" || encoding == "GZipBase64Binary") {
+		return nil, fmt.Errorf("ToGiftiFormat: invalid encoding '%s', use one of 'ASCII', 'Base64Binary', 'GZipBase64Binary'", encoding)
+	}
+
+	logDebug("Generating GIFTI representation with encoding %s for mesh with %d vertices and %d faces.", encoding, NumVertices(mesh), NumFaces(mesh))
+
+	vertexData, err := encodeGiftiData(mesh.Vertices, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("ToGiftiFormat: failed to encode vertices: %s", err)
+	}
+	faceData, err := encodeGiftiData(mesh.Faces, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("ToGiftiFormat: failed to encode faces: %s", err)
+	}
+
+	var gii strings.Builder
+	gii.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	gii.WriteString("<!DOCTYPE GIFTI SYSTEM \"http://www.nitrc.org/frs/download.php/115/gifti.dtd\">\n")
+	gii.WriteString("<GIFTI Version=\"1.0\" NumberOfDataArrays=\"2\">\n")
+	gii.WriteString("  <MetaData/>\n")
+	gii.WriteString("  <LabelTable/>\n")
+	gii.WriteString(fmt.Sprintf("  <DataArray Intent=\"NIFTI_INTENT_POINTSET\" DataType=\"NIFTI_TYPE_FLOAT32\" ArrayIndexingOrder=\"RowMajorOrder\" Dimensionality=\"2\" Dim0=\"%d\" Dim1=\"3\" Encoding=\"%s\" Endian=\"LittleEndian\" ExternalFileName=\"\" ExternalFileOffset=\"\">\n", NumVertices(mesh), encoding))
+	gii.WriteString("    <MetaData/>\n")
+	gii.WriteString("    <CoordinateSystemTransformMatrix>\n")
+	gii.WriteString("      <DataSpace><![CDATA[NIFTI_XFORM_UNKNOWN]]></DataSpace>\n")
+	gii.WriteString("      <TransformedSpace><![CDATA[NIFTI_XFORM_UNKNOWN]]></TransformedSpace>\n")
+	gii.WriteString("      <MatrixData>1 0 0 0 0 1 0 0 0 0 1 0 0 0 0 1</MatrixData>\n")
+	gii.WriteString("    </CoordinateSystemTransformMatrix>\n")
+	gii.WriteString(fmt.Sprintf("    <Data>%s</Data>\n", vertexData))
+	gii.WriteString("  </DataArray>\n")
+	gii.WriteString(fmt.Sprintf("  <DataArray Intent=\"NIFTI_INTENT_TRIANGLE\" DataType=\"NIFTI_TYPE_INT32\" ArrayIndexingOrder=\"RowMajorOrder\" Dimensionality=\"2\" Dim0=\"%d\" Dim1=\"3\" Encoding=\"%s\" Endian=\"LittleEndian\" ExternalFileName=\"\" ExternalFileOffset=\"\">\n", NumFaces(mesh), encoding))
+	gii.WriteString("    <MetaData/>\n")
+	gii.WriteString(fmt.Sprintf("    <Data>%s</Data>\n", faceData))
+	gii.WriteString("  </DataArray>\n")
+	gii.WriteString("</GIFTI>\n")
+
+	return []byte(gii.String()), nil
+}
+
+// encodeGiftiData encodes the values of a GIFTI data array.
+//
+// Parameters:
+//   - data     : the values, a []float32 or []int32 slice
+//   - encoding : the encoding, one of 'ASCII', 'Base64Binary' or 'GZipBase64Binary'
+//
+// Returns:
+//   - string : the encoded data, to be placed in the Data element of the data array
+//   - error  : the error if one occured, or nil otherwise
+func encodeGiftiData(data any, encoding string) (string, error) {
+	if encoding == "ASCII" {
+		var sb strings.Builder
+		switch d := data.(type) {
+		case []float32:
+			for i, v := range d {
+				if i > 0 {
+					sb.WriteString(" ")
+				}
+				sb.WriteString(fmt.Sprintf("%f", v))
+			}
+		case []int32:
+			for i, v := range d {
+				if i > 0 {
+					sb.WriteString(" ")
+				}
+				sb.WriteString(fmt.Sprintf("%d", v))
+			}
+		default:
+			return "", fmt.Errorf("unsupported data type %T", data)
+		}
+		return sb.String(), nil
+	}
+
+	var raw bytes.Buffer
+	if err := binary.Write(&raw, binary.LittleEndian, data); err != nil {
+		return "", err
+	}
+	bs := raw.Bytes()
+	if encoding == "GZipBase64Binary" {
+		// Despite the name, GIFTI uses zlib compression.
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(bs); err != nil {
+			return "", err
+		}
+		if err := zw.Close(); err != nil {
+			return "", err
+		}
+		bs = compressed.Bytes()
+	}
+	return base64.StdEncoding.EncodeToString(bs), nil
+}
@@ -0,0 +1,240 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ToPlyFormatBinary converts a mesh to binary little endian PLY format.
+//
+// Binary PLY files are a lot smaller and faster to load than ASCII PLY files, which matters for brain meshes with hundreds of thousands of faces.
+//
+// Parameters:
+//   - mesh   : the mesh to convert
+//   - colors : the per-vertex colors as a flat array of RGB values, i.e. [r1, g1, b1, r2, g2, b2, ...], see OverlayColors. Pass nil to omit colors.
+//
+// Returns:
+//   - []byte : the mesh representation in binary PLY format
+//   - error  : the error if one occured, e.g., the number of colors does not match the number of vertices, or nil otherwise
+func ToPlyFormatBinary(mesh Mesh, colors []uint8) ([]byte, error) {
+
+	if colors != nil && len(colors) != len(mesh.Vertices) {
+		return nil, fmt.Errorf("ToPlyFormatBinary: got %d color values for %d vertices, need 3 per vertex", len(colors), len(mesh.Vertices)/3)
+	}
+
+	logDebug("Generating binary PLY representation for mesh with %d vertices and %d faces.", NumVertices(mesh), NumFaces(mesh))
+
+	var ply bytes.Buffer
+	ply.WriteString("ply\n")
+	ply.WriteString("format binary_little_endian 1.0\n")
+	ply.WriteString("comment neurogo\n")
+	ply.WriteString(fmt.Sprintf("element vertex %d\n", NumVertices(mesh)))
+	ply.WriteString("property float x\n")
+	ply.WriteString("property float y\n")
+	ply.WriteString("property float z\n")
+	if colors != nil {
+		ply.WriteString("property uchar red\n")
+		ply.WriteString("property uchar green\n")
+		ply.WriteString("property uchar blue\n")
+	}
+	ply.WriteString(fmt.Sprintf("element face %d\n", NumFaces(mesh)))
+	ply.WriteString("property list uchar int vertex_indices\n")
+	ply.WriteString("end_header\n")
+
+	endian := binary.LittleEndian
+	for i := 0; i < len(mesh.Vertices); i += 3 {
+		binary.Write(&ply, endian, mesh.Vertices[i:i+3])
+		if colors != nil {
+			ply.Write(colors[i : i+3])
+		}
+	}
+	for i := 0; i < len(mesh.Faces); i += 3 {
+		ply.WriteByte(3)
+		binary.Write(&ply, endian, mesh.Faces[i:i+3])
+	}
+	return ply.Bytes(), nil
+}
+
+// ToStlFormatBinary converts a mesh to binary STL format.
+//
+// Parameters:
+//   - mesh : the mesh to convert
+//
+// Returns:
+//   - []byte : the mesh representation in binary STL format
+//   - error  : the error if one occured, or nil otherwise
+func ToStlFormatBinary(mesh Mesh) ([]byte, error) {
+
+	logDebug("Generating binary STL representation for mesh with %d vertices and %d faces.", NumVertices(mesh), NumFaces(mesh))
+
+	var stl bytes.Buffer
+	header := make([]byte, 80)
+	copy(header, "neurogo binary STL")
+	stl.Write(header)
+
+	endian := binary.LittleEndian
+	binary.Write(&stl, endian, uint32(NumFaces(mesh)))
+	for i := 0; i < NumFaces(mesh); i++ {
+		normal := faceNormal(mesh, i)
+		binary.Write(&stl, endian, normal)
+		for j := 0; j < 3; j++ {
+			v := mesh.Faces[i*3+j] * 3
+			binary.Write(&stl, endian, mesh.Vertices[v:v+3])
+		}
+		binary.Write(&stl, endian, uint16(0)) // attribute byte count, unused
+	}
+	return stl.Bytes(), nil
+}
+
+// guessMeshFormat determines the mesh file format from the file extension.
+//
+// Parameters:
+//   - filepath : the path to the mesh file
+//
+// Returns:
+//   - string : one of 'ply', 'obj', 'stl', 'gii' or 'fs'. FreeSurfer surfaces typically have no file extension, so 'fs' is returned for all unknown extensions.
+func guessMeshFormat(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".ply", ".obj", ".stl", ".gii":
+		return ext[1:]
+	default:
+		return "fs"
+	}
+}
+
+// fsSurfaceNames holds the names of the surfaces created by FreeSurfer's recon-all, which are used as file extensions, like in 'lh.white'.
+var fsSurfaceNames = map[string]bool{
+	"white": true, "pial": true, "inflated": true, "sphere": true, "orig": true, "smoothwm": true,
+	"reg": true, "qsphere": true, "midthickness": true, "graymid": true, "nofix": true,
+}
+
+// guessOutputMeshFormat determines the mesh file format for writing a file from the file extension.
+//
+// Unlike guessMeshFormat, this does not fall back to FreeSurfer format for unknown extensions, so that
+// a file like 'out.vtk' is not silently written in a format that does not match its name.
+//
+// Parameters:
+//   - filepath : the path to the mesh file
+//
+// Returns:
+//   - string : one of 'ply', 'obj', 'stl', 'gii' or 'fs'. 'fs' is returned for files without extension and for FreeSurfer surface names like 'lh.white'.
+//   - error  : an error if the extension is unknown
+func guessOutputMeshFormat(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".ply", ext == ".obj", ext == ".stl", ext == ".gii":
+		return ext[1:], nil
+	case ext == "", fsSurfaceNames[strings.TrimPrefix(ext, ".")]:
+		return "fs", nil
+	default:
+		return "", fmt.Errorf("cannot determine mesh format from file extension '%s' of file '%s', use one of '.ply', '.obj', '.stl', '.gii', no extension for FreeSurfer format, or specify the format explicitly", ext, path)
+	}
+}
+
+// normalizeMeshFormat translates mesh format names and their aliases into the canonical format name.
+//
+// Parameters:
+//   - format : the format name, case insensitive. Supported are 'fs' (alias 'surf'), 'ply', 'obj', 'stl', 'gii' (alias 'gifti').
+//
+// Returns:
+//   - string : the canonical format name, one of 'fs', 'ply', 'obj', 'stl', 'gii'
+//   - error  : an error if the format is not supported
+func normalizeMeshFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "fs", "surf":
+		return "fs", nil
+	case "ply", "obj", "stl":
+		return strings.ToLower(format), nil
+	case "gii", "gifti":
+		return "gii", nil
+	default:
+		return "", fmt.Errorf("invalid mesh format '%s', use one of 'fs', 'ply', 'obj', 'stl', 'gii'", format)
+	}
+}
+
+// MeshToBytes converts a mesh into the file contents of the requested mesh file format.
+//
+// Parameters:
+//   - mesh     : the mesh to convert
+//   - format   : the mesh file format to use, one of 'fs' (FreeSurfer surface), 'ply' (Stanford PLY format), 'obj' (Wavefront Object Format), 'stl' (StereoLithography format), 'gii' (GIFTI format)
+//   - asBinary : whether to use the binary variant of the format. Used for 'ply' and 'stl'. For 'gii', this selects gzip-compressed base64 encoding of the data arrays instead of ASCII. The 'fs' format is always binary, and 'obj' is always ASCII.
+//   - colors   : optional per-vertex colors as a flat array of RGB values, see OverlayColors. Only supported for 'ply' and 'obj', pass nil for no colors.
+//
+// Returns:
+//   - []byte : the file contents
+//   - error  : the error if one occured, or nil otherwise
+func MeshToBytes(mesh Mesh, format string, asBinary bool, colors []uint8) ([]byte, error) {
+	format, err := normalizeMeshFormat(format)
+	if err != nil {
+		return nil, fmt.Errorf("MeshToBytes: %s", err)
+	}
+	if colors != nil && !(format == "ply" || format == "obj") {
+		return nil, fmt.Errorf("MeshToBytes: per-vertex colors are only supported for formats 'ply' and 'obj', not for '%s'", format)
+	}
+
+	var rep string
+	switch format {
+	case "fs":
+		var buf bytes.Buffer
+		header := FsSurfaceHeader{CreatedLine: fmt.Sprintf("created by neurogo on %s", time.Now().Format(time.ANSIC))}
+		err = writeFsSurface(&buf, mesh, binary.BigEndian, header)
+		return buf.Bytes(), err
+	case "ply":
+		if asBinary {
+			return ToPlyFormatBinary(mesh, colors)
+		}
+		rep, err = ToPlyFormatWithColors(mesh, colors)
+	case "obj":
+		rep, err = ToObjFormatWithColors(mesh, colors)
+	case "stl":
+		if asBinary {
+			return ToStlFormatBinary(mesh)
+		}
+		rep, err = ToStlFormat(mesh)
+	case "gii":
+		encoding := "ASCII"
+		if asBinary {
+			encoding = "GZipBase64Binary"
+		}
+		return ToGiftiFormat(mesh, encoding)
+	}
+	return []byte(rep), err
+}
+
+// ExportMesh writes a mesh to a file in any of the supported mesh file formats.
+//
+// Unlike Export, this supports all mesh formats of the package, binary output, and per-vertex colors.
+//
+// Parameters:
+//   - mesh     : the mesh to export
+//   - filepath : the filepath to export the mesh to
+//   - format   : the mesh file format to use, see MeshToBytes. Use 'auto' to determine the format from the file extension of filepath. Files without extension and with FreeSurfer surface names like 'lh.white' are written in FreeSurfer format, other unknown extensions are an error.
+//   - asBinary : whether to use the binary variant of the format, see MeshToBytes
+//   - colors   : optional per-vertex colors, see MeshToBytes. Pass nil for no colors.
+//
+// Returns:
+//   - error : the error if one occured, or nil otherwise
+func ExportMesh(mesh Mesh, filepath string, format string, asBinary bool, colors []uint8) error {
+	if format == "auto" {
+		var err error
+		format, err = guessOutputMeshFormat(filepath)
+		if err != nil {
+			return fmt.Errorf("ExportMesh: %s", err)
+		}
+	}
+	bs, err := MeshToBytes(mesh, format, asBinary, colors)
+	if err != nil {
+		return fmt.Errorf("ExportMesh: %s", err)
+	}
+	if err := os.WriteFile(filepath, bs, 0644); err != nil {
+		return fmt.Errorf("ExportMesh: could not write mesh file '%s': %s", filepath, err)
+	}
+	logInfo("ExportMesh: Wrote %d bytes in format '%s' to file '%s'.", len(bs), format, filepath)
+	return nil
+}
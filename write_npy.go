@@ -0,0 +1,188 @@
+package neuro
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NpyArray holds an n-dimensional array for export in NumPy format, see ToNpyFormat and WriteNpz.
+type NpyArray struct {
+	Data  any   // the values in row-major (C) order. Must be one of []float32, []float64, []int32, []int16, []uint8.
+	Shape []int // the shape of the array, e.g., [numVertices, 3] for vertex coordinates. If nil, the array is one-dimensional.
+}
+
+// npyMagic holds the magic bytes at the start of a NumPy .npy file, followed by the format version 1.0.
+var npyMagic = []byte{0x93, 'N', 'U', 'M', 'P', 'Y', 1, 0}
+
+// npyDescr returns the NumPy data type description string and the number of values of the data of an array.
+func npyDescr(data any) (string, int, error) {
+	switch d := data.(type) {
+	case []float32:
+		return "<f4", len(d), nil
+	case []float64:
+		return "<f8", len(d), nil
+	case []int32:
+		return "<i4", len(d), nil
+	case []int16:
+		return "<i2", len(d), nil
+	case []uint8:
+		return "|u1", len(d), nil
+	default:
+		return "", 0, fmt.Errorf("unsupported data type %T, use one of []float32, []float64, []int32, []int16, []uint8", data)
+	}
+}
+
+// ToNpyFormat converts an array into the contents of a NumPy .npy file, which can be loaded in Python with numpy.load.
+//
+// Parameters:
+//   - arr: the array. The product of its shape must match the number of values.
+//
+// Returns:
+//   - []byte: the file contents, in .npy format version 1.0 with little endian data
+//   - error: an error if one occurred, e.g., the data type is not supported or does not match the shape
+func ToNpyFormat(arr NpyArray) ([]byte, error) {
+	descr, numValues, err := npyDescr(arr.Data)
+	if err != nil {
+		return nil, fmt.Errorf("ToNpyFormat: %s", err)
+	}
+	shape := arr.Shape
+	if shape == nil {
+		shape = []int{numValues}
+	}
+	shapeProduct := 1
+	shapeStrings := make([]string, len(shape))
+	for i, dim := range shape {
+		if dim < 0 {
+			return nil, fmt.Errorf("ToNpyFormat: invalid shape %v", shape)
+		}
+		shapeProduct *= dim
+		shapeStrings[i] = strconv.Itoa(dim)
+	}
+	if shapeProduct != numValues {
+		return nil, fmt.Errorf("ToNpyFormat: shape %v requires %d values, but got %d", shape, shapeProduct, numValues)
+	}
+
+	// Python tuple syntax: one-element tuples need a trailing comma.
+	shapeTuple := "(" + strings.Join(shapeStrings, ", ") + ")"
+	if len(shape) == 1 {
+		shapeTuple = "(" + shapeStrings[0] + ",)"
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", descr, shapeTuple)
+
+	// The header is padded with spaces and terminated by a newline, so that the data starts at a multiple of 64 bytes.
+	preludeLen := len(npyMagic) + 2
+	padding := 64 - (preludeLen+len(header)+1)%64
+	if padding == 64 {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	var buf bytes.Buffer
+	buf.Write(npyMagic)
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	if err := binary.Write(&buf, binary.LittleEndian, arr.Data); err != nil {
+		return nil, fmt.Errorf("ToNpyFormat: could not write data: %s", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteNpy writes an array to a file in NumPy .npy format, which can be loaded in Python with numpy.load.
+//
+// Parameters:
+//   - filepath: the path of the output file, typically with extension '.npy'
+//   - arr: the array, e.g., per-vertex data like cortical thickness: NpyArray{Data: thickness}
+//
+// Returns:
+//   - error: an error if one occurred
+func WriteNpy(filepath string, arr NpyArray) error {
+	bs, err := ToNpyFormat(arr)
+	if err != nil {
+		return fmt.Errorf("WriteNpy: %s", err)
+	}
+	if err := os.WriteFile(filepath, bs, 0644); err != nil {
+		return fmt.Errorf("WriteNpy: could not write file '%s': %s", filepath, err)
+	}
+	return nil
+}
+
+// ToNpzFormat converts several named arrays into the contents of a NumPy .npz file, i.e., a ZIP archive containing one .npy file per array.
+//
+// Parameters:
+//   - arrays: the arrays, by name. The names become the keys of the NpzFile object returned by numpy.load in Python.
+//
+// Returns:
+//   - []byte: the file contents. The arrays are compressed, like with numpy.savez_compressed.
+//   - error: an error if one occurred
+func ToNpzFormat(arrays map[string]NpyArray) ([]byte, error) {
+	names := make([]string, 0, len(arrays))
+	for name := range arrays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		bs, err := ToNpyFormat(arrays[name])
+		if err != nil {
+			return nil, fmt.Errorf("ToNpzFormat: array '%s': %s", name, err)
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Deflate})
+		if err != nil {
+			return nil, fmt.Errorf("ToNpzFormat: %s", err)
+		}
+		if _, err := fw.Write(bs); err != nil {
+			return nil, fmt.Errorf("ToNpzFormat: %s", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("ToNpzFormat: %s", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteNpz writes several named arrays to a file in NumPy .npz format, which can be loaded in Python with numpy.load.
+//
+// Parameters:
+//   - filepath: the path of the output file, typically with extension '.npz'
+//   - arrays: the arrays, by name, see ToNpzFormat
+//
+// Returns:
+//   - error: an error if one occurred
+func WriteNpz(filepath string, arrays map[string]NpyArray) error {
+	bs, err := ToNpzFormat(arrays)
+	if err != nil {
+		return fmt.Errorf("WriteNpz: %s", err)
+	}
+	if err := os.WriteFile(filepath, bs, 0644); err != nil {
+		return fmt.Errorf("WriteNpz: could not write file '%s': %s", filepath, err)
+	}
+	return nil
+}
+
+// MeshToNpyArrays returns the vertex coordinates and faces of a mesh as arrays for export in NumPy format.
+//
+// Example: to write a mesh and per-vertex data into a single file, use
+//
+//	arrays := MeshToNpyArrays(mesh)
+//	arrays["thickness"] = NpyArray{Data: thickness}
+//	err := WriteNpz("lh_white.npz", arrays)
+//
+// Parameters:
+//   - mesh: the mesh
+//
+// Returns:
+//   - map[string]NpyArray: the arrays 'vertices' (float32, shape [numVertices, 3]) and 'faces' (int32, shape [numFaces, 3], with 0-based vertex indices)
+func MeshToNpyArrays(mesh Mesh) map[string]NpyArray {
+	return map[string]NpyArray{
+		"vertices": {Data: mesh.Vertices, Shape: []int{NumVertices(mesh), 3}},
+		"faces":    {Data: mesh.Faces, Shape: []int{NumFaces(mesh), 3}},
+	}
+}
@@ -0,0 +1,103 @@
+package neuro
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// parseNpyHeader splits .npy file contents into the header dictionary string and the data.
+func parseNpyHeader(t *testing.T, bs []byte) (string, []byte) {
+	t.Helper()
+	if !bytes.Equal(bs[:8], npyMagic) {
+		t.Fatalf("invalid magic bytes %v", bs[:8])
+	}
+	headerLen := int(binary.LittleEndian.Uint16(bs[8:10]))
+	if (10+headerLen)%64 != 0 {
+		t.Errorf("data does not start at a multiple of 64 bytes: header length %d", headerLen)
+	}
+	header := string(bs[10 : 10+headerLen])
+	if !strings.HasSuffix(header, "\n") {
+		t.Errorf("header is not terminated by a newline")
+	}
+	return strings.TrimRight(header, " \n"), bs[10+headerLen:]
+}
+
+func TestToNpyFormat1D(t *testing.T) {
+	bs, err := ToNpyFormat(NpyArray{Data: []float32{1.5, -2, 3}})
+	if err != nil {
+		t.Fatalf("ToNpyFormat failed: %s", err)
+	}
+	header, data := parseNpyHeader(t, bs)
+	if header != "{'descr': '<f4', 'fortran_order': False, 'shape': (3,), }" {
+		t.Errorf("got header %q", header)
+	}
+	if len(data) != 12 || math.Float32frombits(binary.LittleEndian.Uint32(data[4:])) != -2 {
+		t.Errorf("got invalid data %v", data)
+	}
+}
+
+func TestToNpyFormatMesh(t *testing.T) {
+	arrays := MeshToNpyArrays(GenerateCube())
+
+	bs, err := ToNpyFormat(arrays["faces"])
+	if err != nil {
+		t.Fatalf("ToNpyFormat failed: %s", err)
+	}
+	header, data := parseNpyHeader(t, bs)
+	if header != "{'descr': '<i4', 'fortran_order': False, 'shape': (12, 3), }" {
+		t.Errorf("got header %q", header)
+	}
+	if len(data) != 12*3*4 {
+		t.Errorf("got %d data bytes, wanted %d", len(data), 12*3*4)
+	}
+}
+
+func TestToNpyFormatInvalid(t *testing.T) {
+	if _, err := ToNpyFormat(NpyArray{Data: []float32{1, 2, 3}, Shape: []int{2, 2}}); err == nil {
+		t.Errorf("got no error for shape that does not match the data, wanted one")
+	}
+	if _, err := ToNpyFormat(NpyArray{Data: []string{"a"}}); err == nil {
+		t.Errorf("got no error for unsupported data type, wanted one")
+	}
+}
+
+func TestToNpzFormat(t *testing.T) {
+	arrays := MeshToNpyArrays(GenerateCube())
+	arrays["thickness"] = NpyArray{Data: []float32{1, 2, 3, 4, 5, 6, 7, 8}}
+
+	bs, err := ToNpzFormat(arrays)
+	if err != nil {
+		t.Fatalf("ToNpzFormat failed: %s", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(bs), int64(len(bs)))
+	if err != nil {
+		t.Fatalf("result is not a valid ZIP archive: %s", err)
+	}
+
+	names := make([]string, 0)
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		want, _ := ToNpyFormat(arrays[strings.TrimSuffix(f.Name, ".npy")])
+		if !bytes.Equal(content, want) {
+			t.Errorf("content of archive member '%s' differs from ToNpyFormat result", f.Name)
+		}
+	}
+	if strings.Join(names, ",") != "faces.npy,thickness.npy,vertices.npy" {
+		t.Errorf("got archive members %v", names)
+	}
+}
+
+func ExampleMeshToNpyArrays() {
+	arrays := MeshToNpyArrays(GenerateCube())
+	fmt.Printf("vertices: %v, faces: %v\n", arrays["vertices"].Shape, arrays["faces"].Shape)
+	// Output: vertices: [8 3], faces: [12 3]
+}
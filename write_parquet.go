@@ -0,0 +1,361 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+)
+
+// TableColumn is a named column of a table for export in Parquet format, see ToParquetFormat.
+type TableColumn struct {
+	Name string // the column name
+	Data any    // the values, one per row. Must be one of []float32, []float64, []int32, []int64, []string, []bool.
+}
+
+// Parquet physical types, see the Parquet format specification.
+const (
+	parquetTypeBoolean   = 0
+	parquetTypeInt32     = 1
+	parquetTypeInt64     = 2
+	parquetTypeFloat     = 4
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+)
+
+// Parquet encodings, repetition types, page types and converted types used by the writer.
+const (
+	parquetEncodingPlain        = 0
+	parquetEncodingRle          = 3
+	parquetRepetitionRequired   = 0
+	parquetPageTypeData         = 0
+	parquetCodecUncompressed    = 0
+	parquetConvertedTypeUtf8    = 0
+	parquetMaxValuesPerDataPage = 1 << 20
+)
+
+// Thrift compact protocol type codes.
+const (
+	thriftTypeI32    = 5
+	thriftTypeI64    = 6
+	thriftTypeBinary = 8
+	thriftTypeList   = 9
+	thriftTypeStruct = 12
+)
+
+// thriftCompactWriter writes structs in the Thrift compact protocol, which is used for the metadata in Parquet files.
+type thriftCompactWriter struct {
+	buf     bytes.Buffer
+	lastFid []int16 // stack of the last field id written, one entry per open struct
+}
+
+func newThriftCompactWriter() *thriftCompactWriter {
+	return &thriftCompactWriter{lastFid: []int16{0}}
+}
+
+func (w *thriftCompactWriter) varint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	w.buf.Write(tmp[:n])
+}
+
+func (w *thriftCompactWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftCompactWriter) fieldHeader(fid int16, thriftType byte) {
+	last := &w.lastFid[len(w.lastFid)-1]
+	delta := fid - *last
+	if delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | thriftType)
+	} else {
+		w.buf.WriteByte(thriftType)
+		w.zigzag(int64(fid))
+	}
+	*last = fid
+}
+
+func (w *thriftCompactWriter) fieldI32(fid int16, v int32) {
+	w.fieldHeader(fid, thriftTypeI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftCompactWriter) fieldI64(fid int16, v int64) {
+	w.fieldHeader(fid, thriftTypeI64)
+	w.zigzag(v)
+}
+
+func (w *thriftCompactWriter) fieldString(fid int16, s string) {
+	w.fieldHeader(fid, thriftTypeBinary)
+	w.varint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// fieldStructBegin starts a struct field. It must be closed with structEnd.
+func (w *thriftCompactWriter) fieldStructBegin(fid int16) {
+	w.fieldHeader(fid, thriftTypeStruct)
+	w.structBegin()
+}
+
+// fieldList writes the header of a list field. The elements must be written directly afterwards.
+func (w *thriftCompactWriter) fieldList(fid int16, elemType byte, size int) {
+	w.fieldHeader(fid, thriftTypeList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xf0 | elemType)
+		w.varint(uint64(size))
+	}
+}
+
+// structBegin starts a struct that is a list element. It must be closed with structEnd.
+func (w *thriftCompactWriter) structBegin() {
+	w.lastFid = append(w.lastFid, 0)
+}
+
+func (w *thriftCompactWriter) structEnd() {
+	w.buf.WriteByte(0) // stop field
+	w.lastFid = w.lastFid[:len(w.lastFid)-1]
+}
+
+// parquetColumnInfo returns the Parquet physical type and the number of values of the data of a column.
+func parquetColumnInfo(data any) (int32, int, error) {
+	switch d := data.(type) {
+	case []bool:
+		return parquetTypeBoolean, len(d), nil
+	case []int32:
+		return parquetTypeInt32, len(d), nil
+	case []int64:
+		return parquetTypeInt64, len(d), nil
+	case []float32:
+		return parquetTypeFloat, len(d), nil
+	case []float64:
+		return parquetTypeDouble, len(d), nil
+	case []string:
+		return parquetTypeByteArray, len(d), nil
+	default:
+		return 0, 0, fmt.Errorf("unsupported data type %T, use one of []float32, []float64, []int32, []int64, []string, []bool", data)
+	}
+}
+
+// parquetPlainValues encodes the values in the range [start, end) of a column in PLAIN encoding.
+func parquetPlainValues(data any, start int, end int) []byte {
+	var buf bytes.Buffer
+	switch d := data.(type) {
+	case []bool:
+		packed := make([]byte, (end-start+7)/8)
+		for i, v := range d[start:end] {
+			if v {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		buf.Write(packed)
+	case []string:
+		for _, s := range d[start:end] {
+			binary.Write(&buf, binary.LittleEndian, uint32(len(s)))
+			buf.WriteString(s)
+		}
+	case []int32:
+		binary.Write(&buf, binary.LittleEndian, d[start:end])
+	case []int64:
+		binary.Write(&buf, binary.LittleEndian, d[start:end])
+	case []float32:
+		binary.Write(&buf, binary.LittleEndian, d[start:end])
+	case []float64:
+		binary.Write(&buf, binary.LittleEndian, d[start:end])
+	}
+	return buf.Bytes()
+}
+
+// ToParquetFormat converts a table into the contents of an Apache Parquet file, which can be loaded efficiently
+// with pandas (pandas.read_parquet), R (arrow::read_parquet), DuckDB and many other tools.
+//
+// All columns are written as required (non-nullable) columns without compression, in a single row group.
+// String columns are annotated as UTF-8 strings.
+//
+// The output follows the Parquet format specification, but so far it is only tested against the decoder of the package tests. The test
+// TestToParquetFormatPyarrowReference additionally compares it to a file written by pyarrow, once that file has been generated with
+// 'testdata/parquet/pyarrow_reference.py', and the same script checks that pyarrow reads the golden file 'testdata/parquet/table_neurogo.parquet'.
+//
+// Parameters:
+//   - columns: the columns of the table. All columns must have the same number of values, and the column names must be unique.
+//
+// Returns:
+//   - []byte: the file contents
+//   - error: an error if one occurred, e.g., a column has an unsupported data type or the columns differ in length
+func ToParquetFormat(columns []TableColumn) ([]byte, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("ToParquetFormat: table has no columns")
+	}
+	types := make([]int32, len(columns))
+	numRows := -1
+	names := make(map[string]bool, len(columns))
+	for i, col := range columns {
+		colType, n, err := parquetColumnInfo(col.Data)
+		if err != nil {
+			return nil, fmt.Errorf("ToParquetFormat: column '%s': %s", col.Name, err)
+		}
+		if numRows >= 0 && n != numRows {
+			return nil, fmt.Errorf("ToParquetFormat: column '%s' has %d values, but the first column has %d", col.Name, n, numRows)
+		}
+		if col.Name == "" || names[col.Name] {
+			return nil, fmt.Errorf("ToParquetFormat: column names must be unique and not empty, got '%s'", col.Name)
+		}
+		names[col.Name] = true
+		types[i] = colType
+		numRows = n
+	}
+
+	var file bytes.Buffer
+	file.WriteString("PAR1")
+
+	// Write the column chunks, each consisting of one or more data pages.
+	type chunkInfo struct {
+		offset int64
+		size   int64
+	}
+	chunks := make([]chunkInfo, len(columns))
+	for i, col := range columns {
+		chunks[i].offset = int64(file.Len())
+		for start := 0; start < numRows || (start == 0 && numRows == 0); start += parquetMaxValuesPerDataPage {
+			end := start + parquetMaxValuesPerDataPage
+			if end > numRows {
+				end = numRows
+			}
+			values := parquetPlainValues(col.Data, start, end)
+			if len(values) > math.MaxInt32 {
+				return nil, fmt.Errorf("ToParquetFormat: column '%s': data page too large", col.Name)
+			}
+
+			hdr := newThriftCompactWriter()
+			hdr.fieldI32(1, parquetPageTypeData)
+			hdr.fieldI32(2, int32(len(values))) // uncompressed size
+			hdr.fieldI32(3, int32(len(values))) // compressed size
+			hdr.fieldStructBegin(5)             // data page header
+			hdr.fieldI32(1, int32(end-start))
+			hdr.fieldI32(2, parquetEncodingPlain)
+			hdr.fieldI32(3, parquetEncodingRle) // definition levels, not present for required columns
+			hdr.fieldI32(4, parquetEncodingRle) // repetition levels, not present for flat schemas
+			hdr.structEnd()
+			hdr.buf.WriteByte(0) // end of page header
+
+			file.Write(hdr.buf.Bytes())
+			file.Write(values)
+			if numRows == 0 {
+				break
+			}
+		}
+		chunks[i].size = int64(file.Len()) - chunks[i].offset
+	}
+
+	// Write the file metadata.
+	meta := newThriftCompactWriter()
+	meta.fieldI32(1, 1) // format version
+	meta.fieldList(2, thriftTypeStruct, len(columns)+1)
+	meta.structBegin() // the root of the schema
+	meta.fieldString(4, "schema")
+	meta.fieldI32(5, int32(len(columns)))
+	meta.structEnd()
+	for i, col := range columns {
+		meta.structBegin()
+		meta.fieldI32(1, types[i])
+		meta.fieldI32(3, parquetRepetitionRequired)
+		meta.fieldString(4, col.Name)
+		if types[i] == parquetTypeByteArray {
+			meta.fieldI32(6, parquetConvertedTypeUtf8)
+		}
+		meta.structEnd()
+	}
+	meta.fieldI64(3, int64(numRows))
+
+	var totalSize int64
+	for _, c := range chunks {
+		totalSize += c.size
+	}
+	meta.fieldList(4, thriftTypeStruct, 1) // one row group
+	meta.structBegin()
+	meta.fieldList(1, thriftTypeStruct, len(columns))
+	for i, col := range columns {
+		meta.structBegin() // column chunk
+		meta.fieldI64(2, chunks[i].offset)
+		meta.fieldStructBegin(3) // column metadata
+		meta.fieldI32(1, types[i])
+		meta.fieldList(2, thriftTypeI32, 2)
+		meta.zigzag(parquetEncodingPlain)
+		meta.zigzag(parquetEncodingRle)
+		meta.fieldList(3, thriftTypeBinary, 1)
+		meta.varint(uint64(len(col.Name)))
+		meta.buf.WriteString(col.Name)
+		meta.fieldI32(4, parquetCodecUncompressed)
+		meta.fieldI64(5, int64(numRows))
+		meta.fieldI64(6, chunks[i].size)
+		meta.fieldI64(7, chunks[i].size)
+		meta.fieldI64(9, chunks[i].offset)
+		meta.structEnd()
+		meta.structEnd()
+	}
+	meta.fieldI64(2, totalSize)
+	meta.fieldI64(3, int64(numRows))
+	meta.structEnd()
+	meta.fieldString(6, "neurogo")
+	meta.buf.WriteByte(0) // end of file metadata
+
+	file.Write(meta.buf.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.WriteString("PAR1")
+	return file.Bytes(), nil
+}
+
+// WriteParquet writes a table to a file in Apache Parquet format. See ToParquetFormat for details.
+//
+// Parameters:
+//   - filepath: the path of the output file, typically with extension '.parquet'
+//   - columns: the columns of the table
+//
+// Returns:
+//   - error: an error if one occurred
+func WriteParquet(filepath string, columns []TableColumn) error {
+	bs, err := ToParquetFormat(columns)
+	if err != nil {
+		return fmt.Errorf("WriteParquet: %s", err)
+	}
+	if err := os.WriteFile(filepath, bs, 0644); err != nil {
+		return fmt.Errorf("WriteParquet: could not write file '%s': %s", filepath, err)
+	}
+	return nil
+}
+
+// PerVertexMatrixToTable converts per-vertex data of several subjects (a subjects x vertices matrix) into a table in long format,
+// with one row per subject and vertex. This is the format expected by most tools for group analyses, and it compresses well.
+//
+// Parameters:
+//   - subjectIDs: the subject identifiers, one per row of data
+//   - data: the per-vertex data, one slice per subject. All slices must have the same length, e.g., the number of vertices of fsaverage.
+//
+// Returns:
+//   - []TableColumn: the columns 'subject' (string), 'vertex' (int32, 0-based vertex index) and 'value' (float32), see ToParquetFormat
+//   - error: an error if one occurred, e.g., the number of values differs between subjects
+func PerVertexMatrixToTable(subjectIDs []string, data [][]float32) ([]TableColumn, error) {
+	if len(subjectIDs) != len(data) {
+		return nil, fmt.Errorf("PerVertexMatrixToTable: got %d subject IDs, but data for %d subjects", len(subjectIDs), len(data))
+	}
+	numVertices := 0
+	if len(data) > 0 {
+		numVertices = len(data[0])
+	}
+	subjects := make([]string, 0, len(data)*numVertices)
+	vertices := make([]int32, 0, len(data)*numVertices)
+	values := make([]float32, 0, len(data)*numVertices)
+	for i, subjectData := range data {
+		if len(subjectData) != numVertices {
+			return nil, fmt.Errorf("PerVertexMatrixToTable: subject '%s' has %d values, but subject '%s' has %d", subjectIDs[i], len(subjectData), subjectIDs[0], numVertices)
+		}
+		for v, value := range subjectData {
+			subjects = append(subjects, subjectIDs[i])
+			vertices = append(vertices, int32(v))
+			values = append(values, value)
+		}
+	}
+	return []TableColumn{{Name: "subject", Data: subjects}, {Name: "vertex", Data: vertices}, {Name: "value", Data: values}}, nil
+}
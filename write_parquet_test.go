@@ -0,0 +1,347 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// thriftTestReader decodes Thrift compact protocol structs into maps from field id to value, for checking the Parquet metadata.
+type thriftTestReader struct {
+	r *bytes.Reader
+}
+
+func (tr *thriftTestReader) varint() uint64 {
+	v, err := binary.ReadUvarint(tr.r)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (tr *thriftTestReader) zigzag() int64 {
+	v := tr.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (tr *thriftTestReader) value(thriftType byte) any {
+	switch thriftType {
+	case 1, 2: // bool, only reached for list elements, which store the value in a byte
+		b, _ := tr.r.ReadByte()
+		return b == 1
+	case 3: // i8
+		b, _ := tr.r.ReadByte()
+		return int64(int8(b))
+	case 4, thriftTypeI32, thriftTypeI64: // i16, i32, i64
+		return tr.zigzag()
+	case 7: // double
+		var v float64
+		binary.Read(tr.r, binary.LittleEndian, &v)
+		return v
+	case thriftTypeBinary:
+		bs := make([]byte, tr.varint())
+		tr.r.Read(bs)
+		return string(bs)
+	case thriftTypeStruct:
+		return tr.readStruct()
+	case thriftTypeList:
+		hdr, _ := tr.r.ReadByte()
+		size := int(hdr >> 4)
+		if size == 15 {
+			size = int(tr.varint())
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = tr.value(hdr & 0x0f)
+		}
+		return list
+	default:
+		panic(fmt.Sprintf("unsupported thrift type %d", thriftType))
+	}
+}
+
+func (tr *thriftTestReader) readStruct() map[int16]any {
+	fields := map[int16]any{}
+	var fid int16
+	for {
+		hdr, _ := tr.r.ReadByte()
+		if hdr == 0 {
+			return fields
+		}
+		if delta := int16(hdr >> 4); delta != 0 {
+			fid += delta
+		} else {
+			fid = int16(tr.zigzag())
+		}
+		if thriftType := hdr & 0x0f; thriftType == 1 || thriftType == 2 {
+			fields[fid] = thriftType == 1 // bool fields store the value in the type of the field header
+		} else {
+			fields[fid] = tr.value(thriftType)
+		}
+	}
+}
+
+// parquetTestFooter checks the magic bytes of a Parquet file and decodes its file metadata.
+func parquetTestFooter(t *testing.T, bs []byte) map[int16]any {
+	if string(bs[:4]) != "PAR1" || string(bs[len(bs)-4:]) != "PAR1" {
+		t.Fatalf("missing Parquet magic bytes")
+	}
+	metaLen := int(binary.LittleEndian.Uint32(bs[len(bs)-8:]))
+	meta := bs[len(bs)-8-metaLen : len(bs)-8]
+	tr := &thriftTestReader{r: bytes.NewReader(meta)}
+	fields := tr.readStruct()
+	if tr.r.Len() != 0 {
+		t.Errorf("Expected file metadata to span %d bytes, but %d bytes are left after decoding", metaLen, tr.r.Len())
+	}
+	return fields
+}
+
+// parquetTestColumnData returns the concatenated PLAIN values of all data pages of a column chunk.
+func parquetTestColumnData(t *testing.T, bs []byte, offset int64, size int64) ([]byte, int64) {
+	var data []byte
+	var numValues int64
+	r := bytes.NewReader(bs[offset : offset+size])
+	for r.Len() > 0 {
+		tr := &thriftTestReader{r: r}
+		hdr := tr.readStruct()
+		if hdr[1] != int64(parquetPageTypeData) {
+			t.Fatalf("Expected data page, got page type %v", hdr[1])
+		}
+		pageSize := hdr[3].(int64)
+		numValues += hdr[5].(map[int16]any)[1].(int64)
+		page := make([]byte, pageSize)
+		r.Read(page)
+		data = append(data, page...)
+	}
+	return data, numValues
+}
+
+// parquetTestSubset returns the given fields of a decoded Thrift struct, for comparing the fields that do not depend on the writer.
+func parquetTestSubset(fields map[int16]any, fids ...int16) map[int16]any {
+	subset := map[int16]any{}
+	for _, fid := range fids {
+		if v, ok := fields[fid]; ok {
+			subset[fid] = v
+		}
+	}
+	return subset
+}
+
+// parquetTestTable returns a table with one column of each supported type. The files in 'testdata/parquet' contain this table.
+func parquetTestTable() []TableColumn {
+	return []TableColumn{
+		{Name: "region", Data: []string{"bankssts", "cuneus", "insula"}},
+		{Name: "area", Data: []float32{1.5, 2.5, 3.5}},
+		{Name: "thickness", Data: []float64{2.25, 2.5, 2.75}},
+		{Name: "numVertices", Data: []int32{10, 20, 30}},
+		{Name: "cluster", Data: []int64{1, 2, 1 << 40}},
+		{Name: "significant", Data: []bool{true, false, true}},
+	}
+}
+
+func TestToParquetFormat(t *testing.T) {
+	columns := parquetTestTable()
+	bs, err := ToParquetFormat(columns)
+	if err != nil {
+		t.Fatalf("ToParquetFormat failed: %s", err)
+	}
+	meta := parquetTestFooter(t, bs)
+
+	if meta[3] != int64(3) {
+		t.Errorf("Expected 3 rows, got %v", meta[3])
+	}
+	if meta[6] != "neurogo" {
+		t.Errorf("Expected created_by 'neurogo', got %v", meta[6])
+	}
+
+	schema := meta[2].([]any)
+	if len(schema) != len(columns)+1 {
+		t.Fatalf("Expected %d schema elements, got %d", len(columns)+1, len(schema))
+	}
+	if diff := cmp.Diff(map[int16]any{4: "schema", 5: int64(len(columns))}, schema[0]); diff != "" {
+		t.Errorf("Root schema element mismatch (-want +got):\n%s", diff)
+	}
+	expectedTypes := []int64{parquetTypeByteArray, parquetTypeFloat, parquetTypeDouble, parquetTypeInt32, parquetTypeInt64, parquetTypeBoolean}
+	for i, col := range columns {
+		want := map[int16]any{1: expectedTypes[i], 3: int64(parquetRepetitionRequired), 4: col.Name}
+		if i == 0 {
+			want[6] = int64(parquetConvertedTypeUtf8)
+		}
+		if diff := cmp.Diff(want, schema[i+1]); diff != "" {
+			t.Errorf("Schema element of column '%s' mismatch (-want +got):\n%s", col.Name, diff)
+		}
+	}
+
+	rowGroups := meta[4].([]any)
+	if len(rowGroups) != 1 {
+		t.Fatalf("Expected 1 row group, got %d", len(rowGroups))
+	}
+	chunks := rowGroups[0].(map[int16]any)[1].([]any)
+	expectedData := [][]byte{
+		{8, 0, 0, 0, 'b', 'a', 'n', 'k', 's', 's', 't', 's', 6, 0, 0, 0, 'c', 'u', 'n', 'e', 'u', 's', 6, 0, 0, 0, 'i', 'n', 's', 'u', 'l', 'a'},
+		nil, nil, nil, nil,
+		{0x05},
+	}
+	for i, col := range columns[1:5] {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, col.Data)
+		expectedData[i+1] = buf.Bytes()
+	}
+	for i, chunk := range chunks {
+		colMeta := chunk.(map[int16]any)[3].(map[int16]any)
+		if diff := cmp.Diff([]any{columns[i].Name}, colMeta[3]); diff != "" {
+			t.Errorf("Column path mismatch (-want +got):\n%s", diff)
+		}
+		if colMeta[5] != int64(3) {
+			t.Errorf("Expected 3 values in column '%s', got %v", columns[i].Name, colMeta[5])
+		}
+		data, numValues := parquetTestColumnData(t, bs, colMeta[9].(int64), colMeta[7].(int64))
+		if numValues != 3 {
+			t.Errorf("Expected 3 values in pages of column '%s', got %d", columns[i].Name, numValues)
+		}
+		if !bytes.Equal(data, expectedData[i]) {
+			t.Errorf("Data of column '%s' mismatch: expected %v, got %v", columns[i].Name, expectedData[i], data)
+		}
+	}
+}
+
+func TestToParquetFormatGolden(t *testing.T) {
+	// The golden file can be checked with pyarrow, see testdata/parquet/pyarrow_reference.py.
+	want, err := os.ReadFile("testdata/parquet/table_neurogo.parquet")
+	if err != nil {
+		t.Fatalf("Could not read golden file: %s", err)
+	}
+	got, err := ToParquetFormat(parquetTestTable())
+	if err != nil {
+		t.Fatalf("ToParquetFormat failed: %s", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("ToParquetFormat output differs from golden file testdata/parquet/table_neurogo.parquet")
+	}
+}
+
+func TestToParquetFormatPyarrowReference(t *testing.T) {
+	ref, err := os.ReadFile("testdata/parquet/table_pyarrow.parquet")
+	if os.IsNotExist(err) {
+		t.Skip("pyarrow reference file not found, generate it with testdata/parquet/pyarrow_reference.py")
+	}
+	if err != nil {
+		t.Fatalf("Could not read reference file: %s", err)
+	}
+	bs, err := ToParquetFormat(parquetTestTable())
+	if err != nil {
+		t.Fatalf("ToParquetFormat failed: %s", err)
+	}
+	want, got := parquetTestFooter(t, ref), parquetTestFooter(t, bs)
+
+	if want[3] != got[3] {
+		t.Errorf("Number of rows mismatch: pyarrow wrote %v, got %v", want[3], got[3])
+	}
+	// Compare type, repetition, name, number of children and converted type of the schema elements.
+	wantSchema, gotSchema := want[2].([]any), got[2].([]any)
+	if len(wantSchema) != len(gotSchema) {
+		t.Fatalf("Expected %d schema elements like pyarrow, got %d", len(wantSchema), len(gotSchema))
+	}
+	for i := range wantSchema {
+		fids := []int16{1, 3, 4, 5, 6}
+		if i == 0 {
+			fids = []int16{4, 5} // writers differ in the repetition of the root
+		}
+		w, g := parquetTestSubset(wantSchema[i].(map[int16]any), fids...), parquetTestSubset(gotSchema[i].(map[int16]any), fids...)
+		if diff := cmp.Diff(w, g); diff != "" {
+			t.Errorf("Schema element %d mismatch (-pyarrow +got):\n%s", i, diff)
+		}
+	}
+
+	// Compare type, path, codec and number of values of the column chunks, and the values in their data pages.
+	wantChunks := want[4].([]any)[0].(map[int16]any)[1].([]any)
+	gotChunks := got[4].([]any)[0].(map[int16]any)[1].([]any)
+	if len(wantChunks) != len(gotChunks) {
+		t.Fatalf("Expected %d column chunks like pyarrow, got %d", len(wantChunks), len(gotChunks))
+	}
+	for i := range wantChunks {
+		wantMeta, gotMeta := wantChunks[i].(map[int16]any)[3].(map[int16]any), gotChunks[i].(map[int16]any)[3].(map[int16]any)
+		if diff := cmp.Diff(parquetTestSubset(wantMeta, 1, 3, 4, 5), parquetTestSubset(gotMeta, 1, 3, 4, 5)); diff != "" {
+			t.Errorf("Metadata of column chunk %d mismatch (-pyarrow +got):\n%s", i, diff)
+		}
+		wantData, wantNum := parquetTestColumnData(t, ref, wantMeta[9].(int64), wantMeta[7].(int64))
+		gotData, gotNum := parquetTestColumnData(t, bs, gotMeta[9].(int64), gotMeta[7].(int64))
+		if wantNum != gotNum || !bytes.Equal(wantData, gotData) {
+			t.Errorf("Data of column chunk %d mismatch: pyarrow wrote %d values %v, got %d values %v", i, wantNum, wantData, gotNum, gotData)
+		}
+	}
+}
+
+func TestToParquetFormatMultiplePages(t *testing.T) {
+	numRows := parquetMaxValuesPerDataPage + 10
+	values := make([]float32, numRows)
+	for i := range values {
+		values[i] = float32(i)
+	}
+	bs, err := ToParquetFormat([]TableColumn{{Name: "value", Data: values}})
+	if err != nil {
+		t.Fatalf("ToParquetFormat failed: %s", err)
+	}
+	meta := parquetTestFooter(t, bs)
+	colMeta := meta[4].([]any)[0].(map[int16]any)[1].([]any)[0].(map[int16]any)[3].(map[int16]any)
+	data, numValues := parquetTestColumnData(t, bs, colMeta[9].(int64), colMeta[7].(int64))
+	if numValues != int64(numRows) {
+		t.Errorf("Expected %d values, got %d", numRows, numValues)
+	}
+	last := math.Float32frombits(binary.LittleEndian.Uint32(data[len(data)-4:]))
+	if len(data) != 4*numRows || last != float32(numRows-1) {
+		t.Errorf("Unexpected data: %d bytes, last value %f", len(data), last)
+	}
+}
+
+func TestToParquetFormatInvalidTables(t *testing.T) {
+	tables := map[string][]TableColumn{
+		"no columns":       {},
+		"unsupported type": {{Name: "a", Data: []uint16{1}}},
+		"length mismatch":  {{Name: "a", Data: []int32{1, 2}}, {Name: "b", Data: []int32{1}}},
+		"duplicate name":   {{Name: "a", Data: []int32{1}}, {Name: "a", Data: []int32{1}}},
+		"empty name":       {{Name: "", Data: []int32{1}}},
+	}
+	for name, columns := range tables {
+		if _, err := ToParquetFormat(columns); err == nil {
+			t.Errorf("Expected error for table with %s", name)
+		}
+	}
+}
+
+func TestPerVertexMatrixToTableAndWriteParquet(t *testing.T) {
+	columns, err := PerVertexMatrixToTable([]string{"subject1", "subject2"}, [][]float32{{1.0, 2.0, 3.0}, {4.0, 5.0, 6.0}})
+	if err != nil {
+		t.Fatalf("PerVertexMatrixToTable failed: %s", err)
+	}
+	expected := []TableColumn{
+		{Name: "subject", Data: []string{"subject1", "subject1", "subject1", "subject2", "subject2", "subject2"}},
+		{Name: "vertex", Data: []int32{0, 1, 2, 0, 1, 2}},
+		{Name: "value", Data: []float32{1.0, 2.0, 3.0, 4.0, 5.0, 6.0}},
+	}
+	if diff := cmp.Diff(expected, columns); diff != "" {
+		t.Errorf("PerVertexMatrixToTable mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := PerVertexMatrixToTable([]string{"subject1", "subject2"}, [][]float32{{1.0, 2.0}, {4.0}}); err == nil {
+		t.Errorf("Expected error for subjects with different numbers of values")
+	}
+
+	outfile := filepath.Join(t.TempDir(), "thickness.parquet")
+	if err := WriteParquet(outfile, columns); err != nil {
+		t.Fatalf("WriteParquet failed: %s", err)
+	}
+	bs, err := os.ReadFile(outfile)
+	if err != nil {
+		t.Fatalf("Could not read written file: %s", err)
+	}
+	if meta := parquetTestFooter(t, bs); meta[3] != int64(6) {
+		t.Errorf("Expected 6 rows, got %v", meta[3])
+	}
+}
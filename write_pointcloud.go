@@ -0,0 +1,192 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checkPointCloudData checks that the optional per-vertex normals and intensities match the number of vertices of a mesh.
+func checkPointCloudData(mesh Mesh, normals []float32, intensity []float32) error {
+	if normals != nil && len(normals) != len(mesh.Vertices) {
+		return fmt.Errorf("got %d normal values for %d vertices, need 3 per vertex", len(normals), NumVertices(mesh))
+	}
+	if intensity != nil && len(intensity) != NumVertices(mesh) {
+		return fmt.Errorf("got %d intensity values for %d vertices, need 1 per vertex", len(intensity), NumVertices(mesh))
+	}
+	return nil
+}
+
+// ToXyzFormat converts the vertices of a mesh to XYZ point cloud format, i.e., a text file with one point per line.
+//
+// Each line contains the coordinates of a vertex, followed by the normal and the intensity if requested: 'x y z [nx ny nz] [intensity]'.
+// The faces of the mesh are ignored.
+//
+// Parameters:
+//   - mesh      : the mesh whose vertices are exported
+//   - normals   : optional per-vertex normals as a flat array [nx1, ny1, nz1, nx2, ...], see VertexNormals. Pass nil to omit normals.
+//   - intensity : optional per-vertex intensity, e.g., an overlay like cortical thickness. Pass nil to omit intensity.
+//
+// Returns:
+//   - string : the point cloud in XYZ format
+//   - error  : the error if one occured, e.g., the number of normals does not match the number of vertices, or nil otherwise
+func ToXyzFormat(mesh Mesh, normals []float32, intensity []float32) (string, error) {
+	if err := checkPointCloudData(mesh, normals, intensity); err != nil {
+		return "", fmt.Errorf("ToXyzFormat: %s", err)
+	}
+
+	logDebug("Generating XYZ representation for %d vertices.", NumVertices(mesh))
+	var xyz strings.Builder
+	for i := 0; i < NumVertices(mesh); i++ {
+		xyz.WriteString(fmt.Sprintf("%f %f %f", mesh.Vertices[i*3], mesh.Vertices[i*3+1], mesh.Vertices[i*3+2]))
+		if normals != nil {
+			xyz.WriteString(fmt.Sprintf(" %f %f %f", normals[i*3], normals[i*3+1], normals[i*3+2]))
+		}
+		if intensity != nil {
+			xyz.WriteString(fmt.Sprintf(" %f", intensity[i]))
+		}
+		xyz.WriteString("\n")
+	}
+	return xyz.String(), nil
+}
+
+// ToPcdFormat converts the vertices of a mesh to the PCD format (version 0.7) of the Point Cloud Library (PCL).
+//
+// The fields are 'x y z', followed by 'normal_x normal_y normal_z' and 'intensity' if requested, all stored as 32 bit floats.
+// The faces of the mesh are ignored.
+//
+// Parameters:
+//   - mesh      : the mesh whose vertices are exported
+//   - normals   : optional per-vertex normals as a flat array, see ToXyzFormat. Pass nil to omit normals.
+//   - intensity : optional per-vertex intensity, see ToXyzFormat. Pass nil to omit intensity.
+//   - asBinary  : whether to store the points in binary (little endian) instead of ASCII format
+//
+// Returns:
+//   - []byte : the point cloud in PCD format
+//   - error  : the error if one occured, or nil otherwise
+func ToPcdFormat(mesh Mesh, normals []float32, intensity []float32, asBinary bool) ([]byte, error) {
+	if err := checkPointCloudData(mesh, normals, intensity); err != nil {
+		return nil, fmt.Errorf("ToPcdFormat: %s", err)
+	}
+
+	fields := []string{"x", "y", "z"}
+	if normals != nil {
+		fields = append(fields, "normal_x", "normal_y", "normal_z")
+	}
+	if intensity != nil {
+		fields = append(fields, "intensity")
+	}
+	repeat := func(s string) string {
+		return strings.TrimSpace(strings.Repeat(s+" ", len(fields)))
+	}
+	dataFormat := "ascii"
+	if asBinary {
+		dataFormat = "binary"
+	}
+
+	logDebug("Generating PCD representation for %d vertices.", NumVertices(mesh))
+	numPoints := NumVertices(mesh)
+	var pcd bytes.Buffer
+	pcd.WriteString("# .PCD v0.7 - Point Cloud Data file format, written by neurogo\n")
+	pcd.WriteString("VERSION 0.7\n")
+	pcd.WriteString(fmt.Sprintf("FIELDS %s\n", strings.Join(fields, " ")))
+	pcd.WriteString(fmt.Sprintf("SIZE %s\n", repeat("4")))
+	pcd.WriteString(fmt.Sprintf("TYPE %s\n", repeat("F")))
+	pcd.WriteString(fmt.Sprintf("COUNT %s\n", repeat("1")))
+	pcd.WriteString(fmt.Sprintf("WIDTH %d\n", numPoints))
+	pcd.WriteString("HEIGHT 1\n")
+	pcd.WriteString("VIEWPOINT 0 0 0 1 0 0 0\n")
+	pcd.WriteString(fmt.Sprintf("POINTS %d\n", numPoints))
+	pcd.WriteString(fmt.Sprintf("DATA %s\n", dataFormat))
+
+	if !asBinary {
+		// The ASCII data part has the same layout as the XYZ format.
+		xyz, _ := ToXyzFormat(mesh, normals, intensity)
+		pcd.WriteString(xyz)
+		return pcd.Bytes(), nil
+	}
+
+	point := make([]float32, 0, len(fields))
+	for i := 0; i < numPoints; i++ {
+		point = append(point[:0], mesh.Vertices[i*3:i*3+3]...)
+		if normals != nil {
+			point = append(point, normals[i*3:i*3+3]...)
+		}
+		if intensity != nil {
+			point = append(point, intensity[i])
+		}
+		binary.Write(&pcd, binary.LittleEndian, point)
+	}
+	return pcd.Bytes(), nil
+}
+
+// ExportPointCloud writes the vertices of a mesh to a point cloud file, e.g., for use with point cloud registration tools.
+//
+// Parameters:
+//   - mesh        : the mesh whose vertices are exported
+//   - filepath    : the filepath to export the point cloud to
+//   - format      : the point cloud format, one of 'xyz' (see ToXyzFormat) or 'pcd' (see ToPcdFormat). Use 'auto' to determine the format from the file extension of filepath.
+//   - asBinary    : whether to use binary PCD format. Ignored for 'xyz', which is always ASCII.
+//   - withNormals : whether to export the vertex normals, computed with VertexNormals
+//   - intensity   : optional per-vertex intensity, e.g., an overlay like cortical thickness. Pass nil to omit intensity.
+//
+// Returns:
+//   - error : the error if one occured, or nil otherwise
+func ExportPointCloud(mesh Mesh, filepath string, format string, asBinary bool, withNormals bool, intensity []float32) error {
+	if format == "auto" {
+		var err error
+		format, err = guessPointCloudFormat(filepath)
+		if err != nil {
+			return fmt.Errorf("ExportPointCloud: %s", err)
+		}
+	}
+	var normals []float32
+	if withNormals {
+		normals = VertexNormals(mesh)
+	}
+
+	var bs []byte
+	switch strings.ToLower(format) {
+	case "xyz":
+		rep, err := ToXyzFormat(mesh, normals, intensity)
+		if err != nil {
+			return fmt.Errorf("ExportPointCloud: %s", err)
+		}
+		bs = []byte(rep)
+	case "pcd":
+		var err error
+		bs, err = ToPcdFormat(mesh, normals, intensity, asBinary)
+		if err != nil {
+			return fmt.Errorf("ExportPointCloud: %s", err)
+		}
+	default:
+		return fmt.Errorf("ExportPointCloud: invalid point cloud format '%s', use one of 'xyz', 'pcd'", format)
+	}
+
+	if err := os.WriteFile(filepath, bs, 0644); err != nil {
+		return fmt.Errorf("ExportPointCloud: could not write point cloud file '%s': %s", filepath, err)
+	}
+	logInfo("ExportPointCloud: Wrote %d bytes in format '%s' to file '%s'.", len(bs), format, filepath)
+	return nil
+}
+
+// guessPointCloudFormat determines the point cloud file format from the file extension.
+//
+// Parameters:
+//   - filepath : the path to the point cloud file
+//
+// Returns:
+//   - string : one of 'xyz' or 'pcd'
+//   - error  : an error if the extension is unknown
+func guessPointCloudFormat(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".xyz", ".pcd":
+		return ext[1:], nil
+	default:
+		return "", fmt.Errorf("cannot determine point cloud format from file extension '%s' of file '%s', use one of '.xyz', '.pcd', or specify the format explicitly", ext, path)
+	}
+}
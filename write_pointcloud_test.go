@@ -0,0 +1,112 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToXyzFormat(t *testing.T) {
+	mesh := Mesh{Vertices: []float32{0, 0, 0, 1, 0, 0, 0, 1, 0}, Faces: []int32{0, 1, 2}}
+
+	xyz, err := ToXyzFormat(mesh, nil, nil)
+	if err != nil {
+		t.Fatalf("ToXyzFormat failed: %v", err)
+	}
+	want := "0.000000 0.000000 0.000000\n1.000000 0.000000 0.000000\n0.000000 1.000000 0.000000\n"
+	if diff := cmp.Diff(want, xyz); diff != "" {
+		t.Error(diff)
+	}
+
+	xyz, err = ToXyzFormat(mesh, VertexNormals(mesh), []float32{0.5, 1.5, 2.5})
+	if err != nil {
+		t.Fatalf("ToXyzFormat with normals and intensity failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(xyz), "\n")
+	if diff := cmp.Diff("1.000000 0.000000 0.000000 0.000000 0.000000 1.000000 1.500000", lines[1]); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := ToXyzFormat(mesh, nil, []float32{1.0}); err == nil {
+		t.Errorf("got no error for wrong number of intensity values, wanted one")
+	}
+	if _, err := ToXyzFormat(mesh, []float32{1.0}, nil); err == nil {
+		t.Errorf("got no error for wrong number of normal values, wanted one")
+	}
+}
+
+func TestToPcdFormat(t *testing.T) {
+	mesh := GenerateCube()
+	intensity := make([]float32, NumVertices(mesh))
+	for i := range intensity {
+		intensity[i] = float32(i)
+	}
+
+	for _, asBinary := range []bool{false, true} {
+		bs, err := ToPcdFormat(mesh, VertexNormals(mesh), intensity, asBinary)
+		if err != nil {
+			t.Fatalf("ToPcdFormat failed: %v", err)
+		}
+		headerEnd := bytes.Index(bs, []byte("DATA "))
+		dataStart := headerEnd + bytes.IndexByte(bs[headerEnd:], '\n') + 1
+		header := strings.Split(string(bs[:dataStart]), "\n")
+		if diff := cmp.Diff("FIELDS x y z normal_x normal_y normal_z intensity", header[2]); diff != "" {
+			t.Error(diff)
+		}
+		if diff := cmp.Diff("SIZE 4 4 4 4 4 4 4", header[3]); diff != "" {
+			t.Error(diff)
+		}
+		if diff := cmp.Diff("POINTS 8", header[9]); diff != "" {
+			t.Error(diff)
+		}
+
+		data := bs[dataStart:]
+		if asBinary {
+			if len(data) != 8*7*4 {
+				t.Fatalf("got %d bytes of binary data, want %d", len(data), 8*7*4)
+			}
+			values := make([]float32, 8*7)
+			binary.Read(bytes.NewReader(data), binary.LittleEndian, values)
+			if diff := cmp.Diff(mesh.Vertices[3:6], values[7:10]); diff != "" {
+				t.Error(diff)
+			}
+			if values[7*8-1] != 7 {
+				t.Errorf("got intensity %f for last point, want 7", values[7*8-1])
+			}
+		} else if len(strings.Split(strings.TrimSpace(string(data)), "\n")) != 8 {
+			t.Errorf("got ASCII data %q, want 8 lines", data)
+		}
+	}
+}
+
+func TestExportPointCloud(t *testing.T) {
+	mesh := GenerateCube()
+	dir := t.TempDir()
+
+	xyzFile := filepath.Join(dir, "cube.xyz")
+	if err := ExportPointCloud(mesh, xyzFile, "auto", false, true, nil); err != nil {
+		t.Fatalf("ExportPointCloud failed: %v", err)
+	}
+	bs, _ := os.ReadFile(xyzFile)
+	if lines := strings.Split(strings.TrimSpace(string(bs)), "\n"); len(lines) != 8 || len(strings.Fields(lines[0])) != 6 {
+		t.Errorf("got unexpected XYZ file contents %q", bs)
+	}
+
+	pcdFile := filepath.Join(dir, "cube.pcd")
+	if err := ExportPointCloud(mesh, pcdFile, "auto", true, false, nil); err != nil {
+		t.Fatalf("ExportPointCloud failed: %v", err)
+	}
+	bs, _ = os.ReadFile(pcdFile)
+	if !bytes.Contains(bs, []byte("FIELDS x y z\n")) || !bytes.Contains(bs, []byte("DATA binary\n")) {
+		t.Errorf("got unexpected PCD header in %q", bs)
+	}
+
+	if err := ExportPointCloud(mesh, filepath.Join(dir, "cube.las"), "auto", false, false, nil); err == nil {
+		t.Errorf("got no error for unknown point cloud file extension, wanted one")
+	}
+}